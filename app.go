@@ -19,15 +19,21 @@ import (
 	"intel/isecl/lib/common/v5/middleware"
 	cos "intel/isecl/lib/common/v5/os"
 	"intel/isecl/lib/common/v5/setup"
+	"intel/isecl/sqvs/v5/audit"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/quoteverifier"
+	"intel/isecl/sqvs/v5/replay"
 	"intel/isecl/sqvs/v5/resource"
+	"intel/isecl/sqvs/v5/resource/collateral"
 	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/grpcapi"
 	"intel/isecl/sqvs/v5/tasks"
 	"intel/isecl/sqvs/v5/version"
 	"io"
 	"io/ioutil"
 	stdlog "log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -41,6 +47,8 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type App struct {
@@ -55,6 +63,10 @@ type App struct {
 	LogWriter      io.Writer
 	HTTPLogWriter  io.Writer
 	SecLogWriter   io.Writer
+	// SCSClient fetches the collateral of the collateral commands, a client of the configured SCS when nil
+	SCSClient domain.HttpClient
+	// CollateralOwner returns the uid and gid the collateral store is handed over to, the sqvs user when nil
+	CollateralOwner func() (int, int, error)
 }
 
 func (a *App) printUsage() {
@@ -64,6 +76,14 @@ func (a *App) printUsage() {
 	fmt.Fprintln(w, "    sqvs <command> [arguments]")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "Available Commands:")
+	fmt.Fprintln(w, "    audit verify [--dir=] [--key=]	Verify the hash chain of the audit log, keyed with the key in /etc/sqvs/audit.key by default")
+	fmt.Fprintln(w, "    collateral <command>	Manage the collateral store, commands are:")
+	fmt.Fprintln(w, "        fetch --fmspc=<fmspc,...> [--ca=processor,platform] [--timeout=1m]	Fetch collateral from SCS into the store")
+	fmt.Fprintln(w, "        list			List the stored collateral")
+	fmt.Fprintln(w, "        show <key>		Show a stored collateral entry, e.g. /tcb?fmspc=00906ed50000")
+	fmt.Fprintln(w, "        import <file>		Import a collateral bundle (.tar.gz)")
+	fmt.Fprintln(w, "        export <file>		Export the stored collateral as a bundle (.tar.gz)")
+	fmt.Fprintln(w, "        purge			Remove all the stored collateral")
 	fmt.Fprintln(w, "    help|-h|--help		Show this help message")
 	fmt.Fprintln(w, "    setup [task]		Run setup task")
 	fmt.Fprintln(w, "    start			Start sqvs")
@@ -92,8 +112,53 @@ func (a *App) printUsage() {
 	fmt.Fprintln(w, "                                 - SQVS_LOG_MAX_LENGTH                               : SGX Verification Service Log maximum length")
 	fmt.Fprintln(w, "                                 - SQVS_ENABLE_CONSOLE_LOG                           : SGX Verification Service Enable standard output")
 	fmt.Fprintln(w, "                                 - SQVS_INCLUDE_TOKEN                                : Boolean value to decide whether to use token based auth or no auth for quote verifier API")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_CERT_AUTH                             : Boolean value to enable client certificate based auth for quote verifier API")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_CERT_REQUIRED                         : Boolean value to reject TLS connections without client certificate")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_CA_DIR                                : Directory of trusted client CA certificates")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_CERT_ROLE_MAPPINGS                    : JSON array of the roles granted to client certificates by subject or SAN, with the role context binding a tenant")
+	fmt.Fprintln(w, "                                 - SQVS_GLOBAL_RATE_LIMIT                            : Maximum quote verification requests per second across all clients, 0 to disable")
+	fmt.Fprintln(w, "                                 - SQVS_GLOBAL_RATE_BURST                            : Burst size for the global rate limit")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_RATE_LIMIT                            : Maximum quote verification requests per second per client, 0 to disable")
+	fmt.Fprintln(w, "                                 - SQVS_CLIENT_RATE_BURST                            : Burst size for the per client rate limit")
+	fmt.Fprintln(w, "                                 - SQVS_TENANTS                                      : JSON object of the tenants identified through the QuoteVerifier role context and their restrictions")
+	fmt.Fprintln(w, "                                 - SQVS_MAX_CONCURRENT_VERIFICATIONS                 : Maximum number of quote verifications in flight, -1 for no limit")
+	fmt.Fprintln(w, "                                 - SQVS_MAX_QUEUED_VERIFICATIONS                     : Maximum number of quote verifications waiting for a free slot, -1 for no queue")
+	fmt.Fprintln(w, "                                 - SQVS_VERIFICATION_QUEUE_TIMEOUT                   : Maximum time a quote verification waits for a free slot")
+	fmt.Fprintln(w, "                                 - SQVS_AUDIT_LOG_ENABLED                            : Boolean value to enable audit log of verification decisions")
+	fmt.Fprintln(w, "                                 - SQVS_AUDIT_LOG_DIR                                : Directory of the audit log files")
+	fmt.Fprintln(w, "                                 - SQVS_AUDIT_LOG_MAX_SIZE                           : Audit log file size in MB after which it is rotated")
+	fmt.Fprintln(w, "                                 - SQVS_AUDIT_LOG_MAX_AGE                            : Audit log file age after which it is rotated")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_EXPIRY_GRACE                      : Time past nextUpdate during which expired collateral is accepted and reported as expired")
+	fmt.Fprintln(w, "                                 - SQVS_MIN_TCB_EVALUATION_DATA_NUMBER               : Minimum tcbEvaluationDataNumber of TCB info and QE identity, raised after TCB recovery events")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_DETECTION_ENABLED                     : Boolean value to enable detection of quotes verified again, reported by the v2 and gRPC APIs")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_KEY                                   : Identity of quotes for replay detection, quote (default) or reportData")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_TTL                                   : Time verified quotes are remembered for replay detection")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_MAX_ENTRIES                           : Maximum number of verified quotes remembered for replay detection")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_BACKEND                               : Backend of the replay detection, memory (default) or file")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_FILE                                  : File of the file backend of the replay detection")
+	fmt.Fprintln(w, "                                 - SQVS_REPLAY_STRICT                                : Boolean value to reject replayed quotes instead of reporting them, on all APIs")
+	fmt.Fprintln(w, "                                 - SQVS_VERIFICATION_TIMEOUT                         : Maximum time the verification of a quote may take, below SQVS_SERVER_WRITE_TIMEOUT, 0 disables the deadline")
+	fmt.Fprintln(w, "                                 - SQVS_SHUTDOWN_TIMEOUT                             : Maximum time to drain in-flight verifications on shutdown")
+	fmt.Fprintln(w, "                                 - SQVS_SHUTDOWN_DELAY                               : Time between failing the readiness checks and stopping the servers on shutdown, 0 disables the delay")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_REFRESH_ENABLED                   : Boolean value to enable background refresh of collateral")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_PREFETCH_FMSPCS                   : Comma separated FMSPCs whose collateral is prefetched")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_REFRESH_INTERVAL                  : Period at which collateral due for a refresh is looked for")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_REFRESH_AHEAD                     : Time before nextUpdate at which collateral is refreshed")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_MAX_AGE                           : Time after which cached collateral is refreshed regardless of its nextUpdate")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_STORE_ENABLED                     : Boolean value to enable persistence of collateral")
+	fmt.Fprintln(w, "                                 - SQVS_COLLATERAL_DIR                               : Directory of the persisted collateral")
+	fmt.Fprintln(w, "                                 - SQVS_INCLUDE_PPID                                 : Boolean value to report the PPID of platforms in verification responses and audit records, false by default as it identifies the physical platform")
+	fmt.Fprintln(w, "                                 - SQVS_PLATFORM_REJECT_SMT_ENABLED                  : Boolean value to reject quotes from Platform CA certified platforms with SMT enabled")
+	fmt.Fprintln(w, "                                 - SQVS_PLATFORM_REJECT_DYNAMIC_PLATFORM             : Boolean value to reject quotes from Platform CA certified dynamic platforms")
+	fmt.Fprintln(w, "                                 - SQVS_GRPC_PORT                                    : gRPC quote verification API port, the gRPC API is disabled when not set")
 	fmt.Fprintln(w, "                                 - SGX_TRUSTED_ROOT_CA_PATH                          : SQVS Trusted Root CA")
 	fmt.Fprintln(w, "                                 - SCS_BASE_URL                                      : SGX Caching Service URL")
+	fmt.Fprintln(w, "                                 - SCS_TIMEOUT                                       : Timeout of a single SGX Caching Service request")
+	fmt.Fprintln(w, "                                 - SCS_MAX_RETRIES                                   : Number of retries of failed SGX Caching Service requests, negative to disable")
+	fmt.Fprintln(w, "                                 - SCS_RETRY_BACKOFF                                 : Initial backoff between SGX Caching Service request retries")
+	fmt.Fprintln(w, "                                 - SCS_MAX_RETRY_BACKOFF                             : Maximum backoff between SGX Caching Service request retries")
+	fmt.Fprintln(w, "                                 - SCS_BREAKER_THRESHOLD                             : Consecutive failed SGX Caching Service requests after which requests fail fast")
+	fmt.Fprintln(w, "                                 - SCS_BREAKER_COOLDOWN                              : Time after which a failed SGX Caching Service is tried again")
 	fmt.Fprintln(w, "                                 - AAS_API_URL                                       : AAS API URL")
 	fmt.Fprintln(w, "")
 	fmt.Fprintln(w, "    download_ca_cert         Download CMS root CA certificate")
@@ -273,6 +338,10 @@ func (a *App) Run(args []string) error {
 		a.uninstall(purge)
 		log.Info("app:Run() Uninstalled SGX Verification Service")
 		os.Exit(0)
+	case "audit":
+		return a.audit(args[2:])
+	case "collateral":
+		return a.collateral(args[2:])
 	case "version", "--version", "-v":
 		fmt.Println(version.GetVersion())
 		return nil
@@ -364,19 +433,9 @@ func (a *App) Run(args []string) error {
 			return nil
 		}
 
-		sqvsUser, err := user.Lookup(constants.SQVSUserName)
+		uid, gid, err := sqvsUserIDs()
 		if err != nil {
-			return errors.Wrapf(err, "Could not find user '%s'", constants.SQVSUserName)
-		}
-
-		uid, err := strconv.Atoi(sqvsUser.Uid)
-		if err != nil {
-			return errors.Wrapf(err, "Could not parse sqvs user uid '%s'", sqvsUser.Uid)
-		}
-
-		gid, err := strconv.Atoi(sqvsUser.Gid)
-		if err != nil {
-			return errors.Wrapf(err, "Could not parse sqvs user gid '%s'", sqvsUser.Gid)
+			return err
 		}
 
 		// Change the file ownership to sqvs user
@@ -417,22 +476,104 @@ func (a *App) startServer() error {
 	r := mux.NewRouter()
 	r.SkipClean(true)
 
-	// set version endpoint
+	// set version and readiness endpoints
+	readiness := resource.NewReadiness()
 	sr := r.PathPrefix("/svs/v{version:[1-2]}/").Subrouter()
 	func(setters ...func(*mux.Router)) {
 		for _, setter := range setters {
 			setter(sr)
 		}
-	}(resource.SetVersionRoutes)
+	}(resource.SetVersionRoutes, readiness.SetRoutes)
+
+	if c.Audit.Enabled {
+		auditDir := c.Audit.Dir
+		if auditDir == "" {
+			auditDir = constants.AuditLogDir
+		}
+		// the key is kept apart from the audit log, so that access to the log alone does not allow rewriting it
+		auditKey, err := audit.LoadKey(constants.AuditKeyFile, true)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not load audit log key")
+		}
+		auditLogger, err := audit.NewLogger(auditDir, auditKey, int64(c.Audit.MaxSizeMB)<<20, c.Audit.MaxAge)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not open audit log")
+		}
+		audit.SetDefault(auditLogger)
+		defer func() {
+			audit.SetDefault(nil)
+			if err := auditLogger.Close(); err != nil {
+				log.WithError(err).Error("app:startServer() Failed to close audit log")
+			}
+		}()
+	}
+
+	if c.Replay.Enabled {
+		replayCache, err := newReplayCache(c)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not create replay cache")
+		}
+		replay.SetDefault(replayCache)
+		readiness.AddHealthCheck("replay", replayCache.Health)
+		defer func() {
+			replay.SetDefault(nil)
+			if err := replayCache.Close(); err != nil {
+				log.WithError(err).Error("app:startServer() Failed to close replay cache")
+			}
+		}()
+	}
 
+	tenantAuthorizer := resource.NewTenantAuthorizer(c.Tenants)
+	throttler := resource.NewThrottler(c.RateLimit)
 	sr = r.PathPrefix("/svs/v1/").Subrouter()
-	if c.IncludeToken {
-		sr.Use(middleware.NewTokenAuth(constants.TrustedJWTSigningCertsDir, constants.TrustedCAsStoreDir, fnGetJwtCerts,
-			time.Minute*constants.DefaultJwtValidateCacheKeyMins))
+	if c.AuthorizationEnabled() {
+		sr.Use(authMiddleware(c))
+		sr.Use(tenantAuthorizer.Middleware(constants.EndpointV1))
 	}
+	sr.Use(throttler.Middleware())
 
-	scsClient := domain.NewSCSClient(constants.TrustedCAsStoreDir)
-	sqxQuoteVerifier := resource.NewSGXEcdsaQuoteVerifier()
+	scsClient, err := domain.NewSCSClient(constants.TrustedCAsStoreDir, c.SCSClient)
+	if err != nil {
+		return errors.Wrap(err, "app:startServer() Could not create SCS client")
+	}
+	var refresher *collateral.Refresher
+	if c.CollateralRefresh.Enabled || c.CollateralStore.Enabled {
+		trustAnchors, err := quoteverifier.LoadTrustAnchors(constants.TrustedSGXRootCAFile)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not read the trusted SGX root CAs")
+		}
+		cache, err := collateral.NewCache(scsClient, c.SCSBaseURL, trustAnchors)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not create collateral cache")
+		}
+		cache.SetMaxAge(c.CollateralRefresh.MaxAge)
+		if c.CollateralStore.Enabled {
+			store, err := newCollateralStore(c)
+			if err != nil {
+				return errors.Wrap(err, "app:startServer() Could not open collateral store")
+			}
+			if err := cache.UseStore(store); err != nil {
+				return errors.Wrap(err, "app:startServer() Could not load stored collateral")
+			}
+		}
+		scsClient = cache
+		if c.CollateralRefresh.Enabled {
+			refresher = collateral.NewRefresher(cache, c.CollateralRefresh)
+			readiness.AddHealthCheck("collateralRefresh", refresher.Health)
+		}
+	}
+	evaluationData, err := quoteverifier.NewEvaluationDataTracker(constants.TcbEvaluationDataFile)
+	if err != nil {
+		return errors.Wrap(err, "app:startServer() Could not load TCB evaluation data numbers")
+	}
+	readiness.AddHealthCheck("tcbEvaluationData", func() (interface{}, error) {
+		return evaluationData.Numbers(), nil
+	})
+	sqxQuoteVerifier, err := resource.NewSGXEcdsaQuoteVerifier(c, scsClient, constants.TrustedSGXRootCAFile,
+		quoteverifier.WithEvaluationDataTracker(evaluationData))
+	if err != nil {
+		return errors.Wrap(err, "app:startServer() Could not create quote verifier")
+	}
 	func(setters ...func(*mux.Router, *config.Configuration, domain.HttpClient, string, domain.SGXQuoteVerifier)) {
 		for _, setter := range setters {
 			setter(sr, c, scsClient, constants.TrustedSGXRootCAFile, sqxQuoteVerifier)
@@ -440,10 +581,12 @@ func (a *App) startServer() error {
 	}(resource.QuoteVerifyCB)
 
 	sr = r.PathPrefix("/svs/v2/").Subrouter()
-	if c.IncludeToken {
-		sr.Use(middleware.NewTokenAuth(constants.TrustedJWTSigningCertsDir, constants.TrustedCAsStoreDir, fnGetJwtCerts,
-			time.Minute*constants.DefaultJwtValidateCacheKeyMins))
+	sr.Use(resource.AuditRejections(constants.EndpointV2))
+	if c.AuthorizationEnabled() {
+		sr.Use(authMiddleware(c))
+		sr.Use(tenantAuthorizer.Middleware(constants.EndpointV2))
 	}
+	sr.Use(throttler.Middleware())
 	func(setters ...func(*mux.Router, *config.Configuration, domain.HttpClient, string, domain.SGXQuoteVerifier, string, string)) {
 		for _, setter := range setters {
 			setter(sr, c, scsClient, constants.TrustedSGXRootCAFile, sqxQuoteVerifier, constants.PrivateKeyLocation, constants.PublicKeyLocation)
@@ -457,10 +600,53 @@ func (a *App) startServer() error {
 			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
 			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
 	}
+	if c.ClientCertAuth.Enabled {
+		clientCAs, err := loadClientCAs(c.ClientCertAuth.CADir)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not load trusted client CA certificates")
+		}
+		tlsconfig.ClientCAs = clientCAs
+		tlsconfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.ClientCertAuth.Required {
+			tlsconfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
 	// Setup signal handlers to gracefully handle termination
-	stop := make(chan os.Signal)
+	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	// serverFailed stops the service when a server could not be started, without blocking when another
+	// server already failed
+	serverErr := make(chan error, 1)
+	serverFailed := func(err error) {
+		select {
+		case serverErr <- err:
+		default:
+		}
+	}
+
+	var grpcServer *grpc.Server
+	if c.GRPCPort != 0 {
+		grpcServer, err = a.newGRPCServer(c, tlsconfig, tenantAuthorizer, throttler, scsClient, sqxQuoteVerifier,
+			readiness)
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not create gRPC server")
+		}
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", c.GRPCPort))
+		if err != nil {
+			return errors.Wrap(err, "app:startServer() Could not listen on gRPC port")
+		}
+		// dispatch gRPC server go routine
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.WithError(err).Info("Failed to start gRPC server")
+				serverFailed(errors.Wrap(err, "Failed to start gRPC server"))
+			}
+		}()
+	}
 	httpLog := stdlog.New(a.httpLogWriter(), "", 0)
+	// requests are served with a context cancelled when they outlast the drain on shutdown
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	h := &http.Server{
 		Addr:              fmt.Sprintf(":%d", c.Port),
 		Handler:           handlers.RecoveryHandler(handlers.RecoveryLogger(httpLog), handlers.PrintRecoveryStack(true))(handlers.CombinedLoggingHandler(a.httpLogWriter(), r)),
@@ -471,6 +657,9 @@ func (a *App) startServer() error {
 		WriteTimeout:      c.WriteTimeout,
 		IdleTimeout:       c.IdleTimeout,
 		MaxHeaderBytes:    c.MaxHeaderBytes,
+		BaseContext: func(net.Listener) context.Context {
+			return requestCtx
+		},
 	}
 
 	// dispatch web server go routine
@@ -479,26 +668,229 @@ func (a *App) startServer() error {
 		if conf != nil {
 			tlsCert := conf.TLSCertFile
 			tlsKey := conf.TLSKeyFile
-			if err := h.ListenAndServeTLS(tlsCert, tlsKey); err != nil {
+			if err := h.ListenAndServeTLS(tlsCert, tlsKey); err != nil && err != http.ErrServerClosed {
 				log.WithError(err).Info("Failed to start HTTPS server")
-				stop <- syscall.SIGTERM
+				serverFailed(errors.Wrap(err, "Failed to start HTTPS server"))
 			}
 		}
 	}()
 
+	if refresher != nil {
+		refresher.Start()
+	}
+	readiness.SetReady(true)
 	slog.Info(commLogMsg.ServiceStart)
 	// TODO dispatch Service status checker goroutine
-	<-stop
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	var startErr error
+	select {
+	case sig := <-stop:
+		log.Infof("app:startServer() Received %v, shutting down", sig)
+	case startErr = <-serverErr:
+	}
+
+	// fail the readiness checks first and give load balancers time to stop routing new verifications to the
+	// service, then wait for the in-flight ones
+	readiness.SetReady(false)
+	if startErr == nil && c.ShutdownDelay > 0 {
+		log.Infof("app:startServer() Waiting %s before stopping the servers", c.ShutdownDelay)
+		select {
+		case <-time.After(c.ShutdownDelay):
+		case <-stop:
+		}
+	}
+	shutdownTimeout := c.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = constants.DefaultShutdownTimeout
+	}
+	log.Infof("app:startServer() Draining for up to %s", shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
-	if err := h.Shutdown(ctx); err != nil {
-		log.WithError(err).Info("Failed to gracefully shutdown webserver")
-		return err
+	steps := []shutdownStep{{name: "HTTPS server", stop: stopHTTPServer(h, cancelRequests)}}
+	if grpcServer != nil {
+		steps = append(steps, shutdownStep{name: "gRPC server", stop: stopGRPCServer(grpcServer)})
+	}
+	if refresher != nil {
+		steps = append(steps, shutdownStep{name: "collateral refresher", stop: refresher.Stop})
 	}
+	// drain failures are logged by drain, a server that failed to start is reported in their place
+	err = drain(ctx, steps)
+	if startErr != nil {
+		return errors.Wrap(startErr, "app:startServer() Could not start server")
+	}
+	if err != nil {
+		return errors.Wrap(err, "app:startServer() Could not drain in-flight verifications")
+	}
+	log.Info("app:startServer() Drained in-flight verifications")
 	slog.Info(commLogMsg.ServiceStop)
 	return nil
 }
 
+// sqvsUserIDs returns the uid and gid of the sqvs user
+func sqvsUserIDs() (int, int, error) {
+	sqvsUser, err := user.Lookup(constants.SQVSUserName)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Could not find user '%s'", constants.SQVSUserName)
+	}
+
+	uid, err := strconv.Atoi(sqvsUser.Uid)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Could not parse sqvs user uid '%s'", sqvsUser.Uid)
+	}
+
+	gid, err := strconv.Atoi(sqvsUser.Gid)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Could not parse sqvs user gid '%s'", sqvsUser.Gid)
+	}
+	return uid, gid, nil
+}
+
+// newCollateralStore opens the collateral store, whose collateral is verified against the trusted SGX root CAs
+func newCollateralStore(c *config.Configuration) (*collateral.Store, error) {
+	trustAnchors, err := quoteverifier.LoadTrustAnchors(constants.TrustedSGXRootCAFile)
+	if err != nil {
+		return nil, err
+	}
+	dir := c.CollateralStore.Dir
+	if dir == "" {
+		dir = constants.CollateralDir
+	}
+	return collateral.NewStore(dir, trustAnchors)
+}
+
+// newReplayCache returns the replay cache of the configured backend, unset values select the defaults
+func newReplayCache(c *config.Configuration) (*replay.Cache, error) {
+	maxEntries := c.Replay.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = constants.DefaultReplayMaxEntries
+	}
+	var backend replay.Backend
+	var err error
+	switch c.Replay.Backend {
+	case "", constants.ReplayBackendMemory:
+		backend, err = replay.NewMemoryBackend(maxEntries)
+	case constants.ReplayBackendFile:
+		file := c.Replay.File
+		if file == "" {
+			file = constants.ReplayCacheFile
+		}
+		backend, err = replay.NewFileBackend(file, maxEntries, time.Now())
+	default:
+		return nil, errors.Errorf("Invalid replay backend %s", c.Replay.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyBy := c.Replay.KeyBy
+	if keyBy == "" {
+		keyBy = constants.ReplayKeyQuote
+	}
+	ttl := c.Replay.TTL
+	if ttl <= 0 {
+		ttl = constants.DefaultReplayTTL
+	}
+	cache, err := replay.NewCache(backend, keyBy, ttl)
+	if err != nil {
+		_ = backend.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+// newGRPCServer returns the gRPC server of the quote verifier API. It serves with the TLS configuration of the
+// REST API and authorizes calls with the middlewares of the REST endpoints.
+func (a *App) newGRPCServer(c *config.Configuration, tlsconfig *tls.Config, tenantAuthorizer *resource.TenantAuthorizer,
+	throttler *resource.Throttler, scsClient domain.HttpClient, sqxQuoteVerifier domain.SGXQuoteVerifier,
+	readiness *resource.Readiness) (*grpc.Server, error) {
+	tlsCert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not load TLS certificate")
+	}
+	grpcTLSConfig := tlsconfig.Clone()
+	grpcTLSConfig.Certificates = []tls.Certificate{tlsCert}
+
+	var auth mux.MiddlewareFunc
+	if c.AuthorizationEnabled() {
+		auth = authMiddleware(c)
+	}
+	middlewares := func(endpoint string) []mux.MiddlewareFunc {
+		if auth == nil {
+			return []mux.MiddlewareFunc{resource.AuditRejections(endpoint), throttler.Middleware()}
+		}
+		return []mux.MiddlewareFunc{resource.AuditRejections(endpoint), auth, tenantAuthorizer.Middleware(endpoint),
+			throttler.Middleware()}
+	}
+
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(grpcTLSConfig)))
+	grpcapi.RegisterQuoteVerifierServer(grpcServer, resource.NewGRPCQuoteVerifier(c, scsClient,
+		constants.TrustedSGXRootCAFile, sqxQuoteVerifier, constants.PrivateKeyLocation, constants.PublicKeyLocation,
+		middlewares, readiness))
+	return grpcServer, nil
+}
+
+// authMiddleware returns the authentication middleware for the quote verifier APIs. Client certificate
+// authentication falls back to token authentication when both are enabled.
+func authMiddleware(c *config.Configuration) mux.MiddlewareFunc {
+	var tokenAuth mux.MiddlewareFunc
+	if c.IncludeToken {
+		tokenAuth = middleware.NewTokenAuth(constants.TrustedJWTSigningCertsDir, constants.TrustedCAsStoreDir, fnGetJwtCerts,
+			time.Minute*constants.DefaultJwtValidateCacheKeyMins)
+	}
+	if c.ClientCertAuth.Enabled {
+		return resource.NewClientCertAuth(c.ClientCertAuth.RoleMappings, tokenAuth)
+	}
+	return tokenAuth
+}
+
+func loadClientCAs(caDir string) (*x509.CertPool, error) {
+	if caDir == "" {
+		caDir = constants.TrustedClientCAsDir
+	}
+	caPems, err := cos.GetDirFileContents(caDir, "*.pem")
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read client CA certificates")
+	}
+	clientCAs := x509.NewCertPool()
+	for _, caPem := range caPems {
+		if !clientCAs.AppendCertsFromPEM(caPem) {
+			return nil, errors.New("Could not parse client CA certificate")
+		}
+	}
+	return clientCAs, nil
+}
+
+// audit runs the audit log subcommands
+func (a *App) audit(args []string) error {
+	if len(args) < 1 || args[0] != "verify" {
+		a.printUsage()
+		return errors.New("app:audit() Unrecognized audit command")
+	}
+	auditDir := a.configuration().Audit.Dir
+	if auditDir == "" {
+		auditDir = constants.AuditLogDir
+	}
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	fs.StringVar(&auditDir, "dir", auditDir, "audit log directory")
+	keyFile := fs.String("key", constants.AuditKeyFile, "audit log key file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return errors.Wrap(err, "app:audit() Could not parse input flags")
+	}
+
+	key, err := audit.LoadKey(*keyFile, false)
+	if err != nil {
+		return errors.Wrap(err, "app:audit() Could not load audit log key")
+	}
+	result, err := audit.Verify(auditDir, key)
+	if err != nil {
+		fmt.Fprintln(a.consoleWriter(), "Audit log verification failed:", err.Error())
+		return errors.Wrap(err, "app:audit() Audit log verification failed")
+	}
+	fmt.Fprintf(a.consoleWriter(), "Audit log verified: %d records in %d files, starting at record %d\n",
+		result.Records, result.Files, result.FirstSequence)
+	fmt.Fprintf(a.consoleWriter(), "Last record: %d, hash: %s\n", result.LastSequence, result.LastHash)
+	return nil
+}
+
 func (a *App) start() error {
 	fmt.Fprintln(a.consoleWriter(), `Forwarding to "systemctl start sqvs"`)
 	systemctl, err := exec.LookPath("systemctl")
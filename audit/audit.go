@@ -0,0 +1,404 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+// Package audit keeps a hash chained log of attestation decisions. Each record is linked to its predecessor
+// by an HMAC keyed with a secret held outside the audit log directory, so that records can neither be altered,
+// removed from or inserted into the chain without the key. The chain has limits an operator must cover:
+// records removed from its end, or the oldest files removed together, leave a valid chain behind. Verify
+// reports the first and last record of the chain, which should be compared with an independently kept copy,
+// for instance forwarded to a remote log. Whoever can read the key can also rewrite the whole chain.
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// CurrentFile is the name of the audit log file records are appended to
+	CurrentFile = "audit.log"
+	// rotated files are named audit-<UTC timestamp>.log so that lexical order is chronological order
+	rotatedPrefix   = "audit-"
+	rotatedSuffix   = ".log"
+	rotatedTimeFmt  = "20060102T150405.000000000Z"
+	maxRecordLength = 1 << 20
+	// KeySize is the size in bytes of the key generated by LoadKey
+	KeySize = 32
+)
+
+// Collateral identifies the versions of the collateral a verification decision was based on
+type Collateral struct {
+	TcbInfoVersion          int    `json:"tcbInfoVersion,omitempty"`
+	TcbInfoIssueDate        string `json:"tcbInfoIssueDate,omitempty"`
+	TcbEvaluationDataNumber uint   `json:"tcbEvaluationDataNumber,omitempty"`
+	QeIdentityVersion       uint16 `json:"qeIdentityVersion,omitempty"`
+	QeIdentityIssueDate     string `json:"qeIdentityIssueDate,omitempty"`
+	QeIdentityEvalDataNum   uint16 `json:"qeIdentityTcbEvaluationDataNumber,omitempty"`
+	Expiry                  string `json:"expiry,omitempty"`
+	Expired                 bool   `json:"expired,omitempty"`
+}
+
+// Record is a single attestation decision, or a verification request rejected before a decision was made.
+// PrevHash links the record to its predecessor and Hash covers every other field of the record, so that
+// altering, removing or reordering records breaks the chain.
+type Record struct {
+	Sequence         uint64      `json:"seq"`
+	Timestamp        time.Time   `json:"timestamp"`
+	Caller           string      `json:"caller,omitempty"`
+	Tenant           string      `json:"tenant,omitempty"`
+	Endpoint         string      `json:"endpoint,omitempty"`
+	QuoteHash        string      `json:"quoteHash,omitempty"`
+	MrEnclave        string      `json:"mrEnclave,omitempty"`
+	MrSigner         string      `json:"mrSigner,omitempty"`
+	Fmspc            string      `json:"fmspc,omitempty"`
+	Ppid             string      `json:"ppid,omitempty"`
+	PlatformInstance string      `json:"platformInstanceId,omitempty"`
+	TcbStatus        string      `json:"tcbStatus,omitempty"`
+	CollateralSource string      `json:"collateralSource,omitempty"`
+	VerificationTime string      `json:"verificationTime,omitempty"`
+	Collateral       *Collateral `json:"collateral,omitempty"`
+	ReplayStatus     string      `json:"replayStatus,omitempty"`
+	Result           string      `json:"result"`
+	Message          string      `json:"message,omitempty"`
+	ResponseHash     string      `json:"responseHash,omitempty"`
+	PrevHash         string      `json:"prevHash"`
+	Hash             string      `json:"hash"`
+}
+
+// computeHash returns the hex encoded HMAC-SHA256 of the record serialized with an empty Hash field
+func (rec Record) computeHash(key []byte) (string, error) {
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", errors.Wrap(err, "Could not marshal audit record")
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// LoadKey reads the key the audit chain is keyed with from file. A random key is generated and written to
+// file when it does not exist and create is set.
+func LoadKey(file string, create bool) ([]byte, error) {
+	key, err := ioutil.ReadFile(file)
+	if err == nil {
+		if len(key) < KeySize {
+			return nil, errors.Errorf("Audit log key in %s is shorter than %d bytes", file, KeySize)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, errors.Wrap(err, "Could not read audit log key")
+	}
+
+	key = make([]byte, KeySize)
+	if _, err = rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "Could not generate audit log key")
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not create audit log key file")
+	}
+	_, err = f.Write(key)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not write audit log key")
+	}
+	return key, nil
+}
+
+// Logger appends hash chained records to JSON lines files in a directory. The current file is rotated
+// once it grows beyond MaxSize bytes or is older than MaxAge; zero values disable the respective check.
+type Logger struct {
+	mu       sync.Mutex
+	dir      string
+	key      []byte
+	maxSize  int64
+	maxAge   time.Duration
+	file     *os.File
+	size     int64
+	opened   time.Time
+	lastSeq  uint64
+	lastHash string
+	now      func() time.Time
+}
+
+// NewLogger opens the audit log in dir, continuing the chain of any existing records. Records are chained
+// with an HMAC keyed with key.
+func NewLogger(dir string, key []byte, maxSize int64, maxAge time.Duration) (*Logger, error) {
+	if len(key) == 0 {
+		return nil, errors.New("Audit log key is empty")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "Could not create audit log directory")
+	}
+	l := &Logger{
+		dir:     dir,
+		key:     key,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		now:     time.Now,
+	}
+
+	files, err := chainFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	// recover the tail of the chain from the most recent file holding records
+	for i := len(files) - 1; i >= 0; i-- {
+		records, err := readRecords(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(records) > 0 {
+			last := records[len(records)-1]
+			l.lastSeq, l.lastHash = last.Sequence, last.Hash
+			if filepath.Base(files[i]) == CurrentFile {
+				l.opened = records[0].Timestamp
+			}
+			break
+		}
+	}
+
+	if err = l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(filepath.Join(l.dir, CurrentFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Could not open audit log file")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "Could not stat audit log file")
+	}
+	l.file = f
+	l.size = fi.Size()
+	if l.size == 0 || l.opened.IsZero() {
+		l.opened = l.now()
+	}
+	return nil
+}
+
+func (l *Logger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return errors.Wrap(err, "Could not close audit log file")
+	}
+	rotated := filepath.Join(l.dir, rotatedPrefix+l.now().UTC().Format(rotatedTimeFmt)+rotatedSuffix)
+	if err := os.Rename(filepath.Join(l.dir, CurrentFile), rotated); err != nil {
+		return errors.Wrap(err, "Could not rotate audit log file")
+	}
+	l.opened = time.Time{}
+	return l.open()
+}
+
+// Log links the record to the chain and appends it to the audit log. Sequence, PrevHash and Hash are
+// filled in by the logger, Timestamp when it is not already set. Logging to a nil Logger is a no-op.
+func (l *Logger) Log(rec Record) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return errors.New("Audit log is closed")
+	}
+	if l.size > 0 && ((l.maxSize > 0 && l.size >= l.maxSize) || (l.maxAge > 0 && l.now().Sub(l.opened) >= l.maxAge)) {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if rec.Timestamp.IsZero() {
+		rec.Timestamp = l.now()
+	}
+	rec.Timestamp = rec.Timestamp.UTC()
+	rec.Sequence = l.lastSeq + 1
+	rec.PrevHash = l.lastHash
+	hash, err := rec.computeHash(l.key)
+	if err != nil {
+		return err
+	}
+	rec.Hash = hash
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "Could not marshal audit record")
+	}
+	line = append(line, '\n')
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "Could not write audit record")
+	}
+	if err = l.file.Sync(); err != nil {
+		return errors.Wrap(err, "Could not sync audit log file")
+	}
+	l.lastSeq, l.lastHash = rec.Sequence, rec.Hash
+	return nil
+}
+
+// Close closes the current audit log file
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultLogger *Logger
+)
+
+// SetDefault sets the logger returned by Default
+func SetDefault(l *Logger) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultLogger = l
+}
+
+// Default returns the service wide audit logger, nil when audit logging is disabled
+func Default() *Logger {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultLogger
+}
+
+// chainFiles returns the audit log files in dir in chain order, oldest rotated file first
+func chainFiles(dir string) ([]string, error) {
+	rotated, err := filepath.Glob(filepath.Join(dir, rotatedPrefix+"*"+rotatedSuffix))
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not list audit log files")
+	}
+	sort.Strings(rotated)
+	current := filepath.Join(dir, CurrentFile)
+	if _, err = os.Stat(current); err == nil {
+		rotated = append(rotated, current)
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "Could not stat audit log file")
+	}
+	return rotated, nil
+}
+
+func readRecords(file string) ([]Record, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not open audit log file")
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordLength)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var rec Record
+		if err = json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.Wrapf(err, "%s:%d: malformed audit record", file, line)
+		}
+		records = append(records, rec)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "Could not read audit log file %s", file)
+	}
+	return records, nil
+}
+
+// VerifyResult summarizes a successful chain verification
+type VerifyResult struct {
+	Files   int
+	Records int
+	// FirstSequence is the sequence number the verified chain starts at, greater than one when
+	// older files have been removed by retention
+	FirstSequence uint64
+	// LastSequence and LastHash identify the end of the verified chain, records removed from the end of the
+	// chain are only detected by comparing them with an earlier verification
+	LastSequence uint64
+	LastHash     string
+}
+
+// Verify checks the hash chain keyed with key across all audit log files in dir
+func Verify(dir string, key []byte) (*VerifyResult, error) {
+	if len(key) == 0 {
+		return nil, errors.New("Audit log key is empty")
+	}
+	files, err := chainFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("No audit log files found in %s", dir)
+	}
+
+	result := &VerifyResult{Files: len(files)}
+	var prev *Record
+	for _, file := range files {
+		records, err := readRecords(file)
+		if err != nil {
+			return nil, err
+		}
+		for i := range records {
+			rec := records[i]
+			hash, err := rec.computeHash(key)
+			if err != nil {
+				return nil, err
+			}
+			if !hmac.Equal([]byte(hash), []byte(rec.Hash)) {
+				return nil, errors.Errorf("%s: record %d has been modified: hash mismatch", file, rec.Sequence)
+			}
+			if prev == nil {
+				result.FirstSequence = rec.Sequence
+			} else {
+				if rec.Sequence != prev.Sequence+1 {
+					return nil, errors.Errorf("%s: record %d follows record %d: records missing or reordered",
+						file, rec.Sequence, prev.Sequence)
+				}
+				if rec.PrevHash != prev.Hash {
+					return nil, errors.Errorf("%s: record %d is not linked to record %d", file, rec.Sequence, prev.Sequence)
+				}
+			}
+			prev = &records[i]
+			result.Records++
+		}
+	}
+	if prev != nil {
+		result.LastSequence, result.LastHash = prev.Sequence, prev.Hash
+	}
+	return result, nil
+}
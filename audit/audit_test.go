@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestLogger(t *testing.T, maxSize int64, maxAge time.Duration) (*Logger, string) {
+	dir, err := ioutil.TempDir("", "sqvs-audit")
+	assert.NoError(t, err)
+	l, err := NewLogger(dir, testKey, maxSize, maxAge)
+	assert.NoError(t, err)
+	return l, dir
+}
+
+func TestLogAndVerify(t *testing.T) {
+	l, dir := newTestLogger(t, 0, 0)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Log(Record{QuoteHash: "abcd", Result: "SGX_QL_QV_RESULT_OK"}))
+	}
+	assert.NoError(t, l.Close())
+
+	result, err := Verify(dir, testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+	assert.Equal(t, uint64(1), result.FirstSequence)
+	assert.Equal(t, uint64(3), result.LastSequence)
+
+	// the chain continues after reopening
+	l, err = NewLogger(dir, testKey, 0, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Log(Record{Result: "SGX_QL_QV_RESULT_OK"}))
+	assert.NoError(t, l.Close())
+	result, err = Verify(dir, testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Records)
+	assert.Equal(t, uint64(4), result.LastSequence)
+}
+
+func TestRotation(t *testing.T) {
+	l, dir := newTestLogger(t, 1, 0)
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Log(Record{Result: "SGX_QL_QV_RESULT_OK"}))
+	}
+	assert.NoError(t, l.Close())
+
+	files, err := chainFiles(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 3)
+	result, err := Verify(dir, testKey)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+	assert.Equal(t, 3, result.Files)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	l, dir := newTestLogger(t, 0, 0)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Log(Record{Result: "SGX_QL_QV_RESULT_OK"}))
+	}
+	assert.NoError(t, l.Close())
+
+	file := filepath.Join(dir, CurrentFile)
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	// modified record
+	tampered := strings.Replace(string(data), "SGX_QL_QV_RESULT_OK", "SGX_QL_QV_RESULT_REVOKED", 1)
+	assert.NoError(t, ioutil.WriteFile(file, []byte(tampered), 0600))
+	_, err = Verify(dir, testKey)
+	assert.Error(t, err)
+
+	// removed record
+	assert.NoError(t, ioutil.WriteFile(file, []byte(lines[0]+lines[2]), 0600))
+	_, err = Verify(dir, testKey)
+	assert.Error(t, err)
+
+	// reordered records
+	assert.NoError(t, ioutil.WriteFile(file, []byte(lines[1]+lines[0]+lines[2]), 0600))
+	_, err = Verify(dir, testKey)
+	assert.Error(t, err)
+}
+
+func TestVerifyDetectsRewrittenChain(t *testing.T) {
+	l, dir := newTestLogger(t, 0, 0)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Log(Record{Result: "SGX_QL_QV_RESULT_OK"}))
+	}
+	assert.NoError(t, l.Close())
+	_, err := Verify(dir, []byte("another key"))
+	assert.Error(t, err)
+
+	// a chain rebuilt without the key does not verify, even though every record is linked to its predecessor
+	assert.NoError(t, os.Remove(filepath.Join(dir, CurrentFile)))
+	l, err = NewLogger(dir, []byte("another key"), 0, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Log(Record{Result: "SGX_QL_QV_RESULT_REVOKED"}))
+	assert.NoError(t, l.Close())
+	_, err = Verify(dir, testKey)
+	assert.Error(t, err)
+}
+
+func TestLoadKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqvs-audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "audit.key")
+
+	_, err = LoadKey(file, false)
+	assert.Error(t, err)
+
+	key, err := LoadKey(file, true)
+	assert.NoError(t, err)
+	assert.Len(t, key, KeySize)
+	fi, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	loaded, err := LoadKey(file, false)
+	assert.NoError(t, err)
+	assert.Equal(t, key, loaded)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("short"), 0600))
+	_, err = LoadKey(file, true)
+	assert.Error(t, err)
+}
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+	assert.NoError(t, l.Log(Record{}))
+	assert.NoError(t, l.Close())
+}
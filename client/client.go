@@ -0,0 +1,336 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+
+// Package client is a Go client of the SGX Quote Verification Service REST API. Responses signed by the
+// service can be checked with VerifySignedResponse.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout = 2 * time.Minute
+	// maxErrorBodySize bounds the part of an error response kept in a StatusError
+	maxErrorBodySize = 4096
+)
+
+// Client calls the SQVS REST API under a base URL such as https://sqvs.example.com:12000/svs
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	token      string
+}
+
+type clientOptions struct {
+	httpClient *http.Client
+	tlsConfig  *tls.Config
+	token      string
+	timeout    time.Duration
+}
+
+// Option configures a Client
+type Option func(*clientOptions) error
+
+// WithBearerToken authenticates requests with a bearer token issued by the authentication service
+func WithBearerToken(token string) Option {
+	return func(o *clientOptions) error {
+		if strings.TrimSpace(token) == "" {
+			return errors.New("bearer token is empty")
+		}
+		o.token = token
+		return nil
+	}
+}
+
+// WithRootCAs sets the CAs trusted to issue the TLS certificate of the service, the system pool is used
+// otherwise
+func WithRootCAs(rootCAs *x509.CertPool) Option {
+	return func(o *clientOptions) error {
+		o.tlsConfig.RootCAs = rootCAs
+		return nil
+	}
+}
+
+// WithClientCertificate authenticates requests with a TLS client certificate
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(o *clientOptions) error {
+		o.tlsConfig.Certificates = append(o.tlsConfig.Certificates, cert)
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of a request, including reading the response
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithHTTPClient sends requests with the given HTTP client, which then carries the TLS configuration and
+// timeout. It can not be combined with WithRootCAs, WithClientCertificate and WithTimeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) error {
+		if httpClient == nil {
+			return errors.New("HTTP client is nil")
+		}
+		o.httpClient = httpClient
+		return nil
+	}
+}
+
+// New returns a client of the service at baseURL
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "client:New() Invalid base URL")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, errors.New("client:New() Base URL must be an http or https URL")
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	o := clientOptions{
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+	for _, opt := range opts {
+		if err := opt(&o); err != nil {
+			return nil, errors.Wrap(err, "client:New() Invalid option")
+		}
+	}
+
+	httpClient := o.httpClient
+	if httpClient == nil {
+		timeout := o.timeout
+		if timeout == 0 {
+			timeout = defaultTimeout
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = o.tlsConfig
+		httpClient = &http.Client{Transport: transport, Timeout: timeout}
+	} else if o.timeout != 0 || o.tlsConfig.RootCAs != nil || len(o.tlsConfig.Certificates) != 0 {
+		return nil, errors.New("client:New() TLS and timeout options can not be combined with an HTTP client")
+	}
+
+	return &Client{
+		baseURL:    u,
+		httpClient: httpClient,
+		token:      o.token,
+	}, nil
+}
+
+// StatusError is returned for requests the service did not answer with 200 OK, it is the cause of the
+// error returned by the calls
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// QuoteInfo is the quote data of a v2 verification response
+type QuoteInfo struct {
+	ReportData        string `json:"ReportData,omitempty"`
+	UserDataHashMatch string `json:"UserDataMatch,omitempty"`
+	models.AdditionalQuoteData
+}
+
+// SignedSGXResponse is a v2 verification response signed by the service. QuoteData is the base64 encoded
+// JSON of a QuoteInfo and Signature the base64 encoded signature over QuoteData.
+type SignedSGXResponse struct {
+	QuoteData        string `json:"quoteData"`
+	Signature        string `json:"signature,omitempty"`
+	CertificateChain string `json:"certificateChain,omitempty"`
+}
+
+// VerifyQuoteResponse is the response of a v2 verification. Signed is only set when the service signed the
+// response, in which case QuoteInfo is decoded from it without checking the signature.
+type VerifyQuoteResponse struct {
+	QuoteInfo QuoteInfo
+	Signed    *SignedSGXResponse
+}
+
+// Version returns the version of the service
+func (c *Client) Version(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, http.MethodGet, "v1/version", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "client:Version() Request failed")
+	}
+	return string(body), nil
+}
+
+// HealthCheck is the health of a background component of the service, such as the collateral refresher
+type HealthCheck struct {
+	Status  string          `json:"status"`
+	Error   string          `json:"error,omitempty"`
+	Metrics json.RawMessage `json:"metrics,omitempty"`
+}
+
+// Health is the health of the service. Status is "ok", "degraded" when a health check fails or "not ready"
+// while the service is draining.
+type Health struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// Ready reports whether the service accepts new verifications, it is false while the service is draining
+func (c *Client) Ready(ctx context.Context) (bool, error) {
+	_, err := c.do(ctx, http.MethodGet, "v1/ready", nil)
+	if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusServiceUnavailable {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "client:Ready() Request failed")
+	}
+	return true, nil
+}
+
+// Health returns the health of the service and its background components. The health is also returned
+// while the service is draining, with status "not ready".
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	statusCode, body, err := c.send(ctx, http.MethodGet, "v1/health", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "client:Health() Request failed")
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusServiceUnavailable {
+		return nil, errors.Wrap(newStatusError(statusCode, body), "client:Health() Request failed")
+	}
+	var health Health
+	if err := json.Unmarshal(body, &health); err != nil {
+		return nil, errors.Wrap(err, "client:Health() Could not decode response")
+	}
+	return &health, nil
+}
+
+// VerifyQuote verifies a quote with the v1 API
+func (c *Client) VerifyQuote(ctx context.Context, data models.QuoteData) (*models.SGXResponse, error) {
+	body, err := c.do(ctx, http.MethodPost, "v1/sgx_qv_verify_quote", data)
+	if err != nil {
+		return nil, errors.Wrap(err, "client:VerifyQuote() Request failed")
+	}
+	var resp models.SGXResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "client:VerifyQuote() Could not decode response")
+	}
+	return &resp, nil
+}
+
+// VerifyQuoteV2 verifies a quote with the v2 API. The response is signed when a challenge is given and
+// response signing is enabled in the service, use VerifySignedResponse to check it. A signed response
+// reports a failed verification in QuoteInfo.Message rather than as an error.
+func (c *Client) VerifyQuoteV2(ctx context.Context, data models.QuoteDataWithChallenge) (*VerifyQuoteResponse, error) {
+	body, err := c.do(ctx, http.MethodPost, "v2/sgx_qv_verify_quote", data)
+	if err != nil {
+		return nil, errors.Wrap(err, "client:VerifyQuoteV2() Request failed")
+	}
+	var raw struct {
+		QuoteData json.RawMessage `json:"quoteData"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, errors.Wrap(err, "client:VerifyQuoteV2() Could not decode response")
+	}
+
+	resp := &VerifyQuoteResponse{}
+	if len(raw.QuoteData) != 0 && raw.QuoteData[0] == '"' {
+		resp.Signed = &SignedSGXResponse{}
+		if err := json.Unmarshal(body, resp.Signed); err != nil {
+			return nil, errors.Wrap(err, "client:VerifyQuoteV2() Could not decode signed response")
+		}
+		quoteInfo, err := decodeQuoteData(resp.Signed.QuoteData)
+		if err != nil {
+			return nil, errors.Wrap(err, "client:VerifyQuoteV2() Could not decode signed response")
+		}
+		resp.QuoteInfo = *quoteInfo
+		return resp, nil
+	}
+	if err := json.Unmarshal(raw.QuoteData, &resp.QuoteInfo); err != nil {
+		return nil, errors.Wrap(err, "client:VerifyQuoteV2() Could not decode quote data")
+	}
+	return resp, nil
+}
+
+func newStatusError(statusCode int, body []byte) *StatusError {
+	return &StatusError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+}
+
+// do sends a request and returns the body of a 200 OK response, other responses are returned as *StatusError
+func (c *Client) do(ctx context.Context, method, path string, reqBody interface{}) ([]byte, error) {
+	statusCode, body, err := c.send(ctx, method, path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, newStatusError(statusCode, body)
+	}
+	return body, nil
+}
+
+// send sends a request and returns the status and body of the response, error responses are read up to
+// maxErrorBodySize
+func (c *Client) send(ctx context.Context, method, path string, reqBody interface{}) (int, []byte, error) {
+	u, err := c.baseURL.Parse(path)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "Invalid request path")
+	}
+
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "Could not encode request")
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "Could not create request")
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return resp.StatusCode, msg, nil
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "Could not read response")
+	}
+	return resp.StatusCode, respBody, nil
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/utils"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSigner struct {
+	caPool    *x509.CertPool
+	chainPem  string
+	keyFile   string
+	otherPool *x509.CertPool
+}
+
+func newTestCA(t *testing.T, serial int64) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "CMS Signing CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+// newTestSigner creates a response signing key pair issued by a test CA and stores the private key the way
+// the service does
+func newTestSigner(t *testing.T) *testSigner {
+	caCert, caKey := newTestCA(t, 1)
+	otherCACert, _ := newTestCA(t, 2)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "SQVS Signing Certificate"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "signing_key.pem")
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600))
+
+	s := &testSigner{
+		caPool:    x509.NewCertPool(),
+		otherPool: x509.NewCertPool(),
+		chainPem:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyFile:   keyFile,
+	}
+	s.caPool.AddCert(caCert)
+	s.otherPool.AddCert(otherCACert)
+	return s
+}
+
+func (s *testSigner) sign(t *testing.T, quoteInfo QuoteInfo, usePSSPadding bool) *SignedSGXResponse {
+	data, err := json.Marshal(quoteInfo)
+	assert.NoError(t, err)
+	quoteData := base64.StdEncoding.EncodeToString(data)
+	signature, err := utils.GenerateSignature([]byte(quoteData), s.keyFile, usePSSPadding)
+	assert.NoError(t, err)
+	return &SignedSGXResponse{QuoteData: quoteData, Signature: signature, CertificateChain: s.chainPem}
+}
+
+func TestVerifySignedResponse(t *testing.T) {
+	s := newTestSigner(t)
+	quoteInfo := QuoteInfo{ReportData: "00"}
+	quoteInfo.Message = "SGX_QL_QV_RESULT_OK"
+	quoteInfo.Challenge = "challenge"
+
+	for _, usePSSPadding := range []bool{true, false} {
+		got, err := VerifySignedResponse(s.sign(t, quoteInfo, usePSSPadding), s.caPool, "challenge")
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, "SGX_QL_QV_RESULT_OK", got.Message)
+		}
+	}
+
+	_, err := VerifySignedResponse(s.sign(t, quoteInfo, true), s.caPool, "other")
+	assert.Error(t, err)
+
+	_, err = VerifySignedResponse(s.sign(t, quoteInfo, true), s.otherPool, "challenge")
+	assert.Error(t, err)
+
+	// quote data of another response under the original signature
+	tampered := s.sign(t, quoteInfo, true)
+	quoteInfo.Message = "SGX_QL_QV_RESULT_REVOKED"
+	tampered.QuoteData = s.sign(t, quoteInfo, true).QuoteData
+	_, err = VerifySignedResponse(tampered, s.caPool, "challenge")
+	assert.Error(t, err)
+
+	_, err = VerifySignedResponse(s.sign(t, quoteInfo, true), s.caPool, "")
+	assert.Error(t, err)
+}
+
+func TestClient(t *testing.T) {
+	s := newTestSigner(t)
+	var authHeader string
+	draining := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/svs/v1/version":
+			_, _ = w.Write([]byte("v5.1.0"))
+		case "/svs/v1/ready":
+			if draining {
+				http.Error(w, "not ready", http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ready"))
+		case "/svs/v1/health":
+			if draining {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = w.Write([]byte(`{"status":"not ready"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"degraded","checks":{"collateral":{"status":"failing","error":"SCS unreachable","metrics":{"entries":3}}}}`))
+		case "/svs/v1/sgx_qv_verify_quote":
+			_, _ = w.Write([]byte(`{"Message":"SGX_QL_QV_RESULT_OK","reportData":"00"}`))
+		case "/svs/v2/sgx_qv_verify_quote":
+			var data models.QuoteDataWithChallenge
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+			if data.QuoteBlob == "" {
+				http.Error(w, "SGX_QL_ERROR_INVALID_PARAMETER", http.StatusBadRequest)
+				return
+			}
+			if data.Challenge == "" {
+				_, _ = w.Write([]byte(`{"quoteData":{"Message":"SGX_QL_QV_RESULT_OK","ReportData":"00"}}`))
+				return
+			}
+			quoteInfo := QuoteInfo{}
+			quoteInfo.Message = "SGX_QL_QV_RESULT_OK"
+			quoteInfo.Challenge = data.Challenge
+			_ = json.NewEncoder(w).Encode(s.sign(t, quoteInfo, true))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL+"/svs", WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	version, err := c.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "v5.1.0", version)
+	assert.Empty(t, authHeader)
+
+	ready, err := c.Ready(ctx)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	health, err := c.Health(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "degraded", health.Status)
+	if assert.Contains(t, health.Checks, "collateral") {
+		assert.Equal(t, "failing", health.Checks["collateral"].Status)
+		assert.Equal(t, "SCS unreachable", health.Checks["collateral"].Error)
+		assert.JSONEq(t, `{"entries":3}`, string(health.Checks["collateral"].Metrics))
+	}
+
+	draining = true
+	ready, err = c.Ready(ctx)
+	assert.NoError(t, err)
+	assert.False(t, ready)
+	health, err = c.Health(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "not ready", health.Status)
+	draining = false
+
+	v1Resp, err := c.VerifyQuote(ctx, models.QuoteData{QuoteBlob: "quote"})
+	assert.NoError(t, err)
+	assert.Equal(t, "00", v1Resp.ReportData)
+
+	v2Resp, err := c.VerifyQuoteV2(ctx, models.QuoteDataWithChallenge{QuoteData: models.QuoteData{QuoteBlob: "quote"}})
+	assert.NoError(t, err)
+	assert.Nil(t, v2Resp.Signed)
+	assert.Equal(t, "SGX_QL_QV_RESULT_OK", v2Resp.QuoteInfo.Message)
+
+	v2Resp, err = c.VerifyQuoteV2(ctx, models.QuoteDataWithChallenge{QuoteData: models.QuoteData{QuoteBlob: "quote"},
+		Challenge: "challenge"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, v2Resp.Signed) {
+		quoteInfo, err := VerifySignedResponse(v2Resp.Signed, s.caPool, "challenge")
+		assert.NoError(t, err)
+		assert.Equal(t, v2Resp.QuoteInfo, *quoteInfo)
+	}
+
+	_, err = c.VerifyQuoteV2(ctx, models.QuoteDataWithChallenge{})
+	statusErr, ok := errors.Cause(err).(*StatusError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+		assert.Equal(t, "SGX_QL_ERROR_INVALID_PARAMETER", statusErr.Message)
+	}
+
+	c, err = New(server.URL+"/svs/", WithHTTPClient(server.Client()), WithBearerToken("token"))
+	assert.NoError(t, err)
+	_, err = c.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", authHeader)
+
+	c, err = New(server.URL+"/other", WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+	_, err = c.Ready(ctx)
+	assert.Error(t, err)
+	_, err = c.Health(ctx)
+	assert.Error(t, err)
+}
+
+func TestNewOptions(t *testing.T) {
+	_, err := New("ftp://sqvs/svs")
+	assert.Error(t, err)
+
+	_, err = New("https://sqvs/svs", WithHTTPClient(http.DefaultClient), WithTimeout(time.Second))
+	assert.Error(t, err)
+
+	_, err = New("https://sqvs/svs", WithBearerToken(" "))
+	assert.Error(t, err)
+
+	_, err = New("https://sqvs/svs", WithRootCAs(x509.NewCertPool()), WithTimeout(time.Second))
+	assert.NoError(t, err)
+}
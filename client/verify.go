@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package client
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// VerifySignedResponse checks a signed v2 verification response and returns its quote data. The certificate
+// chain of the response must lead to one of trustedCAs, the signature must be an RSA SHA-384 signature over
+// the quote data with either PSS or PKCS#1 v1.5 padding, and the quote data must echo challenge.
+// A quote which failed verification is still reported in QuoteInfo.Message.
+func VerifySignedResponse(resp *SignedSGXResponse, trustedCAs *x509.CertPool, challenge string) (*QuoteInfo, error) {
+	if resp == nil {
+		return nil, errors.New("VerifySignedResponse: response is empty")
+	}
+	if trustedCAs == nil {
+		return nil, errors.New("VerifySignedResponse: no trusted CAs given")
+	}
+	if challenge == "" {
+		return nil, errors.New("VerifySignedResponse: challenge is empty")
+	}
+
+	signingCert, err := verifySigningCertChain(resp.CertificateChain, trustedCAs)
+	if err != nil {
+		return nil, errors.Wrap(err, "VerifySignedResponse: Invalid certificate chain")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "VerifySignedResponse: Could not decode signature")
+	}
+	pubKey, ok := signingCert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("VerifySignedResponse: signing certificate does not carry an RSA key")
+	}
+	// the signature covers the base64 encoded quote data as sent
+	hash := sha512.Sum384([]byte(resp.QuoteData))
+	err = rsa.VerifyPSS(pubKey, crypto.SHA384, hash[:], signature, &rsa.PSSOptions{
+		SaltLength: rsa.PSSSaltLengthAuto,
+		Hash:       crypto.SHA384,
+	})
+	if err != nil {
+		if rsa.VerifyPKCS1v15(pubKey, crypto.SHA384, hash[:], signature) != nil {
+			return nil, errors.New("VerifySignedResponse: signature verification failed")
+		}
+	}
+
+	quoteInfo, err := decodeQuoteData(resp.QuoteData)
+	if err != nil {
+		return nil, errors.Wrap(err, "VerifySignedResponse: Invalid quote data")
+	}
+	if subtle.ConstantTimeCompare([]byte(quoteInfo.Challenge), []byte(challenge)) != 1 {
+		return nil, errors.New("VerifySignedResponse: challenge does not match")
+	}
+	return quoteInfo, nil
+}
+
+// verifySigningCertChain returns the first certificate of the PEM chain once it has been verified against
+// trustedCAs, the other certificates of the chain are used as intermediate CAs
+func verifySigningCertChain(chainPem string, trustedCAs *x509.CertPool) (*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(chainPem)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not parse certificate")
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         trustedCAs,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+func decodeQuoteData(quoteData string) (*QuoteInfo, error) {
+	data, err := base64.StdEncoding.DecodeString(quoteData)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not decode quote data")
+	}
+	var quoteInfo QuoteInfo
+	if err := json.Unmarshal(data, &quoteInfo); err != nil {
+		return nil, errors.Wrap(err, "Could not parse quote data")
+	}
+	return &quoteInfo, nil
+}
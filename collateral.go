@@ -0,0 +1,272 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	cos "intel/isecl/lib/common/v5/os"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/collateral"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// collateral runs the collateral store subcommands
+func (a *App) collateral(args []string) error {
+	if len(args) < 1 {
+		a.printUsage()
+		return errors.New("app:collateral() Missing collateral command")
+	}
+	c := a.configuration()
+	store, err := newCollateralStore(c)
+	if err != nil {
+		return errors.Wrap(err, "app:collateral() Could not open collateral store")
+	}
+
+	switch args[0] {
+	case "fetch":
+		return a.collateralFetch(c, store, args[1:])
+	case "list":
+		return a.collateralList(store)
+	case "show":
+		if len(args) != 2 {
+			return errors.New("app:collateral() show requires the key of the collateral")
+		}
+		return a.collateralShow(store, args[1])
+	case "import":
+		if len(args) != 2 {
+			return errors.New("app:collateral() import requires the bundle file")
+		}
+		return a.collateralImport(store, args[1])
+	case "export":
+		if len(args) != 2 {
+			return errors.New("app:collateral() export requires the bundle file")
+		}
+		return a.collateralExport(store, args[1])
+	case "purge":
+		removed, err := store.Purge()
+		if err != nil {
+			return errors.Wrap(err, "app:collateral() Could not purge collateral store")
+		}
+		fmt.Fprintf(a.consoleWriter(), "Removed %d collateral entries from %s\n", removed, store.Dir())
+		return nil
+	default:
+		a.printUsage()
+		return errors.Errorf("app:collateral() Unrecognized collateral command %s", args[0])
+	}
+}
+
+// collateralFetch pulls the collateral of the FMSPCs from SCS into the store
+func (a *App) collateralFetch(c *config.Configuration, store *collateral.Store, args []string) error {
+	fmspcList := strings.Join(c.CollateralRefresh.FMSPCs, ",")
+	pckCAList := "processor,platform"
+	timeout := constants.DefaultCollateralFetchTimeout
+	fs := flag.NewFlagSet("collateral fetch", flag.ContinueOnError)
+	fs.SetOutput(a.consoleWriter())
+	fs.StringVar(&fmspcList, "fmspc", fmspcList, "comma separated FMSPCs whose TCB info is fetched")
+	fs.StringVar(&pckCAList, "ca", pckCAList, "comma separated PCK CAs whose CRL is fetched")
+	fs.DurationVar(&timeout, "timeout", timeout, "maximum time the fetch may take")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "app:collateralFetch() Could not parse input flags")
+	}
+	if timeout <= 0 {
+		return errors.Errorf("app:collateralFetch() Invalid timeout %s", timeout)
+	}
+
+	var fmspcs []string
+	for _, fmspc := range strings.Split(fmspcList, ",") {
+		fmspc = strings.ToLower(strings.TrimSpace(fmspc))
+		if fmspc == "" {
+			continue
+		}
+		if _, err := hex.DecodeString(fmspc); err != nil || len(fmspc) != constants.FmspcLen {
+			return errors.Errorf("app:collateralFetch() Invalid FMSPC %s", fmspc)
+		}
+		fmspcs = append(fmspcs, fmspc)
+	}
+	if len(fmspcs) == 0 {
+		return errors.New("app:collateralFetch() No FMSPC provided")
+	}
+	var pckCAs []string
+	for _, ca := range strings.Split(pckCAList, ",") {
+		ca = strings.ToLower(strings.TrimSpace(ca))
+		switch ca {
+		case "":
+		case "processor", "platform":
+			pckCAs = append(pckCAs, ca)
+		default:
+			return errors.Errorf("app:collateralFetch() Invalid PCK CA %s, must be processor or platform", ca)
+		}
+	}
+
+	scsClient := a.SCSClient
+	if scsClient == nil {
+		var err error
+		scsClient, err = domain.NewSCSClient(constants.TrustedCAsStoreDir, c.SCSClient)
+		if err != nil {
+			return errors.Wrap(err, "app:collateralFetch() Could not create SCS client")
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	entries, err := collateral.Fetch(ctx, c, scsClient, store.TrustAnchors(), fmspcs, pckCAs)
+	if err != nil {
+		return errors.Wrap(err, "app:collateralFetch() Could not fetch collateral")
+	}
+	for _, entry := range entries {
+		if err := store.Save(entry); err != nil {
+			return errors.Wrapf(err, "app:collateralFetch() Could not store collateral %s", entry.Key)
+		}
+	}
+	if err := a.chownCollateralStore(store); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.consoleWriter(), "Fetched %d collateral entries into %s\n", len(entries), store.Dir())
+	return nil
+}
+
+// collateralList prints the stored collateral
+func (a *App) collateralList(store *collateral.Store) error {
+	entries, err := store.Load()
+	if err != nil {
+		return errors.Wrap(err, "app:collateralList() Could not load collateral store")
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	w := tabwriter.NewWriter(a.consoleWriter(), 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tISSUED\tNEXT UPDATE\tTCB EVALUATION DATA NUMBER\tFETCHED")
+	for _, entry := range entries {
+		evaluationDataNumber := "-"
+		if entry.Kind == collateral.KindTcbInfo || entry.Kind == collateral.KindQeIdentity {
+			evaluationDataNumber = fmt.Sprint(entry.TcbEvaluationDataNumber)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Key, entry.IssueDate.Format(time.RFC3339),
+			entry.NextUpdate.Format(time.RFC3339), evaluationDataNumber, entry.FetchedAt.UTC().Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+// collateralShow prints a stored collateral entry with its decoded document
+func (a *App) collateralShow(store *collateral.Store, key string) error {
+	entry, err := store.Get(key)
+	if err != nil {
+		return errors.Wrap(err, "app:collateralShow() Could not load collateral")
+	}
+
+	w := a.consoleWriter()
+	fmt.Fprintf(w, "Key:          %s\n", entry.Key)
+	fmt.Fprintf(w, "Kind:         %s\n", entry.Kind)
+	fmt.Fprintf(w, "Fetched:      %s\n", entry.FetchedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "Issued:       %s\n", entry.IssueDate.Format(time.RFC3339))
+	fmt.Fprintf(w, "Next update:  %s\n", entry.NextUpdate.Format(time.RFC3339))
+	if entry.IssuerChain != "" {
+		chain, err := entry.IssuerCerts()
+		if err != nil {
+			return errors.Wrap(err, "app:collateralShow() Could not parse issuer chain")
+		}
+		fmt.Fprintln(w, "Issuer chain:")
+		for _, cert := range chain {
+			fmt.Fprintf(w, "    %s (expires %s)\n", cert.Subject.String(), cert.NotAfter.UTC().Format(time.RFC3339))
+		}
+	}
+
+	switch entry.Kind {
+	case collateral.KindTcbInfo, collateral.KindQeIdentity:
+		fmt.Fprintf(w, "TCB evaluation data number: %d\n", entry.TcbEvaluationDataNumber)
+		var body bytes.Buffer
+		if err := json.Indent(&body, entry.Body, "", "    "); err != nil {
+			return errors.Wrap(err, "app:collateralShow() Could not decode collateral")
+		}
+		fmt.Fprintln(w, body.String())
+	case collateral.KindPckCrl, collateral.KindRootCaCrl:
+		crl, err := entry.CRL()
+		if err != nil {
+			return errors.Wrap(err, "app:collateralShow() Could not decode collateral")
+		}
+		fmt.Fprintf(w, "CRL issuer:   %s\n", crl.TBSCertList.Issuer.String())
+		fmt.Fprintf(w, "Revoked:      %d certificates\n", len(crl.TBSCertList.RevokedCertificates))
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			fmt.Fprintf(w, "    %x revoked at %s\n", revoked.SerialNumber, revoked.RevocationTime.UTC().Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// collateralImport verifies a collateral bundle and adds it to the store
+func (a *App) collateralImport(store *collateral.Store, path string) error {
+	bundle, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "app:collateralImport() Could not open collateral bundle")
+	}
+	defer func() {
+		if err := bundle.Close(); err != nil {
+			log.WithError(err).Error("app:collateralImport() Failed to close collateral bundle")
+		}
+	}()
+
+	entries, err := store.Import(bundle)
+	if err != nil {
+		return errors.Wrap(err, "app:collateralImport() Could not import collateral bundle")
+	}
+	if err := a.chownCollateralStore(store); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.consoleWriter(), "Imported %d collateral entries into %s, restart sqvs to use them\n",
+		len(entries), store.Dir())
+	return nil
+}
+
+// collateralExport writes the stored collateral to a bundle
+func (a *App) collateralExport(store *collateral.Store, path string) (err error) {
+	bundle, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return errors.Wrap(err, "app:collateralExport() Could not create collateral bundle")
+	}
+	defer func() {
+		if cerr := bundle.Close(); cerr != nil && err == nil {
+			err = errors.Wrap(cerr, "app:collateralExport() Could not write collateral bundle")
+		}
+	}()
+
+	if err := store.Export(bundle); err != nil {
+		return errors.Wrap(err, "app:collateralExport() Could not export collateral")
+	}
+	fmt.Fprintf(a.consoleWriter(), "Exported the collateral of %s to %s\n", store.Dir(), path)
+	return nil
+}
+
+// chownCollateralStore hands the store over to the sqvs user, which the commands may have run as root
+func (a *App) chownCollateralStore(store *collateral.Store) error {
+	// Containers are always run as non root users, does not require changing ownership of config directories
+	if _, err := os.Stat("/.container-env"); err == nil {
+		return nil
+	}
+	owner := a.CollateralOwner
+	if owner == nil {
+		owner = sqvsUserIDs
+	}
+	uid, gid, err := owner()
+	if err != nil {
+		return err
+	}
+	if err := cos.ChownR(store.Dir(), uid, gid); err != nil {
+		return errors.Wrap(err, "Error while changing ownership of collateral store")
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/resource/collateral"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSCSBaseURL = "https://scs.example.com:9000/scs/sgx/certification/v1"
+
+// testSCS serves TCB info, QE identity and CRLs signed by a signing CA issued by a test root CA. Requests
+// wait for their context to be done while the server hangs.
+type testSCS struct {
+	t          *testing.T
+	root       *x509.Certificate
+	rootKey    *ecdsa.PrivateKey
+	signing    *x509.Certificate
+	signingKey *ecdsa.PrivateKey
+	chain      string
+	hang       bool
+}
+
+func newTestCert(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDer)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func newTestSCS(t *testing.T) *testSCS {
+	root, rootKey := newTestCert(t, "Intel SGX Root CA", nil, nil)
+	signing, signingKey := newTestCert(t, "Intel SGX TCB Signing", root, rootKey)
+	chain := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signing.Raw})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}))
+	return &testSCS{t: t, root: root, rootKey: rootKey, signing: signing, signingKey: signingKey, chain: chain}
+}
+
+func (s *testSCS) Do(req *http.Request) (*http.Response, error) {
+	if s.hang {
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Request: req}
+	dates := fmt.Sprintf(`"issueDate":"%s","nextUpdate":"%s","tcbEvaluationDataNumber":12`,
+		time.Now().Add(-time.Hour).UTC().Format(time.RFC3339), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	var body string
+	switch strings.TrimPrefix(req.URL.Path, "/scs/sgx/certification/v1") {
+	case "/tcb":
+		body = s.sign("tcbInfo", fmt.Sprintf(`{"fmspc":"%s",%s}`, req.URL.Query().Get("fmspc"), dates))
+		resp.Header.Set("SGX-TCB-Info-Issuer-Chain", url.QueryEscape(s.chain))
+	case "/qe/identity":
+		body = s.sign("enclaveIdentity", fmt.Sprintf(`{%s}`, dates))
+		resp.Header.Set("Sgx-Qe-Identity-Issuer-Chain", url.QueryEscape(s.chain))
+	case "/pckcrl":
+		body = s.crl(s.signing, s.signingKey)
+		resp.Header.Set("SGX-PCK-CRL-Issuer-Chain", url.QueryEscape(s.chain))
+	case "/rootcacrl":
+		body = s.crl(s.root, s.rootKey)
+	default:
+		resp.StatusCode = http.StatusNotFound
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	return resp, nil
+}
+
+// sign returns a TCB info or QE identity document with the signature over its object
+func (s *testSCS) sign(name, object string) string {
+	hash := sha256.Sum256([]byte(object))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.signingKey, hash[:])
+	assert.NoError(s.t, err)
+	sigBlob := make([]byte, 64)
+	r.FillBytes(sigBlob[:32])
+	sig.FillBytes(sigBlob[32:])
+	return fmt.Sprintf(`{"%s":%s,"signature":"%s"}`, name, object, hex.EncodeToString(sigBlob))
+}
+
+func (s *testSCS) crl(issuer *x509.Certificate, key *ecdsa.PrivateKey) string {
+	crlDer, err := issuer.CreateCRL(rand.Reader, key, nil, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.NoError(s.t, err)
+	return base64.StdEncoding.EncodeToString(crlDer)
+}
+
+func newTestCollateralApp(scs *testSCS, console *bytes.Buffer) *App {
+	return &App{
+		ConsoleWriter: console,
+		SCSClient:     scs,
+		CollateralOwner: func() (int, int, error) {
+			return os.Getuid(), os.Getgid(), nil
+		},
+	}
+}
+
+func newTestCollateralStore(t *testing.T, scs *testSCS) *collateral.Store {
+	store, err := collateral.NewStore(t.TempDir(), []*x509.Certificate{scs.root})
+	assert.NoError(t, err)
+	return store
+}
+
+func TestCollateralFetch(t *testing.T) {
+	scs := newTestSCS(t)
+	store := newTestCollateralStore(t, scs)
+	var console bytes.Buffer
+	a := newTestCollateralApp(scs, &console)
+	c := &config.Configuration{SCSBaseURL: testSCSBaseURL}
+
+	err := a.collateralFetch(c, store, []string{"--fmspc=00906ED50000", "--ca=processor,platform"})
+	assert.NoError(t, err)
+	assert.Contains(t, console.String(), "Fetched 5 collateral entries")
+	entries, err := store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+	_, err = store.Get("/tcb?fmspc=00906ed50000")
+	assert.NoError(t, err)
+	_, err = store.Get("/pckcrl?ca=platform")
+	assert.NoError(t, err)
+
+	// the FMSPCs to prefetch are fetched when none are given
+	c.CollateralRefresh.FMSPCs = []string{"00606a000000"}
+	err = a.collateralFetch(c, store, []string{"--ca="})
+	assert.NoError(t, err)
+	entries, err = store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 6)
+}
+
+func TestCollateralFetchInvalid(t *testing.T) {
+	scs := newTestSCS(t)
+	store := newTestCollateralStore(t, scs)
+	a := newTestCollateralApp(scs, &bytes.Buffer{})
+	c := &config.Configuration{SCSBaseURL: testSCSBaseURL}
+
+	for _, args := range [][]string{
+		{},
+		{"--fmspc=00906ed5"},
+		{"--fmspc=not-hex-fmsp"},
+		{"--fmspc=00906ed50000", "--ca=intermediate"},
+		{"--fmspc=00906ed50000", "--timeout=0s"},
+		{"--fmspc=00906ed50000", "--timeout=soon"},
+		{"--unknown"},
+	} {
+		assert.Error(t, a.collateralFetch(c, store, args), strings.Join(args, " "))
+	}
+
+	// collateral not signed by the trust anchors is not stored
+	a.SCSClient = newTestSCS(t)
+	assert.Error(t, a.collateralFetch(c, store, []string{"--fmspc=00906ed50000"}))
+	entries, err := store.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCollateralFetchTimeout(t *testing.T) {
+	scs := newTestSCS(t)
+	scs.hang = true
+	store := newTestCollateralStore(t, scs)
+	a := newTestCollateralApp(scs, &bytes.Buffer{})
+	c := &config.Configuration{SCSBaseURL: testSCSBaseURL}
+
+	start := time.Now()
+	err := a.collateralFetch(c, store, []string{"--fmspc=00906ed50000", "--timeout=50ms"})
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < 10*time.Second)
+}
+
+func TestCollateralExportImport(t *testing.T) {
+	scs := newTestSCS(t)
+	store := newTestCollateralStore(t, scs)
+	var console bytes.Buffer
+	a := newTestCollateralApp(scs, &console)
+	c := &config.Configuration{SCSBaseURL: testSCSBaseURL}
+	assert.NoError(t, a.collateralFetch(c, store, []string{"--fmspc=00906ed50000"}))
+
+	bundle := filepath.Join(t.TempDir(), "collateral.tar.gz")
+	assert.NoError(t, a.collateralExport(store, bundle))
+	assert.Contains(t, console.String(), "Exported the collateral")
+	info, err := os.Stat(bundle)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	imported := newTestCollateralStore(t, scs)
+	assert.NoError(t, a.collateralImport(imported, bundle))
+	assert.Contains(t, console.String(), "Imported 5 collateral entries")
+	entries, err := imported.Load()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+
+	// bundles are verified against the trust anchors of the importing store
+	untrusted := newTestCollateralStore(t, newTestSCS(t))
+	assert.Error(t, a.collateralImport(untrusted, bundle))
+	entries, err = untrusted.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Error(t, a.collateralImport(imported, filepath.Join(t.TempDir(), "missing.tar.gz")))
+	notBundle := filepath.Join(t.TempDir(), "not-a-bundle.tar.gz")
+	assert.NoError(t, ioutil.WriteFile(notBundle, []byte("not a bundle"), 0600))
+	assert.Error(t, a.collateralImport(imported, notBundle))
+	assert.Error(t, a.collateralExport(store, filepath.Join(t.TempDir(), "missing", "collateral.tar.gz")))
+}
@@ -24,8 +24,10 @@ var log = commLog.GetDefaultLogger()
 
 // Configuration is the global configuration struct that is marshalled/unmarshalled to a persisted yaml file
 type Configuration struct {
-	configFile       string
-	Port             int
+	configFile string
+	Port       int
+	// GRPCPort is the port of the gRPC quote verification API, which is disabled when zero
+	GRPCPort         int
 	CmsTLSCertDigest string
 
 	LogMaxLength    int
@@ -49,6 +51,142 @@ type Configuration struct {
 	WriteTimeout             time.Duration
 	IdleTimeout              time.Duration
 	MaxHeaderBytes           int
+	Tenants                  map[string]TenantConfig
+	ClientCertAuth           ClientCertAuthConfig
+	RateLimit                RateLimitConfig
+	Audit                    AuditConfig
+	SCSClient                SCSClientConfig
+	// CollateralExpiryGrace is the time past nextUpdate during which expired collateral is still accepted,
+	// the quote is then reported with expired collateral instead of being rejected
+	CollateralExpiryGrace time.Duration
+	PlatformPolicy        PlatformPolicyConfig
+	// IncludePPID reports the PPID of the platform in verification responses and audit records. Unlike the
+	// other PCK platform fields, the PPID is omitted by default: it identifies the physical platform across
+	// all enclaves and relying parties.
+	IncludePPID bool
+	// MinTcbEvaluationDataNumber rejects TCB info and QE identity with a lower tcbEvaluationDataNumber, it is
+	// raised after TCB recovery events. Collateral below the highest number seen before is always rejected.
+	MinTcbEvaluationDataNumber uint
+	// VerificationTimeout bounds the verification of a quote including its collateral requests, zero disables
+	// the deadline
+	VerificationTimeout time.Duration
+	// ShutdownTimeout bounds the drain of in-flight verifications and background work on shutdown
+	ShutdownTimeout time.Duration
+	// ShutdownDelay is the time between failing the readiness checks and stopping the servers on shutdown, so
+	// that load balancers stop routing requests to the service first
+	ShutdownDelay     time.Duration
+	CollateralRefresh CollateralRefreshConfig
+	CollateralStore   CollateralStoreConfig
+	Replay            ReplayConfig
+}
+
+// ReplayConfig configures the detection of quotes verified again, which the v2 and gRPC APIs report. Verified quotes
+// are remembered for TTL, at most MaxEntries of them, identified by KeyBy: the quote or its report data.
+// Backend keeps them in memory or, for a single node, in memory and in File so that they survive restarts.
+type ReplayConfig struct {
+	Enabled    bool
+	KeyBy      string
+	TTL        time.Duration
+	MaxEntries int
+	Backend    string
+	File       string
+	// Strict rejects replayed quotes instead of reporting them, on the v1 API as well
+	Strict bool
+}
+
+// CollateralStoreConfig configures the persistence of verified collateral in Dir, which lets the service
+// verify quotes with the stored collateral after a restart while SCS is unreachable
+type CollateralStoreConfig struct {
+	Enabled bool
+	Dir     string
+}
+
+// CollateralRefreshConfig configures the background refresh of the collateral fetched from SCS. The collateral
+// of FMSPCs is prefetched in addition to the collateral requested for verifications. Zero durations select
+// the defaults.
+type CollateralRefreshConfig struct {
+	Enabled bool
+	FMSPCs  []string
+	// Interval is the period at which collateral due for a refresh is looked for
+	Interval time.Duration
+	// RefreshAhead is the time before nextUpdate at which collateral is refreshed
+	RefreshAhead time.Duration
+	// MaxAge is the time after which collateral is refreshed regardless of its nextUpdate, it also applies to
+	// the collateral cached for the collateral store when the refresher is disabled
+	MaxAge time.Duration
+}
+
+// PlatformPolicyConfig selects the Scalable SGX platform configurations rejected for quotes with a PCK
+// certificate issued by the PCK Platform CA. All platform configurations are accepted by default.
+type PlatformPolicyConfig struct {
+	RejectSmtEnabled      bool
+	RejectDynamicPlatform bool
+}
+
+// SCSClientConfig configures the timeouts, retries and circuit breaker applied to SGX Caching Service requests.
+// Zero values select the defaults, a negative MaxRetries disables retries.
+type SCSClientConfig struct {
+	Timeout         time.Duration
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// BreakerThreshold is the number of consecutive failed requests after which SCS is considered down
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// AuditConfig configures the hash chained audit log of verification decisions. The current audit log file
+// is rotated once it exceeds MaxSizeMB megabytes or gets older than MaxAge, zero values disable the check.
+type AuditConfig struct {
+	Enabled   bool
+	Dir       string
+	MaxSizeMB int
+	MaxAge    time.Duration
+}
+
+// RateLimitConfig bounds the load accepted by the quote verifier APIs. Zero rate values disable the
+// respective limit. A negative MaxConcurrent leaves the number of in-flight verifications unbounded and a negative
+// MaxQueued rejects verifications without a free slot right away, zero values are set to the defaults by setup.
+type RateLimitConfig struct {
+	GlobalRate  float64
+	GlobalBurst int
+	// ClientRate is applied per token subject, or per remote address for unauthenticated callers
+	ClientRate    float64
+	ClientBurst   int
+	MaxConcurrent int
+	MaxQueued     int
+	QueueTimeout  time.Duration
+}
+
+// ClientCertAuthConfig configures x509 client certificate authentication for the quote verifier APIs
+type ClientCertAuthConfig struct {
+	Enabled bool
+	// Required rejects TLS connections that do not present a client certificate
+	Required     bool
+	CADir        string
+	RoleMappings []ClientCertRoleMapping
+}
+
+// ClientCertRoleMapping grants SQVS roles to client certificates matching the Subject and/or SAN.
+// Subject is compared against the certificate common name or full subject DN, SAN against any DNS, email,
+// IP or URI subject alternative name. All non-empty fields must match.
+type ClientCertRoleMapping struct {
+	Subject string
+	SAN     string
+	Roles   []string
+	Context string
+}
+
+// TenantConfig holds the restrictions applied to a tenant identified through the QuoteVerifier role context.
+// Policies are the report data bindings and TrustProfiles the collateral sources, SCS or REQUEST, a tenant may
+// use. Empty lists place no restriction, zero rate limit and quota values disable the respective limit.
+type TenantConfig struct {
+	Endpoints     []string
+	RateLimit     float64
+	RateBurst     int
+	DailyQuota    int
+	Policies      []string
+	TrustProfiles []string
 }
 
 var global *Configuration
@@ -161,6 +299,12 @@ func (conf *Configuration) SaveConfiguration(taskName string, c setup.Context) e
 	return conf.Save()
 }
 
+// AuthorizationEnabled reports whether callers of the quote verifier APIs are authenticated either by token
+// or by client certificate
+func (conf *Configuration) AuthorizationEnabled() bool {
+	return conf.IncludeToken || conf.ClientCertAuth.Enabled
+}
+
 func (conf *Configuration) Save() error {
 	if conf.configFile == "" {
 		return ErrNoConfigFile
@@ -17,16 +17,26 @@ const (
 	LogFile                        = LogDir + "sqvs.log"
 	SecLogFile                     = LogDir + "sqvs-security.log"
 	HTTPLogFile                    = LogDir + "http.log"
+	AuditLogDir                    = LogDir + "audit/"
+	AuditKeyFile                   = ConfigDir + "audit.key"
 	ConfigFile                     = "config.yml"
 	DefaultTLSCertFile             = ConfigDir + "tls-cert.pem"
 	DefaultTLSKeyFile              = ConfigDir + "tls.key"
 	TrustedJWTSigningCertsDir      = ConfigDir + "certs/trustedjwt/"
 	TrustedCAsStoreDir             = ConfigDir + "certs/trustedca/"
+	TrustedClientCAsDir            = ConfigDir + "certs/trustedclientca/"
 	TrustedSGXRootCAFile           = ConfigDir + "certs/trustedSGXRootCA.pem"
+	CollateralDir                  = ConfigDir + "collateral/"
+	TcbEvaluationDataFile          = ConfigDir + "tcb-evaluation-data.json"
 	ServiceRemoveCmd               = "systemctl disable sqvs"
 	ServiceName                    = "SQVS"
 	ExplicitServiceName            = "SGX Quote Verification Service"
 	QuoteVerifierGroupName         = "QuoteVerifier"
+	TenantRoleContextKey           = "tenant"
+	EndpointV1                     = "v1"
+	EndpointV2                     = "v2"
+	EndpointBatch                  = "batch"
+	MaxBatchVerifyQuotes           = 32
 	SQVSUserName                   = "sqvs"
 	DefaultHTTPSPort               = 12000
 	DefaultKeyAlgorithm            = "rsa"
@@ -43,8 +53,30 @@ const (
 	DefaultIdleTimeout             = 1 * time.Second
 	DefaultMaxHeaderBytes          = 1 << 20
 	DefaultLogEntryMaxLength       = 300
+	DefaultMaxConcurrentVerify     = 64
+	DefaultMaxQueuedVerify         = 256
+	DefaultVerifyQueueTimeout      = 10 * time.Second
+	DefaultAuditLogMaxSizeMB       = 100
+	DefaultAuditLogMaxAge          = 24 * time.Hour
+	DefaultSCSTimeout              = 30 * time.Second
+	DefaultSCSMaxRetries           = 2
+	DefaultSCSRetryBackoff         = 500 * time.Millisecond
+	DefaultSCSMaxRetryBackoff      = 5 * time.Second
+	DefaultSCSBreakerThreshold     = 5
+	DefaultSCSBreakerCooldown      = 30 * time.Second
+	DefaultCollateralExpiryGrace   = 0
+	DefaultVerificationTimeout     = 8 * time.Second
+	DefaultShutdownTimeout         = 30 * time.Second
+	DefaultShutdownDelay           = 5 * time.Second
+	DefaultCollateralRefreshPeriod = time.Minute
+	DefaultCollateralRefreshAhead  = 6 * time.Hour
+	DefaultCollateralMaxAge        = 24 * time.Hour
+	DefaultCollateralForgetAfter   = 7 * 24 * time.Hour
+	DefaultCollateralFetchTimeout  = time.Minute
 	SGXRootCACertSubjectStr        = "CN=Intel SGX Root CA,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
-	SGXInterCACertSubjectStr       = "CN=Intel SGX PCK Processor CA,O=Intel Corporation,L=Santa Clara,ST=CA,C=US|CN=Intel SGX PCK Platform CA,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
+	SGXProcessorCACertSubjectStr   = "CN=Intel SGX PCK Processor CA,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
+	SGXPlatformCACertSubjectStr    = "CN=Intel SGX PCK Platform CA,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
+	SGXInterCACertSubjectStr       = SGXProcessorCACertSubjectStr + "|" + SGXPlatformCACertSubjectStr
 	SGXCRLIssuerStr                = "C=US,ST=CA,L=Santa Clara,O=Intel Corporation,CN=Intel SGX PCK Processor CA|C=US,ST=CA,L=Santa Clara,O=Intel Corporation,CN=Intel SGX PCK Platform CA"
 	SGXPCKCertificateSubjectStr    = "CN=Intel SGX PCK Certificate,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
 	SGXTCBInfoSubjectStr           = "CN=Intel SGX TCB Signing,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
@@ -61,4 +93,24 @@ const (
 	PCKCertType         = 5
 	PublicKeyLocation   = ConfigDir + "sqvs_signing_pub_key.pem"
 	PrivateKeyLocation  = ConfigDir + "sqvs_signing_priv_key.pem"
+	// CollateralSourceSCS and CollateralSourceRequest report where the collateral used for verification came from
+	CollateralSourceSCS     = "SCS"
+	CollateralSourceRequest = "REQUEST"
+	// ReportDataBinding* select how the user data of a verification request is bound to the enclave report data
+	ReportDataBindingSHA256      = "sha256"
+	ReportDataBindingSHA384      = "sha384"
+	ReportDataBindingSHA512      = "sha512"
+	ReportDataBindingNoncePubKey = "nonce-pubkey"
+	ReportDataBindingRaw         = "raw"
+	// ReplayKey* select what identifies a quote for replay detection, ReplayBackend* where seen quotes are kept
+	// and ReplayStatus* report whether a verified quote has been seen before
+	ReplayKeyQuote          = "quote"
+	ReplayKeyReportData     = "reportData"
+	ReplayBackendMemory     = "memory"
+	ReplayBackendFile       = "file"
+	ReplayStatusFirstSeen   = "FIRST_SEEN"
+	ReplayStatusReplayed    = "REPLAYED"
+	DefaultReplayTTL        = 24 * time.Hour
+	DefaultReplayMaxEntries = 100000
+	ReplayCacheFile         = ConfigDir + "replay-cache"
 )
@@ -0,0 +1,198 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"intel/isecl/sqvs/v5/resource/utils"
+	"intel/isecl/sqvs/v5/resource/verifier"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func verifyQeIdentityReport(qeIdObj *parser.QeIdentityData, quoteObj domain.SGXQuoteParser) error {
+	log.Trace("quoteverifier/checks:verifyQeIdentityReport() Entering")
+	defer log.Trace("quoteverifier/checks:verifyQeIdentityReport() Leaving")
+
+	err := verifier.VerifyMiscSelect(quoteObj.GetQeReportMiscSelect(), qeIdObj.GetQeIDMiscSelect(),
+		qeIdObj.GetQeIDMiscSelectMask())
+	if err != nil {
+		return errors.Wrap(err, "verifyQeIdentityReport: ")
+	}
+
+	err = verifier.VerifyAttributes(quoteObj.GetQeReportAttributes(), qeIdObj.GetQeIDAttributes(),
+		qeIdObj.GetQeIDAttributesMask())
+	if err != nil {
+		return errors.Wrap(err, "verifyQeIdentityReport:")
+	}
+
+	err = verifier.VerifyReportAttrSize(quoteObj.GetQeReportMrSigner(), "MrSigner", qeIdObj.GetQeIDMrSigner())
+	if err != nil {
+		return errors.Wrap(err, "verifyQeIdentityReport")
+	}
+
+	if quoteObj.GetQeReportProdID() < qeIdObj.GetQeIDIsvProdID() {
+		log.Info("Qe Prod Id in ecdsa quote is below the minimum prod id expected for QE")
+	}
+
+	if quoteObj.GetQeReportIsvSvn() < qeIdObj.GetQeIDIsvSvn() {
+		log.Info("IsvSvn in ecdsa quote is below the minimum IsvSvn expected for QE")
+	}
+	return nil
+}
+
+func verifyQeIdentity(qeIDObj *parser.QeIdentityData, quoteObj domain.SGXQuoteParser,
+	trustedRootCA *x509.Certificate, verificationTime time.Time, expiryGrace time.Duration) error {
+	log.Trace("quoteverifier/checks:verifyQeIdentity() Entering")
+	defer log.Trace("quoteverifier/checks:verifyQeIdentity() Leaving")
+
+	if qeIDObj == nil || quoteObj == nil {
+		return errors.New("verifyQeIdentity: QEIdentity/Quote Object is empty")
+	}
+	err := verifier.VerifyQeIDCertChain(qeIDObj.GetQeInfoInterCaList(), qeIDObj.GetQeInfoRootCaList(),
+		trustedRootCA, verificationTime)
+	if err != nil {
+		return errors.Wrap(err, "verifyQeIdentity: VerifyQeIDCertChain")
+	}
+
+	// the QE identity is signed by the leaf of its verified issuer chain, nothing in it is trusted before the
+	// signature verifies
+	err = verifier.VerifyCollateralDocumentSignature(qeIDObj.RawBlob, "enclaveIdentity",
+		qeIDObj.GetQeInfoInterCaList()[0])
+	if err != nil {
+		return errors.Wrap(err, "verifyQeIdentity: QE Identity signature verification failed")
+	}
+
+	status := qeIDObj.GetQeIdentityStatus()
+	if !status {
+		return errors.New("verifyQeIdentity: GetQeIdentityStatus is invalid")
+	}
+
+	if !utils.CheckDate(qeIDObj.GetQeIDIssueDate(), qeIDObj.GetQeIDNextUpdate(), verificationTime, expiryGrace) {
+		return errors.New("verifyQeIdentity: Date Check validation failed")
+	}
+
+	return verifyQeIdentityReport(qeIDObj, quoteObj)
+}
+
+func verifyTcbInfo(certObj domain.PCKCertParser, tcbObj *parser.TcbInfoStruct, trustedRootCA *x509.Certificate,
+	verificationTime time.Time, expiryGrace time.Duration) error {
+	log.Trace("quoteverifier/checks:verifyTcbInfo() Entering")
+	defer log.Trace("quoteverifier/checks:verifyTcbInfo() Leaving")
+
+	if tcbObj.GetTcbInfoFmspc() != certObj.GetFmspcValue() {
+		return errors.New("verifyTcbInfo: FMSPC in TCBInfoStruct does not match with PCK Cert FMSPC")
+	}
+
+	err := verifier.VerifyTcbInfoCertChain(tcbObj.GetTcbInfoInterCaList(), tcbObj.GetTcbInfoRootCaList(),
+		trustedRootCA, verificationTime)
+	if err != nil {
+		return errors.Wrap(err, "verifyTcbInfo: failed to verify Tcbinfo Certchain")
+	}
+
+	// the TCB info is signed by the leaf of its verified issuer chain, nothing in it is trusted before the
+	// signature verifies
+	err = verifier.VerifyCollateralDocumentSignature(tcbObj.RawBlob, "tcbInfo", tcbObj.GetTcbInfoInterCaList()[0])
+	if err != nil {
+		return errors.Wrap(err, "verifyTcbInfo: TCB Info signature verification failed")
+	}
+
+	if !utils.CheckDate(tcbObj.GetTcbInfoIssueDate(), tcbObj.GetTcbInfoNextUpdate(), verificationTime, expiryGrace) {
+		return errors.New("verifyTcbInfo: Date Check validation failed")
+	}
+
+	return nil
+}
+
+// newEnclaveReport converts the enclave report of a quote into its response representation
+func newEnclaveReport(report models.ReportBody) models.EnclaveReport {
+	flags := binary.LittleEndian.Uint64(report.SgxAttributes[:8])
+	return models.EnclaveReport{
+		CPUSvn:     hex.EncodeToString(report.CPUSvn[:]),
+		MiscSelect: report.MiscSelect,
+		Attributes: models.EnclaveAttributes{
+			Flags:         flags,
+			Xfrm:          binary.LittleEndian.Uint64(report.SgxAttributes[8:]),
+			Init:          flags&models.SgxFlagsInitted != 0,
+			Debug:         flags&models.SgxFlagsDebug != 0,
+			Mode64Bit:     flags&models.SgxFlagsMode64Bit != 0,
+			ProvisionKey:  flags&models.SgxFlagsProvisionKey != 0,
+			EinitTokenKey: flags&models.SgxFlagsEinitTokenKey != 0,
+			Kss:           flags&models.SgxFlagsKss != 0,
+		},
+		MrEnclave:    hex.EncodeToString(report.MrEnclave[:]),
+		MrSigner:     hex.EncodeToString(report.MrSigner[:]),
+		IsvProdID:    report.SgxIsvProdID,
+		IsvSvn:       report.SgxIsvSvn,
+		IsvExtProdID: hex.EncodeToString(report.IsvExtProdID[:]),
+		IsvFamilyID:  hex.EncodeToString(report.IsvFamilyID[:]),
+		ConfigID:     hex.EncodeToString(report.ConfigID[:]),
+		ConfigSvn:    report.ConfigSvn,
+		ReportData:   hex.EncodeToString(report.ReportData[:]),
+	}
+}
+
+// checkVerifyOptions rejects unsupported report data bindings and bindings lacking the data to check
+func checkVerifyOptions(opts VerifyOptions) error {
+	if !verifier.IsValidReportDataBinding(opts.ReportDataBinding) {
+		return errors.New("Invalid reportDataBinding " + opts.ReportDataBinding)
+	}
+	if opts.StrictReportData && len(opts.UserData) == 0 {
+		return errors.New("userData is required for strict report data verification")
+	}
+	if opts.ReportDataBinding == constants.ReportDataBindingNoncePubKey && len(opts.UserData) != 0 &&
+		len(opts.Nonce) == 0 {
+		return errors.New("nonce is required for " + constants.ReportDataBindingNoncePubKey + " report data binding")
+	}
+	return nil
+}
+
+// getCollateralExpiry returns the earliest nextUpdate or notAfter date across the CRLs, TCBInfo, QE identity
+// and certificates used to verify the quote and whether that collateral has expired at verificationTime
+func getCollateralExpiry(quoteObj domain.SGXQuoteParser, certObj domain.PCKCertParser, rootCaCrl *pkix.CertificateList,
+	tcbObj *parser.TcbInfoStruct, qeIDObj *parser.QeIdentityData, verificationTime time.Time) (time.Time, bool, error) {
+	var expiryDates []time.Time
+
+	crls := []*pkix.CertificateList{rootCaCrl}
+	crls = append(crls, certObj.GetPckCrlObj()...)
+	for _, crl := range crls {
+		if crl == nil {
+			return time.Time{}, false, errors.New("getCollateralExpiry: Revocation List is empty")
+		}
+		expiryDates = append(expiryDates, crl.TBSCertList.NextUpdate)
+	}
+
+	for _, nextUpdate := range []string{tcbObj.GetTcbInfoNextUpdate(), qeIDObj.GetQeIDNextUpdate()} {
+		date, err := time.Parse(time.RFC3339, nextUpdate)
+		if err != nil {
+			return time.Time{}, false, errors.Wrap(err, "getCollateralExpiry: invalid nextUpdate")
+		}
+		expiryDates = append(expiryDates, date)
+	}
+
+	certs := []*x509.Certificate{quoteObj.GetQuotePckCertObj()}
+	for _, certList := range [][]*x509.Certificate{quoteObj.GetQuotePckCertInterCAList(),
+		quoteObj.GetQuotePckCertRootCAList(), certObj.GetPckCrlInterCaList(), certObj.GetPckCrlRootCaList(),
+		tcbObj.GetTcbInfoInterCaList(), tcbObj.GetTcbInfoRootCaList(), qeIDObj.GetQeInfoInterCaList(),
+		qeIDObj.GetQeInfoRootCaList()} {
+		certs = append(certs, certList...)
+	}
+	for _, cert := range certs {
+		if cert == nil {
+			return time.Time{}, false, errors.New("getCollateralExpiry: Certificate is empty")
+		}
+		expiryDates = append(expiryDates, cert.NotAfter)
+	}
+
+	return verifier.GetCollateralExpiry(expiryDates, verificationTime)
+}
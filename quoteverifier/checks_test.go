@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/mocks"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCollateral issues TCB info, QE identity and CRLs signed by a TCB signing CA issued by a test SGX root CA.
+// The certificates carry the subjects and extensions the issuer chains are verified for.
+type testCollateral struct {
+	t          *testing.T
+	root       *x509.Certificate
+	rootKey    *ecdsa.PrivateKey
+	signing    *x509.Certificate
+	signingKey *ecdsa.PrivateKey
+	chain      string
+}
+
+func newTestCollateralCert(t *testing.T, name string, serial int64, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject: pkix.Name{
+			CommonName:   name,
+			Organization: []string{"Intel Corporation"},
+			Locality:     []string{"Santa Clara"},
+			Province:     []string{"CA"},
+			Country:      []string{"US"},
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		SubjectKeyId:          []byte(name),
+		CRLDistributionPoints: []string{"https://certificates.trustedservices.intel.com/IntelSGXRootCA.der"},
+	}
+	if parent == nil {
+		// the authority key identifier of a self-signed certificate is not set from the parent
+		template.AuthorityKeyId = template.SubjectKeyId
+		parent, parentKey = template, key
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDer)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func newTestCollateral(t *testing.T) *testCollateral {
+	root, rootKey := newTestCollateralCert(t, "Intel SGX Root CA", 1, nil, nil)
+	signing, signingKey := newTestCollateralCert(t, "Intel SGX TCB Signing", 2, root, rootKey)
+	chain := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signing.Raw})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}))
+	return &testCollateral{t: t, root: root, rootKey: rootKey, signing: signing, signingKey: signingKey,
+		chain: chain}
+}
+
+// sign returns a TCB info or QE identity document with the signature over its object
+func (c *testCollateral) sign(name, object string) string {
+	hash := sha256.Sum256([]byte(object))
+	r, s, err := ecdsa.Sign(rand.Reader, c.signingKey, hash[:])
+	assert.NoError(c.t, err)
+	sigBlob := make([]byte, 64)
+	r.FillBytes(sigBlob[:32])
+	s.FillBytes(sigBlob[32:])
+	return fmt.Sprintf(`{"%s":%s,"signature":"%s"}`, name, object, hex.EncodeToString(sigBlob))
+}
+
+func (c *testCollateral) crl(issuer *x509.Certificate, key *ecdsa.PrivateKey) string {
+	crlDer, err := issuer.CreateCRL(rand.Reader, key, nil, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	assert.NoError(c.t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDer}))
+}
+
+// tcbInfo returns a signed TCB info with the FMSPC of the fake PCK certificate
+func (c *testCollateral) tcbInfo(evaluationDataNumber int) string {
+	return c.sign("tcbInfo", fmt.Sprintf(`{"version":2,"issueDate":"%s","nextUpdate":"%s","fmspc":"",`+
+		`"pceId":"0000","tcbType":0,"tcbEvaluationDataNumber":%d,"tcbLevels":[{"tcb":{"sgxtcbcomp01svn":2,`+
+		`"pcesvn":10},"tcbDate":"2021-11-10T00:00:00Z","tcbStatus":"OutOfDate"}]}`, c.issueDate(), c.nextUpdate(),
+		evaluationDataNumber))
+}
+
+// qeIdentity returns a signed QE identity matching the QE report of the mock quote parser
+func (c *testCollateral) qeIdentity(evaluationDataNumber int) string {
+	return c.sign("enclaveIdentity", fmt.Sprintf(`{"id":"QE","version":2,"issueDate":"%s","nextUpdate":"%s",`+
+		`"tcbEvaluationDataNumber":%d,"miscselect":"00000000","miscselectMask":"FFFFFFFF",`+
+		`"attributes":"00000000000000000000000000000000","attributesMask":"FBFFFFFFFFFFFFFF0000000000000000",`+
+		`"mrsigner":"8C4F5775D796503E96137F77C68A829A0056AC8DED70140B081B094490C57BFF","isvprodid":1,`+
+		`"tcbLevels":[{"tcb":{"isvsvn":2},"tcbDate":"2021-05-15T00:00:00Z","tcbStatus":"UpToDate"},`+
+		`{"tcb":{"isvsvn":1},"tcbDate":"2020-08-15T00:00:00Z","tcbStatus":"OutOfDate"}]}`, c.issueDate(),
+		c.nextUpdate(), evaluationDataNumber))
+}
+
+func (c *testCollateral) issueDate() string {
+	return time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+}
+
+func (c *testCollateral) nextUpdate() string {
+	return time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+}
+
+// bundle returns a collateral bundle with the TCB info and QE identity
+func (c *testCollateral) bundle(tcbInfo, qeIdentity string) *models.Collateral {
+	return &models.Collateral{
+		PckCrl:                c.crl(c.signing, c.signingKey),
+		PckCrlIssuerChain:     c.chain,
+		RootCaCrl:             c.crl(c.root, c.rootKey),
+		TcbInfo:               tcbInfo,
+		TcbInfoIssuerChain:    c.chain,
+		QeIdentity:            qeIdentity,
+		QeIdentityIssuerChain: c.chain,
+	}
+}
+
+// verifyTestCollateral verifies the TCB info and QE identity of collateral the way Verify does for the fake PCK
+// certificate and the mock quote parser
+func verifyTestCollateral(t *testing.T, v *Verifier, collateral CollateralProvider,
+	evaluation *EvaluationDataTracker) error {
+	ctx := context.Background()
+	rootCaCrl, err := fetchRootCACrl(ctx, collateral)
+	assert.NoError(t, err)
+	sgxCaCert := v.trustAnchors[0]
+
+	_, err = v.verifiedTcbInfo(ctx, collateral, mocks.NewFakePCKCertObj(), rootCaCrl.GetRootCACrlObj(), sgxCaCert,
+		time.Now(), evaluation)
+	if err != nil {
+		return err
+	}
+	_, err = v.verifiedQeIdentity(ctx, collateral, mocks.NewMockSGXQuoteParser([]byte(testQuote)),
+		rootCaCrl.GetRootCACrlObj(), sgxCaCert, time.Now(), evaluation)
+	return err
+}
+
+func newBundleCollateralProvider(t *testing.T, bundle *models.Collateral) CollateralProvider {
+	client, err := domain.NewCollateralBundleClient(bundle)
+	assert.NoError(t, err)
+	return NewSCSCollateralProvider(client, "")
+}
+
+func TestVerifyCollateralBundleSignatures(t *testing.T) {
+	c := newTestCollateral(t)
+	v, err := New(WithTrustAnchors(c.root))
+	assert.NoError(t, err)
+
+	tcbInfo, qeIdentity := c.tcbInfo(12), c.qeIdentity(12)
+	assert.NoError(t, verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tcbInfo, qeIdentity)), nil))
+
+	// a TCB level rated UpToDate by the caller is rejected, the issuer chain alone does not make the TCB info
+	// trusted
+	tampered := strings.Replace(tcbInfo, `"tcbStatus":"OutOfDate"`, `"tcbStatus":"UpToDate"`, 1)
+	assert.NotEqual(t, tcbInfo, tampered)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tampered, qeIdentity)), nil)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+	assert.Contains(t, err.Error(), "TCB Info signature verification failed")
+
+	tampered = strings.Replace(qeIdentity, `"tcbStatus":"OutOfDate"`, `"tcbStatus":"UpToDate"`, 1)
+	assert.NotEqual(t, qeIdentity, tampered)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tcbInfo, tampered)), nil)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+	assert.Contains(t, err.Error(), "QE Identity signature verification failed")
+
+	// collateral signed by another TCB signing key is rejected with the genuine issuer chain
+	other := newTestCollateral(t)
+	other.root, other.chain = c.root, c.chain
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(other.tcbInfo(12), qeIdentity)), nil)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+}
+
+func TestVerifyEvaluationDataNumberForged(t *testing.T) {
+	c := newTestCollateral(t)
+	v, err := New(WithTrustAnchors(c.root))
+	assert.NoError(t, err)
+	tracker, err := NewEvaluationDataTracker("")
+	assert.NoError(t, err)
+
+	tcbInfo, qeIdentity := c.tcbInfo(12), c.qeIdentity(12)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tcbInfo, qeIdentity)), tracker)
+	assert.NoError(t, err)
+	numbers := map[string]uint{tcbInfoEvaluationKey(""): 12, qeIdentityEvaluationKey: 12}
+	assert.Equal(t, numbers, tracker.Numbers())
+
+	// collateral with a forged number is rejected before the number is tracked, it neither passes the rollback
+	// check nor raises the floor above the genuine collateral
+	forged := strings.Replace(tcbInfo, `"tcbEvaluationDataNumber":12`, `"tcbEvaluationDataNumber":20`, 1)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(forged, qeIdentity)), tracker)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+	assert.Equal(t, numbers, tracker.Numbers())
+
+	forged = strings.Replace(qeIdentity, `"tcbEvaluationDataNumber":12`, `"tcbEvaluationDataNumber":20`, 1)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tcbInfo, forged)), tracker)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+	assert.Equal(t, numbers, tracker.Numbers())
+
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(tcbInfo, qeIdentity)), tracker)
+	assert.NoError(t, err)
+
+	// genuine collateral with a lower number is still rolled back
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, c.bundle(c.tcbInfo(11), qeIdentity)), tracker)
+	assertErrorKind(t, ErrCollateralInvalid, err)
+	assert.Contains(t, err.Error(), "TCB evaluation data rejected")
+	assert.Equal(t, numbers, tracker.Numbers())
+}
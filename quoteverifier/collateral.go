@@ -0,0 +1,147 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"context"
+	"fmt"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// CollateralDocument is a collateral document as published by the SGX Caching Service
+type CollateralDocument struct {
+	// Body is the TCB info or QE identity JSON, or the base64 DER encoded CRL
+	Body []byte
+	// IssuerChain is the PEM encoded certificate chain of the signer of Body, empty for the root CA CRL
+	IssuerChain string
+}
+
+// CollateralProvider provides the collateral quotes are verified with. The Verifier checks the signatures and
+// issuer chains of all documents against its trust anchors, a provider does not need to be trusted.
+type CollateralProvider interface {
+	// TcbInfo returns the TCB info of the platforms with the hex encoded fmspc
+	TcbInfo(ctx context.Context, fmspc string) (*CollateralDocument, error)
+	// QeIdentity returns the identity of the quoting enclave
+	QeIdentity(ctx context.Context) (*CollateralDocument, error)
+	// PckCrl returns the CRL of the PCK CA, ca is processor or platform
+	PckCrl(ctx context.Context, ca string) (*CollateralDocument, error)
+	// RootCaCrl returns the CRL of the SGX root CA
+	RootCaCrl(ctx context.Context) (*CollateralDocument, error)
+}
+
+// scsCollateralProvider fetches collateral from the SGX Caching Service
+type scsCollateralProvider struct {
+	client  domain.HttpClient
+	baseURL string
+}
+
+// NewSCSCollateralProvider returns a CollateralProvider fetching collateral with client from the SGX Caching
+// Service under baseURL
+func NewSCSCollateralProvider(client domain.HttpClient, baseURL string) CollateralProvider {
+	return &scsCollateralProvider{client: client, baseURL: baseURL}
+}
+
+func (p *scsCollateralProvider) TcbInfo(ctx context.Context, fmspc string) (*CollateralDocument, error) {
+	return p.get(ctx, "/tcb?fmspc="+url.QueryEscape(fmspc), "SGX-TCB-Info-Issuer-Chain")
+}
+
+func (p *scsCollateralProvider) QeIdentity(ctx context.Context) (*CollateralDocument, error) {
+	return p.get(ctx, "/qe/identity", "Sgx-Qe-Identity-Issuer-Chain")
+}
+
+func (p *scsCollateralProvider) PckCrl(ctx context.Context, ca string) (*CollateralDocument, error) {
+	return p.get(ctx, "/pckcrl?ca="+url.QueryEscape(ca), "SGX-PCK-CRL-Issuer-Chain")
+}
+
+func (p *scsCollateralProvider) RootCaCrl(ctx context.Context) (*CollateralDocument, error) {
+	return p.get(ctx, "/rootcacrl", "")
+}
+
+// get fetches the collateral document under path, the issuer chain is read from the chainHeader response header
+func (p *scsCollateralProvider) get(ctx context.Context, path, chainHeader string) (*CollateralDocument, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create collateral request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if resp != nil {
+		defer func() {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing collateral response")
+			}
+		}()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to fetch %s from SCS", path)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Invalid status code received for %s: %d", path, resp.StatusCode))
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read %s response body", path)
+	}
+
+	doc := &CollateralDocument{Body: body}
+	if chainHeader != "" {
+		// issuer chains are URL encoded in SCS response headers
+		doc.IssuerChain, err = url.QueryUnescape(resp.Header.Get(chainHeader))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid %s header", chainHeader)
+		}
+	}
+	return doc, nil
+}
+
+// fetchTcbInfo fetches and parses the TCB info of the platforms with the hex encoded fmspc
+func fetchTcbInfo(ctx context.Context, collateral CollateralProvider, fmspc string) (*parser.TcbInfoStruct, error) {
+	doc, err := collateral.TcbInfo(ctx, fmspc)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseTcbInfo(doc.Body, doc.IssuerChain)
+}
+
+// fetchQeIdentity fetches and parses the identity of the quoting enclave
+func fetchQeIdentity(ctx context.Context, collateral CollateralProvider) (*parser.QeIdentityData, error) {
+	doc, err := collateral.QeIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseQeIdentity(doc.Body, doc.IssuerChain)
+}
+
+// fetchRootCACrl fetches and parses the CRL of the SGX root CA
+func fetchRootCACrl(ctx context.Context, collateral CollateralProvider) (*parser.RootCACrl, error) {
+	doc, err := collateral.RootCaCrl(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseRootCACrl(doc.Body)
+}
+
+// pckCrlCA returns the PCK CA named in the query of a CRL distribution point of a PCK certificate
+func pckCrlCA(distributionPoint string) (string, error) {
+	dp, err := url.Parse(distributionPoint)
+	if err != nil {
+		return "", errors.Wrap(err, "Invalid PCK CRL distribution point")
+	}
+	ca := dp.Query().Get("ca")
+	if ca == "" {
+		return "", errors.Errorf("PCK CRL distribution point %s names no CA", distributionPoint)
+	}
+	return ca, nil
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tamperingProvider rewrites the TCB info and QE identity of a provider
+type tamperingProvider struct {
+	CollateralProvider
+	old, new string
+}
+
+func (p *tamperingProvider) TcbInfo(ctx context.Context, fmspc string) (*CollateralDocument, error) {
+	return p.tamper(p.CollateralProvider.TcbInfo(ctx, fmspc))
+}
+
+func (p *tamperingProvider) QeIdentity(ctx context.Context) (*CollateralDocument, error) {
+	return p.tamper(p.CollateralProvider.QeIdentity(ctx))
+}
+
+func (p *tamperingProvider) tamper(doc *CollateralDocument, err error) (*CollateralDocument, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &CollateralDocument{Body: bytes.Replace(doc.Body, []byte(p.old), []byte(p.new), 1),
+		IssuerChain: doc.IssuerChain}, nil
+}
+
+// recordingClient answers every request with the same response and records the requested URLs
+type recordingClient struct {
+	statusCode int
+	header     http.Header
+	urls       []string
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, req.URL.String())
+	return &http.Response{
+		StatusCode: c.statusCode,
+		Header:     c.header,
+		Body:       ioutil.NopCloser(strings.NewReader("body")),
+	}, nil
+}
+
+func TestSCSCollateralProvider(t *testing.T) {
+	chain := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	header := http.Header{}
+	header.Set("SGX-TCB-Info-Issuer-Chain", url.QueryEscape(chain))
+	header.Set("Sgx-Qe-Identity-Issuer-Chain", url.QueryEscape(chain))
+	header.Set("SGX-PCK-CRL-Issuer-Chain", url.QueryEscape(chain))
+	client := &recordingClient{statusCode: http.StatusOK, header: header}
+	provider := NewSCSCollateralProvider(client, "https://scs/v1")
+	ctx := context.Background()
+
+	doc, err := provider.TcbInfo(ctx, "00606a000000")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("body"), doc.Body)
+	assert.Equal(t, chain, doc.IssuerChain)
+
+	doc, err = provider.QeIdentity(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, chain, doc.IssuerChain)
+
+	doc, err = provider.PckCrl(ctx, "platform")
+	assert.NoError(t, err)
+	assert.Equal(t, chain, doc.IssuerChain)
+
+	doc, err = provider.RootCaCrl(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, doc.IssuerChain)
+
+	assert.Equal(t, []string{
+		"https://scs/v1/tcb?fmspc=00606a000000",
+		"https://scs/v1/qe/identity",
+		"https://scs/v1/pckcrl?ca=platform",
+		"https://scs/v1/rootcacrl",
+	}, client.urls)
+
+	_, err = NewSCSCollateralProvider(&recordingClient{statusCode: http.StatusNotFound}, "https://scs/v1").
+		QeIdentity(ctx)
+	assert.Error(t, err)
+
+	header = http.Header{}
+	header.Set("Sgx-Qe-Identity-Issuer-Chain", "%zz")
+	_, err = NewSCSCollateralProvider(&recordingClient{statusCode: http.StatusOK, header: header}, "https://scs/v1").
+		QeIdentity(ctx)
+	assert.Error(t, err)
+}
+
+func TestPckCrlCA(t *testing.T) {
+	ca, err := pckCrlCA("https://api.trustedservices.intel.com/sgx/certification/v3/pckcrl?ca=processor&encoding=der")
+	assert.NoError(t, err)
+	assert.Equal(t, "processor", ca)
+
+	_, err = pckCrlCA("https://api.trustedservices.intel.com/sgx/certification/v3/pckcrl")
+	assert.Error(t, err)
+
+	_, err = pckCrlCA("%zz")
+	assert.Error(t, err)
+}
+
+func TestCollateralProviderUntrusted(t *testing.T) {
+	c := newTestCollateral(t)
+	v, err := New(WithTrustAnchors(c.root))
+	assert.NoError(t, err)
+	provider := newBundleCollateralProvider(t, c.bundle(c.tcbInfo(12), c.qeIdentity(12)))
+	assert.NoError(t, verifyTestCollateral(t, v, provider, nil))
+
+	for _, tampered := range []CollateralProvider{
+		&tamperingProvider{CollateralProvider: provider, old: `"tcbStatus":"OutOfDate"`, new: `"tcbStatus":"UpToDate"`},
+		&tamperingProvider{CollateralProvider: provider, old: `"isvsvn":2`, new: `"isvsvn":1`},
+		&tamperingProvider{CollateralProvider: provider, old: `"signature":"`, new: `"signature":"00`},
+	} {
+		err = verifyTestCollateral(t, v, tampered, nil)
+		assertErrorKind(t, ErrCollateralInvalid, err)
+	}
+
+	// genuine documents with an issuer chain not leading to a trust anchor are rejected
+	other := newTestCollateral(t)
+	err = verifyTestCollateral(t, v, newBundleCollateralProvider(t, other.bundle(other.tcbInfo(12),
+		other.qeIdentity(12))), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import "fmt"
+
+// ErrorKind classifies the reason a quote could not be verified
+type ErrorKind int
+
+const (
+	// ErrInvalidRequest is returned for verify options which can not be satisfied
+	ErrInvalidRequest ErrorKind = iota + 1
+	// ErrInvalidQuote is returned for quotes, or PCK certificates in quotes, which can not be parsed
+	ErrInvalidQuote
+	// ErrCollateralUnavailable is returned when collateral could not be fetched or parsed
+	ErrCollateralUnavailable
+	// ErrCollateralInvalid is returned for TCB info and QE identity which fail verification
+	ErrCollateralInvalid
+	// ErrUntrusted is returned when a certificate or CRL does not chain to a trust anchor or has expired
+	ErrUntrusted
+	// ErrRevoked is returned when a certificate is revoked
+	ErrRevoked
+	// ErrPolicy is returned for quotes rejected by the platform or report data policy
+	ErrPolicy
+	// ErrSignature is returned when the enclave or QE report signature is invalid
+	ErrSignature
+	// ErrCanceled is returned when the context of the verification is done before it completed
+	ErrCanceled
+)
+
+var errorKindNames = map[ErrorKind]string{
+	ErrInvalidRequest:        "invalid request",
+	ErrInvalidQuote:          "invalid quote",
+	ErrCollateralUnavailable: "collateral unavailable",
+	ErrCollateralInvalid:     "invalid collateral",
+	ErrUntrusted:             "untrusted",
+	ErrRevoked:               "revoked",
+	ErrPolicy:                "rejected by policy",
+	ErrSignature:             "invalid signature",
+	ErrCanceled:              "canceled",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+// Error is returned by Verify for quotes which could not be verified. Message is suitable for callers of
+// the service, Err holds the underlying cause.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind ErrorKind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// qeIdentityEvaluationKey is the key of the QE identity in an EvaluationDataTracker, TCB info is tracked per FMSPC
+const qeIdentityEvaluationKey = "qeIdentity"
+
+func tcbInfoEvaluationKey(fmspc string) string {
+	return "tcbInfo:" + strings.ToLower(fmspc)
+}
+
+// EvaluationDataTracker tracks the highest tcbEvaluationDataNumber seen in verified TCB info, per FMSPC, and QE
+// identity. Collateral with a lower number is rejected, which keeps a stale or malicious collateral provider from
+// rolling back to TCB evaluation data that still rates now vulnerable TCB levels UpToDate. An
+// EvaluationDataTracker is safe for concurrent use.
+type EvaluationDataTracker struct {
+	path string
+
+	mu      sync.Mutex
+	highest map[string]uint
+}
+
+// NewEvaluationDataTracker returns a tracker which persists the highest numbers in the file at path, so that
+// they survive restarts. The numbers are only kept in memory when path is empty.
+func NewEvaluationDataTracker(path string) (*EvaluationDataTracker, error) {
+	t := &EvaluationDataTracker{
+		path:    path,
+		highest: make(map[string]uint),
+	}
+	if path == "" {
+		return t, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "quoteverifier:NewEvaluationDataTracker() Could not read TCB evaluation data numbers")
+	}
+	// a corrupt file is not reset, that would disable the rollback protection
+	if err := json.Unmarshal(data, &t.highest); err != nil {
+		return nil, errors.Wrap(err, "quoteverifier:NewEvaluationDataTracker() Could not parse TCB evaluation data numbers")
+	}
+	return t, nil
+}
+
+// Observe records the tcbEvaluationDataNumber of verified collateral under key. It fails for a number below the
+// highest number recorded under key.
+func (t *EvaluationDataTracker) Observe(key string, number uint) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	highest, ok := t.highest[key]
+	if ok && number < highest {
+		return errors.Errorf("tcbEvaluationDataNumber %d of %s is below %d seen before", number, key, highest)
+	}
+	if ok && number == highest {
+		return nil
+	}
+	t.highest[key] = number
+	log.Infof("quoteverifier:Observe() tcbEvaluationDataNumber of %s raised to %d", key, number)
+	if err := t.save(); err != nil {
+		// the number is still enforced until the next restart
+		log.WithError(err).Error("quoteverifier:Observe() Could not persist TCB evaluation data numbers")
+	}
+	return nil
+}
+
+// Numbers returns the highest tcbEvaluationDataNumber recorded under each key
+func (t *EvaluationDataTracker) Numbers() map[string]uint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	numbers := make(map[string]uint, len(t.highest))
+	for key, number := range t.highest {
+		numbers[key] = number
+	}
+	return numbers
+}
+
+// save writes the numbers to the file of the tracker, replacing it atomically
+func (t *EvaluationDataTracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.highest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Could not marshal TCB evaluation data numbers")
+	}
+	// the file is replaced atomically so that a crash does not leave partial numbers behind
+	tmp, err := ioutil.TempFile(filepath.Dir(t.path), ".tcb-evaluation-")
+	if err != nil {
+		return errors.Wrap(err, "Could not create TCB evaluation data file")
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "Could not write TCB evaluation data file")
+	}
+	if err := os.Rename(tmp.Name(), t.path); err != nil {
+		return errors.Wrap(err, "Could not write TCB evaluation data file")
+	}
+	return nil
+}
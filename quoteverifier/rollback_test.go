@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluationDataTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tcb-evaluation-data.json")
+	tracker, err := NewEvaluationDataTracker(path)
+	assert.NoError(t, err)
+	assert.Empty(t, tracker.Numbers())
+
+	tcbInfoKey := tcbInfoEvaluationKey("00906ed50000")
+	assert.NoError(t, tracker.Observe(tcbInfoKey, 12))
+	assert.NoError(t, tracker.Observe(tcbInfoKey, 12))
+	assert.Error(t, tracker.Observe(tcbInfoKey, 11))
+	assert.NoError(t, tracker.Observe(tcbInfoKey, 13))
+	assert.NoError(t, tracker.Observe(qeIdentityEvaluationKey, 12))
+	assert.Equal(t, map[string]uint{tcbInfoKey: 13, qeIdentityEvaluationKey: 12}, tracker.Numbers())
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the numbers survive restarts
+	reloaded, err := NewEvaluationDataTracker(path)
+	assert.NoError(t, err)
+	assert.Equal(t, tracker.Numbers(), reloaded.Numbers())
+	assert.Error(t, reloaded.Observe(tcbInfoKey, 12))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = NewEvaluationDataTracker(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,535 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+
+// Package quoteverifier verifies SGX ECDSA quotes independently of the HTTP layer of the service, so that
+// quote verification can be embedded in other Go services.
+package quoteverifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	clog "intel/isecl/lib/common/v5/log"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"intel/isecl/sqvs/v5/resource/utils"
+	"intel/isecl/sqvs/v5/resource/verifier"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var log = clog.GetDefaultLogger()
+
+// Policy holds the verification policy of a Verifier
+type Policy struct {
+	// CollateralExpiryGrace is how long collateral past its nextUpdate is still accepted
+	CollateralExpiryGrace time.Duration
+	// Platform is evaluated for PCK certificates issued by the PCK Platform CA
+	Platform PlatformPolicy
+	// MinTcbEvaluationDataNumber rejects TCB info and QE identity with a lower tcbEvaluationDataNumber, it is
+	// raised to stop accepting the TCB evaluation data superseded by a TCB recovery event
+	MinTcbEvaluationDataNumber uint
+}
+
+// PlatformPolicy selects the Scalable SGX platform configurations rejected for quotes with a PCK certificate
+// issued by the PCK Platform CA. All platform configurations are accepted by default.
+type PlatformPolicy struct {
+	RejectSmtEnabled      bool
+	RejectDynamicPlatform bool
+}
+
+// Verifier verifies SGX ECDSA quotes against a set of trust anchors with collateral from a collateral
+// provider. A Verifier is safe for concurrent use.
+type Verifier struct {
+	trustAnchors []*x509.Certificate
+	collateral   CollateralProvider
+	now          func() time.Time
+	policy       Policy
+	evaluation   *EvaluationDataTracker
+	crlNumbers   *verifier.CrlNumberTracker
+}
+
+// Option configures a Verifier
+type Option func(*Verifier) error
+
+// WithTrustAnchors sets the trusted SGX root CA certificates
+func WithTrustAnchors(certs ...*x509.Certificate) Option {
+	return func(v *Verifier) error {
+		for _, cert := range certs {
+			if cert == nil {
+				return errors.New("trust anchor is empty")
+			}
+		}
+		v.trustAnchors = append(v.trustAnchors, certs...)
+		return nil
+	}
+}
+
+// WithCollateralProvider sets the provider of the TCB info, QE identity, PCK CRL and root CA CRL
+func WithCollateralProvider(provider CollateralProvider) Option {
+	return func(v *Verifier) error {
+		if provider == nil {
+			return errors.New("collateral provider is empty")
+		}
+		v.collateral = provider
+		return nil
+	}
+}
+
+// WithClock sets the source of the current time, which is the default verification time
+func WithClock(now func() time.Time) Option {
+	return func(v *Verifier) error {
+		if now == nil {
+			return errors.New("clock is empty")
+		}
+		v.now = now
+		return nil
+	}
+}
+
+// WithEvaluationDataTracker rejects TCB info and QE identity whose tcbEvaluationDataNumber is below the highest
+// number tracked, and tracks the numbers of the verified collateral
+func WithEvaluationDataTracker(tracker *EvaluationDataTracker) Option {
+	return func(v *Verifier) error {
+		if tracker == nil {
+			return errors.New("TCB evaluation data tracker is empty")
+		}
+		v.evaluation = tracker
+		return nil
+	}
+}
+
+// WithPolicy sets the verification policy
+func WithPolicy(policy Policy) Option {
+	return func(v *Verifier) error {
+		if policy.CollateralExpiryGrace < 0 {
+			return errors.New("collateral expiry grace must not be negative")
+		}
+		v.policy = policy
+		return nil
+	}
+}
+
+// New returns a Verifier, trust anchors are required. Without a collateral provider only quotes verified
+// with collateral in VerifyOptions can be verified.
+func New(opts ...Option) (*Verifier, error) {
+	v := &Verifier{
+		now:        time.Now,
+		crlNumbers: verifier.NewCrlNumberTracker(),
+	}
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, errors.Wrap(err, "quoteverifier:New() Invalid option")
+		}
+	}
+	if len(v.trustAnchors) == 0 {
+		return nil, errors.New("quoteverifier:New() No trust anchors given")
+	}
+	return v, nil
+}
+
+// LoadTrustAnchors reads the PEM encoded trust anchors from a file
+func LoadTrustAnchors(certFile string) ([]*x509.Certificate, error) {
+	certBytes, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "LoadTrustAnchors: error reading SGX CA certificate")
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, certBytes = pem.Decode(certBytes)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "LoadTrustAnchors: error parsing SGX CA certificate")
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("LoadTrustAnchors: Pem Decode error")
+	}
+	return certs, nil
+}
+
+// VerifyOptions are the per quote options of Verify
+type VerifyOptions struct {
+	// UserData is checked against the report data of the quote with ReportDataBinding, sha256 when empty.
+	// Nonce is only used by the nonce-pubkey binding.
+	UserData          []byte
+	Nonce             []byte
+	ReportDataBinding string
+	// StrictReportData fails verification when UserData does not match instead of reporting the mismatch
+	StrictReportData bool
+	// VerificationTime is the point in time the quote and its collateral are verified at, the current time
+	// when zero
+	VerificationTime time.Time
+	// Collateral is used instead of the collateral provider when given
+	Collateral *models.Collateral
+}
+
+// Result is the outcome of a successful verification
+type Result struct {
+	// TcbStatus is the status of the TCB level of the platform in the TCB info
+	TcbStatus string
+	// UserDataMatch reports whether UserData matched the report data, it is false when no UserData was given
+	UserDataMatch  bool
+	EnclaveReport  models.EnclaveReport
+	Platform       models.Platform
+	PlatformPolicy *models.PlatformPolicy
+	// VerificationTime is the point in time the quote was verified at
+	VerificationTime time.Time
+	// CollateralExpiry is the earliest expiry across all collateral, CollateralExpired reports collateral past
+	// its nextUpdate accepted within the collateral expiry grace
+	CollateralExpiry  time.Time
+	CollateralExpired bool
+	Collateral        models.CollateralInfo
+}
+
+// Verify verifies a raw SGX ECDSA quote. Failures are returned as *Error. Collateral requests are bound to ctx,
+// verification is abandoned with ErrCanceled once ctx is done.
+func (v *Verifier) Verify(ctx context.Context, quote []byte, opts VerifyOptions) (*Result, error) {
+	log.Trace("quoteverifier:Verify() Entering")
+	defer log.Trace("quoteverifier:Verify() Leaving")
+
+	if ctx.Err() != nil {
+		return nil, canceledError(ctx)
+	}
+
+	verificationTime, err := v.verificationTime(opts.VerificationTime)
+	if err != nil {
+		log.WithError(err).Error("Invalid verification time")
+		return nil, newError(ErrInvalidRequest, err.Error(), nil)
+	}
+
+	if err = checkVerifyOptions(opts); err != nil {
+		log.WithError(err).Error("Invalid report data binding request")
+		return nil, newError(ErrInvalidRequest, err.Error(), nil)
+	}
+
+	collateral := v.collateral
+	if opts.Collateral != nil {
+		bundleClient, err := domain.NewCollateralBundleClient(opts.Collateral)
+		if err != nil {
+			log.WithError(err).Error("Invalid collateral bundle")
+			return nil, newError(ErrInvalidRequest, "Invalid collateral bundle: "+err.Error(), nil)
+		}
+		collateral = NewSCSCollateralProvider(bundleClient, "")
+	}
+	if collateral == nil {
+		return nil, newError(ErrCollateralUnavailable, "No collateral provider", nil)
+	}
+
+	if len(quote) < constants.MinQuoteSize || len(quote) > constants.MaxQuoteSize {
+		log.Error("Quote Size is invalid. Seems to be an invalid ecdsa quote")
+		return nil, newError(ErrInvalidQuote, "Could not parse sgx ecdsa quote", nil)
+	}
+	quoteObj := parser.NewSGXQuoteParser(quote)
+	if quoteObj == nil {
+		log.Error("Could not parse sgx ecdsa quote")
+		return nil, newError(ErrInvalidQuote, "Could not parse sgx ecdsa quote", nil)
+	}
+
+	pckCertBytes, err := utils.GetCertPemData(quoteObj.GetQuotePckCertObj())
+	if err != nil {
+		log.WithError(err).Error("Cannot extract PCK cert data")
+		return nil, newError(ErrInvalidQuote, "Cannot extract PCK cert data", err)
+	}
+
+	certObj, err := parser.ParsePCKCertObj(pckCertBytes, func(distributionPoint string) ([]byte, string, error) {
+		ca, err := pckCrlCA(distributionPoint)
+		if err != nil {
+			return nil, "", err
+		}
+		doc, err := collateral.PckCrl(ctx, ca)
+		if err != nil {
+			return nil, "", err
+		}
+		return doc.Body, doc.IssuerChain, nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Invalid PCK Certificate Buffer")
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
+		return nil, newError(ErrInvalidQuote, "Invalid PCK Certificate Buffer", nil)
+	}
+
+	sgxCaCert := v.trustAnchor(quoteObj.GetQuotePckCertRootCAList())
+
+	rootCaCrlObj, err := fetchRootCACrl(ctx, collateral)
+	if err != nil {
+		log.WithError(err).Error("Root CA CRL fetch/parsing failed")
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
+		return nil, newError(ErrCollateralUnavailable, "Root CA CRL fetch/parsing failed", err)
+	}
+	rootCaCrl := rootCaCrlObj.GetRootCACrlObj()
+
+	err = verifier.VerifyRootCaCrl(rootCaCrl, sgxCaCert, verificationTime, v.policy.CollateralExpiryGrace)
+	if err != nil {
+		log.WithError(err).Error("Cannot verify Root CA crl")
+		return nil, newError(ErrUntrusted, "Cannot verify Root CA crl", err)
+	}
+
+	err = verifier.CheckInterCaRevocation(rootCaCrl, append(quoteObj.GetQuotePckCertInterCAList(),
+		certObj.GetPckCrlInterCaList()...))
+	if err != nil {
+		log.WithError(err).Error("PCK intermediate CA is revoked")
+		return nil, newError(ErrRevoked, "PCK intermediate CA is revoked", err)
+	}
+	log.Info("PCK Intermediate CA Certificates checked against Root CA Certificate Revocation List")
+
+	err = verifier.VerifyPCKCertificate(quoteObj.GetQuotePckCertObj(), quoteObj.GetQuotePckCertInterCAList(),
+		quoteObj.GetQuotePckCertRootCAList(), certObj.GetPckCrlObj(), sgxCaCert, verificationTime)
+	if err != nil {
+		log.WithError(err).Error("Cannot verify pck cert")
+		return nil, newError(ErrUntrusted, "Cannot verify pck cert", err)
+	}
+
+	log.Info("PCK Certificate Chain Verified")
+	// older collateral is legitimately used for caller supplied collateral and past verification times, only
+	// the collateral of the collateral provider verified at the current time is tracked
+	var crlNumbers *verifier.CrlNumberTracker
+	var evaluation *EvaluationDataTracker
+	if opts.Collateral == nil && opts.VerificationTime.IsZero() {
+		crlNumbers = v.crlNumbers
+		evaluation = v.evaluation
+	}
+	err = verifier.VerifyPckCrl(certObj.GetPckCrlURL(), certObj.GetPckCrlObj(), certObj.GetPckCrlInterCaList(),
+		certObj.GetPckCrlRootCaList(), sgxCaCert, verificationTime, v.policy.CollateralExpiryGrace, crlNumbers)
+	if err != nil {
+		log.WithError(err).Error("Cannot verify PCK crl")
+		return nil, newError(ErrUntrusted, "Cannot verify PCK crl", err)
+	}
+
+	log.Info("PCK Certificates checked against PCK Certificate Revocation List")
+	platformPolicy, err := verifier.VerifyPlatformPolicy(quoteObj.GetQuotePckCertObj(), certObj.GetPlatformInfo(),
+		verifier.PlatformPolicy(v.policy.Platform))
+	if err != nil {
+		log.WithError(err).Error("Platform configuration rejected by the platform policy")
+		return nil, newError(ErrPolicy, "Platform configuration rejected by the platform policy", err)
+	}
+
+	tcbObj, err := v.verifiedTcbInfo(ctx, collateral, certObj, rootCaCrl, sgxCaCert, verificationTime, evaluation)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("TCBInfo Structure Verified")
+	tcbUptoDateStatus := tcbObj.GetTcbUptoDateStatus(certObj.GetPckCertTcbLevels())
+	log.Info("Current Tcb-Upto-Date Status is : ", tcbUptoDateStatus)
+
+	qeIDObj, err := v.verifiedQeIdentity(ctx, collateral, quoteObj, rootCaCrl, sgxCaCert, verificationTime,
+		evaluation)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("QEIdentity Structure Verified")
+
+	collateralExpiry, collateralExpired, err := getCollateralExpiry(quoteObj, certObj, rootCaCrl, tcbObj, qeIDObj,
+		verificationTime)
+	if err != nil {
+		log.WithError(err).Error("Collateral expiry could not be determined")
+		return nil, newError(ErrCollateralInvalid, "Collateral expiry could not be determined", err)
+	}
+
+	userDataMatch := false
+	if len(opts.UserData) != 0 {
+		err = verifier.VerifyReportData(quoteObj.GetReportData(), opts.ReportDataBinding, opts.UserData, opts.Nonce)
+		if err != nil {
+			log.WithError(err).Error("User Data does not match the report data in quote")
+			if opts.StrictReportData {
+				return nil, newError(ErrPolicy, "User Data does not match the report data in quote", err)
+			}
+		} else {
+			userDataMatch = true
+			log.Info("User Data matches the report data in quote")
+		}
+	}
+
+	repBlob, err := quoteObj.GetHeaderAndEnclaveReportBlob()
+	if err != nil {
+		log.WithError(err).Error("Invalid Header and Enclave Report Blob in SGX ECDSA Quote")
+		return nil, newError(ErrSignature, "Invalid Header and Enclave Report Blob in SGX ECDSA Quote", err)
+	}
+
+	err = verifier.VerifyEnclaveReportSignature(quoteObj.GetEnclaveReportSignature(), repBlob, quoteObj.GetAttestationPublicKey())
+	if err != nil {
+		log.WithError(err).Error("Enclave Report Signature Verification failed")
+		return nil, newError(ErrSignature, "Enclave Report Signature Verification failed", err)
+	}
+
+	log.Info("Enclave Report Signature Verified")
+	qeBlob, err := quoteObj.GetQeReportBlob()
+	if err != nil {
+		log.Error(err.Error())
+		return nil, newError(ErrSignature, "Invalid QE Report Blob in SGX ECDSA Quote", err)
+	}
+	err = verifier.VerifyQeReportSignature(quoteObj.GetQeReportSignature(), qeBlob, certObj.GetPCKPublicKey())
+	if err != nil {
+		log.WithError(err).Error("QE Report Signature Verification failed")
+		return nil, newError(ErrSignature, "QE Report Signature Verification failed", err)
+	}
+	log.Info("QE Report Signature Verified")
+
+	quoteHeader := quoteObj.GetQuoteHeader()
+	platformInfo := certObj.GetPlatformInfo()
+	result := &Result{
+		TcbStatus:     tcbUptoDateStatus,
+		UserDataMatch: userDataMatch,
+		EnclaveReport: newEnclaveReport(quoteObj.GetEnclaveReport()),
+		Platform: models.Platform{
+			Fmspc:              certObj.GetFmspcValue(),
+			PceID:              platformInfo.PceID,
+			QeSvn:              quoteHeader.QeSvn,
+			PceSvn:             quoteHeader.PceSvn,
+			PPID:               platformInfo.PPID,
+			SgxType:            platformInfo.SgxType,
+			PlatformInstanceID: platformInfo.PlatformInstanceID,
+			Configuration:      platformInfo.Configuration,
+		},
+		PlatformPolicy:    platformPolicy,
+		VerificationTime:  verificationTime,
+		CollateralExpiry:  collateralExpiry,
+		CollateralExpired: collateralExpired,
+		Collateral: models.CollateralInfo{
+			TcbInfoVersion:             tcbObj.TcbInfoData.TcbInfo.Version,
+			TcbInfoIssueDate:           tcbObj.GetTcbInfoIssueDate(),
+			TcbEvaluationDataNumber:    tcbObj.TcbInfoData.TcbInfo.TcbEvaluationDataNumber,
+			QeIdentityVersion:          qeIDObj.QEJson.EnclaveIdentity.Version,
+			QeIdentityIssueDate:        qeIDObj.GetQeIDIssueDate(),
+			QeIdentityEvaluationNumber: qeIDObj.QEJson.EnclaveIdentity.TcbEvaluationDataNumber,
+		},
+	}
+
+	log.Info("Sgx Ecdsa Quote Verification completed")
+	return result, nil
+}
+
+// verifiedTcbInfo fetches the TCB info of the platform of certObj from collateral and verifies it. The
+// tcbEvaluationDataNumber of the TCB info is only checked, and tracked by evaluation, once its signature verified.
+func (v *Verifier) verifiedTcbInfo(ctx context.Context, collateral CollateralProvider, certObj domain.PCKCertParser,
+	rootCaCrl *pkix.CertificateList, sgxCaCert *x509.Certificate, verificationTime time.Time,
+	evaluation *EvaluationDataTracker) (*parser.TcbInfoStruct, error) {
+	tcbObj, err := fetchTcbInfo(ctx, collateral, certObj.GetFmspcValue())
+	if err != nil {
+		log.WithError(err).Error("Get TCB Info data parsing/fetch failed")
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
+		return nil, newError(ErrCollateralUnavailable, "Get TCB Info data parsing/fetch failed", err)
+	}
+
+	err = verifyTcbInfo(certObj, tcbObj, sgxCaCert, verificationTime, v.policy.CollateralExpiryGrace)
+	if err != nil {
+		log.WithError(err).Error("TCBInfo Verification failed")
+		return nil, newError(ErrCollateralInvalid, "TCBInfo Verification failed", err)
+	}
+
+	err = verifier.CheckInterCaRevocation(rootCaCrl, tcbObj.GetTcbInfoInterCaList())
+	if err != nil {
+		log.WithError(err).Error("TCBInfo signing CA is revoked")
+		return nil, newError(ErrRevoked, "TCBInfo signing CA is revoked", err)
+	}
+
+	err = v.checkEvaluationDataNumber(evaluation, tcbInfoEvaluationKey(certObj.GetFmspcValue()),
+		tcbObj.TcbInfoData.TcbInfo.TcbEvaluationDataNumber)
+	if err != nil {
+		log.WithError(err).Error("TCBInfo TCB evaluation data rejected")
+		return nil, newError(ErrCollateralInvalid, "TCBInfo TCB evaluation data rejected", err)
+	}
+	return tcbObj, nil
+}
+
+// verifiedQeIdentity fetches the identity of the quoting enclave from collateral and verifies it against the QE
+// report of quoteObj. The tcbEvaluationDataNumber of the QE identity is only checked, and tracked by evaluation,
+// once its signature verified.
+func (v *Verifier) verifiedQeIdentity(ctx context.Context, collateral CollateralProvider,
+	quoteObj domain.SGXQuoteParser, rootCaCrl *pkix.CertificateList, sgxCaCert *x509.Certificate,
+	verificationTime time.Time, evaluation *EvaluationDataTracker) (*parser.QeIdentityData, error) {
+	qeIDObj, err := fetchQeIdentity(ctx, collateral)
+	if err != nil {
+		log.WithError(err).Error("QEIdentity Parsing failed")
+		if ctx.Err() != nil {
+			return nil, canceledError(ctx)
+		}
+		return nil, newError(ErrCollateralUnavailable, "QEIdentity Parsing failed", err)
+	}
+
+	err = verifyQeIdentity(qeIDObj, quoteObj, sgxCaCert, verificationTime, v.policy.CollateralExpiryGrace)
+	if err != nil {
+		log.WithError(err).Error("verifyQeIdentity failed")
+		return nil, newError(ErrCollateralInvalid, "Verification of QeIdentity failed", err)
+	}
+
+	err = verifier.CheckInterCaRevocation(rootCaCrl, qeIDObj.GetQeInfoInterCaList())
+	if err != nil {
+		log.WithError(err).Error("QEIdentity signing CA is revoked")
+		return nil, newError(ErrRevoked, "QEIdentity signing CA is revoked", err)
+	}
+
+	err = v.checkEvaluationDataNumber(evaluation, qeIdentityEvaluationKey,
+		uint(qeIDObj.QEJson.EnclaveIdentity.TcbEvaluationDataNumber))
+	if err != nil {
+		log.WithError(err).Error("QEIdentity TCB evaluation data rejected")
+		return nil, newError(ErrCollateralInvalid, "QEIdentity TCB evaluation data rejected", err)
+	}
+	return qeIDObj, nil
+}
+
+// verificationTime returns the point in time requested for verification, the current time when none is
+// given. Verification in the future is rejected, collateral can not be known to be valid at that time.
+func (v *Verifier) verificationTime(requested time.Time) (time.Time, error) {
+	now := v.now()
+	if requested.IsZero() {
+		return now, nil
+	}
+	if requested.After(now) {
+		return time.Time{}, errors.New("Invalid verificationTime, must not be in the future")
+	}
+	return requested, nil
+}
+
+// checkEvaluationDataNumber checks the tcbEvaluationDataNumber of verified collateral against the minimum of the
+// policy and, when a tracker is given, the highest number tracked under key
+func (v *Verifier) checkEvaluationDataNumber(tracker *EvaluationDataTracker, key string, number uint) error {
+	if number < v.policy.MinTcbEvaluationDataNumber {
+		return errors.Errorf("tcbEvaluationDataNumber %d of %s is below the minimum %d", number, key,
+			v.policy.MinTcbEvaluationDataNumber)
+	}
+	if tracker == nil {
+		return nil
+	}
+	return tracker.Observe(key, number)
+}
+
+// trustAnchor returns the trust anchor matching the root CA of the PCK certificate chain in the quote. The
+// first trust anchor is returned when none matches, the chain then fails verification against it.
+func (v *Verifier) trustAnchor(quoteRootCAs []*x509.Certificate) *x509.Certificate {
+	for _, anchor := range v.trustAnchors {
+		for _, rootCA := range quoteRootCAs {
+			if rootCA != nil && bytes.Equal(anchor.Raw, rootCA.Raw) {
+				return anchor
+			}
+		}
+	}
+	return v.trustAnchors[0]
+}
+
+// canceledError reports verification abandoned because ctx is done
+func canceledError(ctx context.Context) *Error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return newError(ErrCanceled, "Quote verification deadline exceeded", ctx.Err())
+	}
+	return newError(ErrCanceled, "Quote verification cancelled", ctx.Err())
+}
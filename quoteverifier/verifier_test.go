@@ -0,0 +1,406 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package quoteverifier
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/mocks"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"intel/isecl/sqvs/v5/test/utils"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestCAs writes a test root CA and an intermediate CA issued by it to a temporary directory
+func createTestCAs(t *testing.T) (string, string) {
+	keypair, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	rootCAFile := filepath.Join(dir, "trustedSGXRootCA.pem")
+	intermediateCAFile := filepath.Join(dir, "intermediateSGXRootCA.pem")
+	utils.CreateTestCertificate(rootCAFile, "Intel SGX Root CA", keypair, true, nil)
+	utils.CreateTestCertificate(intermediateCAFile, "Intel SGX PCK Processor CA", keypair, true, nil)
+	return rootCAFile, intermediateCAFile
+}
+
+func TestNew(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+	anchors, err := LoadTrustAnchors(rootCAFile)
+	assert.NoError(t, err)
+	assert.Len(t, anchors, 1)
+
+	_, err = New()
+	assert.Error(t, err)
+
+	_, err = New(WithTrustAnchors(anchors...), WithClock(nil))
+	assert.Error(t, err)
+
+	_, err = New(WithTrustAnchors(anchors...), WithPolicy(Policy{CollateralExpiryGrace: -time.Hour}))
+	assert.Error(t, err)
+
+	_, err = New(WithTrustAnchors(nil))
+	assert.Error(t, err)
+
+	_, err = New(WithTrustAnchors(anchors...), WithEvaluationDataTracker(nil))
+	assert.Error(t, err)
+
+	_, err = New(WithTrustAnchors(anchors...), WithCollateralProvider(nil))
+	assert.Error(t, err)
+
+	v, err := New(WithTrustAnchors(anchors...), WithCollateralProvider(NewSCSCollateralProvider(
+		mocks.NewClientMock(http.StatusOK), "https://scs/scs/sgx/certification/v1")))
+	assert.NoError(t, err)
+	assert.NotNil(t, v.collateral)
+}
+
+func TestLoadTrustAnchors(t *testing.T) {
+	_, err := LoadTrustAnchors(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+
+	emptyFile := filepath.Join(t.TempDir(), "empty.pem")
+	assert.NoError(t, ioutil.WriteFile(emptyFile, []byte(""), 0600))
+	_, err = LoadTrustAnchors(emptyFile)
+	assert.Error(t, err)
+
+	rootCAFile, intermediateCAFile := createTestCAs(t)
+	rootCA, err := ioutil.ReadFile(rootCAFile)
+	assert.NoError(t, err)
+	intermediateCA, err := ioutil.ReadFile(intermediateCAFile)
+	assert.NoError(t, err)
+	bundleFile := filepath.Join(t.TempDir(), "bundle.pem")
+	assert.NoError(t, ioutil.WriteFile(bundleFile, append(rootCA, intermediateCA...), 0600))
+	anchors, err := LoadTrustAnchors(bundleFile)
+	assert.NoError(t, err)
+	assert.Len(t, anchors, 2)
+}
+
+func TestTrustAnchor(t *testing.T) {
+	rootCAFile, intermediateCAFile := createTestCAs(t)
+	rootCA := utils.ReadCertFromFile(t, rootCAFile)
+	intermediateCA := utils.ReadCertFromFile(t, intermediateCAFile)
+
+	v, err := New(WithTrustAnchors(rootCA, intermediateCA))
+	assert.NoError(t, err)
+	assert.Equal(t, intermediateCA, v.trustAnchor([]*x509.Certificate{intermediateCA}))
+	assert.Equal(t, rootCA, v.trustAnchor(nil))
+}
+
+func TestVerificationTime(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+	anchors, err := LoadTrustAnchors(rootCAFile)
+	assert.NoError(t, err)
+	now := time.Date(2022, 6, 15, 10, 0, 0, 0, time.UTC)
+	v, err := New(WithTrustAnchors(anchors...), WithClock(func() time.Time { return now }))
+	assert.NoError(t, err)
+
+	got, err := v.verificationTime(time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, now, got)
+
+	got, err = v.verificationTime(now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Hour), got)
+
+	_, err = v.verificationTime(now.Add(time.Hour))
+	assert.Error(t, err)
+}
+
+func TestCheckEvaluationDataNumber(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+	anchors, err := LoadTrustAnchors(rootCAFile)
+	assert.NoError(t, err)
+
+	v, err := New(WithTrustAnchors(anchors...), WithPolicy(Policy{MinTcbEvaluationDataNumber: 10}))
+	assert.NoError(t, err)
+	assert.Error(t, v.checkEvaluationDataNumber(v.evaluation, qeIdentityEvaluationKey, 9))
+	assert.NoError(t, v.checkEvaluationDataNumber(v.evaluation, qeIdentityEvaluationKey, 12))
+	// without a tracker only the minimum is enforced
+	assert.NoError(t, v.checkEvaluationDataNumber(v.evaluation, qeIdentityEvaluationKey, 10))
+
+	tracker, err := NewEvaluationDataTracker("")
+	assert.NoError(t, err)
+	v, err = New(WithTrustAnchors(anchors...), WithPolicy(Policy{MinTcbEvaluationDataNumber: 10}),
+		WithEvaluationDataTracker(tracker))
+	assert.NoError(t, err)
+	assert.NoError(t, v.checkEvaluationDataNumber(v.evaluation, tcbInfoEvaluationKey("00906ED50000"), 12))
+	assert.Error(t, v.checkEvaluationDataNumber(v.evaluation, tcbInfoEvaluationKey("00906ed50000"), 11))
+	// TCB info is tracked per FMSPC
+	assert.NoError(t, v.checkEvaluationDataNumber(v.evaluation, tcbInfoEvaluationKey("00606a000000"), 11))
+	assert.Error(t, v.checkEvaluationDataNumber(v.evaluation, tcbInfoEvaluationKey("00606a000000"), 9))
+
+	// collateral which is not tracked, supplied by the caller or verified at a past time, is only held to the
+	// minimum and does not raise the tracked number
+	assert.NoError(t, v.checkEvaluationDataNumber(nil, tcbInfoEvaluationKey("00906ed50000"), 11))
+	assert.NoError(t, v.checkEvaluationDataNumber(nil, tcbInfoEvaluationKey("00606a000000"), 20))
+	assert.NoError(t, v.checkEvaluationDataNumber(v.evaluation, tcbInfoEvaluationKey("00606a000000"), 11))
+	assert.Error(t, v.checkEvaluationDataNumber(nil, qeIdentityEvaluationKey, 9))
+}
+
+func TestCheckVerifyOptions(t *testing.T) {
+	userData := []byte("enclave public key")
+
+	assert.NoError(t, checkVerifyOptions(VerifyOptions{}))
+	assert.NoError(t, checkVerifyOptions(VerifyOptions{ReportDataBinding: constants.ReportDataBindingSHA384,
+		UserData: userData}))
+	assert.Error(t, checkVerifyOptions(VerifyOptions{ReportDataBinding: "crc32"}))
+	assert.Error(t, checkVerifyOptions(VerifyOptions{StrictReportData: true}))
+	assert.Error(t, checkVerifyOptions(VerifyOptions{ReportDataBinding: constants.ReportDataBindingNoncePubKey,
+		UserData: userData}))
+}
+
+func TestVerifyErrors(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+	anchors, err := LoadTrustAnchors(rootCAFile)
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	v, err := New(WithTrustAnchors(anchors...))
+	assert.NoError(t, err)
+	_, err = v.Verify(ctx, []byte("quote"), VerifyOptions{})
+	assertErrorKind(t, ErrCollateralUnavailable, err)
+
+	v, err = New(WithTrustAnchors(anchors...), WithCollateralProvider(NewSCSCollateralProvider(mocks.NewClientMock(http.StatusOK), "")))
+	assert.NoError(t, err)
+	_, err = v.Verify(ctx, []byte("quote"), VerifyOptions{})
+	assertErrorKind(t, ErrInvalidQuote, err)
+
+	_, err = v.Verify(ctx, nil, VerifyOptions{VerificationTime: time.Now().Add(time.Hour)})
+	assertErrorKind(t, ErrInvalidRequest, err)
+
+	_, err = v.Verify(ctx, nil, VerifyOptions{ReportDataBinding: "crc32"})
+	assertErrorKind(t, ErrInvalidRequest, err)
+
+	_, err = v.Verify(ctx, nil, VerifyOptions{Collateral: &models.Collateral{}})
+	assertErrorKind(t, ErrInvalidRequest, err)
+
+	_, err = v.Verify(ctx, make([]byte, constants.MinQuoteSize), VerifyOptions{})
+	assertErrorKind(t, ErrInvalidQuote, err)
+}
+
+type ctxKey struct{}
+
+// ctxCheckingClient fails requests which are not bound to the context of the verification
+type ctxCheckingClient struct {
+	t        *testing.T
+	cancel   context.CancelFunc
+	requests int
+}
+
+func (c *ctxCheckingClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+	assert.Equal(c.t, "verification", req.Context().Value(ctxKey{}))
+	c.cancel()
+	return nil, req.Context().Err()
+}
+
+func TestVerifyContext(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+	anchors, err := LoadTrustAnchors(rootCAFile)
+	assert.NoError(t, err)
+	quote, err := base64.StdEncoding.DecodeString(testQuote)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "verification"))
+	client := &ctxCheckingClient{t: t, cancel: cancel}
+	v, err := New(WithTrustAnchors(anchors...), WithCollateralProvider(NewSCSCollateralProvider(client,
+		"https://scs/scs/sgx/certification/v1")))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(ctx, quote, VerifyOptions{})
+	assertErrorKind(t, ErrCanceled, err)
+	assert.Equal(t, 1, client.requests)
+
+	// verification does not start once the context is done
+	_, err = v.Verify(ctx, quote, VerifyOptions{})
+	assertErrorKind(t, ErrCanceled, err)
+	assert.Equal(t, 1, client.requests)
+
+	deadlineCtx, deadlineCancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer deadlineCancel()
+	_, err = v.Verify(deadlineCtx, quote, VerifyOptions{})
+	assertErrorKind(t, ErrCanceled, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func assertErrorKind(t *testing.T, kind ErrorKind, err error) {
+	var verifyErr *Error
+	if assert.True(t, errors.As(err, &verifyErr), "expected *Error, got %v", err) {
+		assert.Equal(t, kind, verifyErr.Kind, verifyErr.Error())
+	}
+}
+
+// testQuote is a base64 encoded SGX ECDSA quote with a PCK certificate chain
+const testQuote = "AwACAAAAAAAFAAoAk5pyM/ecTKmUCg2zlX8GB1ePHvTyaJq7KWtZvEB5i5QAAAAAAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABwAAAAAAAADnAAAAAAAAAK1GdJ7UHrqiMnJSBB7nRtN5Gp8kMYMP7giD95k8rzFqAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACD1xnnferKFHD2uvYqTXdDA8iZ22kCD5xw7h38CMfOngAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAU850rHdoyZhtjHHze/xDF6e/hNwogmoRd40iZZB/v+AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA1BAAAGp1IMlI7P+lVMltAJ3xTyeLmrqsZgK/0WBajiIPqCrhxAagIIu0l+QPoAuYmEmHm4oBrgjHhUspUmzqguHHofFM5sfwb/QU4hRFUhtwVAno0GAfyGz8nHVy64xAtRNnv7Vvk/GjislKD73UamghpdNaH5pz0/u5JhOp37YoDNVfAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAFQAAAAAAAADnAAAAAAAAAGDYWvKL6NHECgjZiwCdX4rME4Sjhc9GCADkeHkdGpecAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACMT1d115ZQPpYTf3fGioKaAFasje1wFAsIGwlEkMV7/wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEABQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAADNWDh6dvJehw5sQSZBtNlOVBGafQaMeOQkvnxUAIAuYgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAhguSX/JsCRh+Rjbg+dTLhT3/rzHPoMboaUH2fSWNyk7h+hUPh2QloKd8slEi8ZPnXYzzhcYXqTUXwlGHkr3nkiAAAAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8FAGwOAAAtLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJRTlEQ0NCSnFnQXdJQkFnSVVkK3p1Yi94WlhaSVZtd0d6MXFDUzBVcG9sNlF3Q2dZSUtvWkl6ajBFQXdJd2NERWlNQ0FHQTFVRQpBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2dRMjl5Y0c5eVlYUnBiMjR4CkZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTE1Ba0dBMVVFQmhNQ1ZWTXdIaGNOTWpFd016QTUKTURZek5USTJXaGNOTWpnd016QTVNRFl6TlRJMldqQndNU0l3SUFZRFZRUUREQmxKYm5SbGJDQlRSMWdnVUVOTElFTmxjblJwWm1sagpZWFJsTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JEYjNKd2IzSmhkR2x2YmpFVU1CSUdBMVVFQnd3TFUyRnVkR0VnUTJ4aGNtRXhDekFKCkJnTlZCQWdNQWtOQk1Rc3dDUVlEVlFRR0V3SlZVekJaTUJNR0J5cUdTTTQ5QWdFR0NDcUdTTTQ5QXdFSEEwSUFCTXhuYWJ0c0VxRlUKblNvVE50Y0kraG1xQlA3eXcvR2FldlllS3UzTVNsc21ZQVloc0RuNWNTczRObFNabkJWQ1F4NU9XaWpHNTUrZUd3QTJzWHRCZ2VhagpnZ01RTUlJREREQWZCZ05WSFNNRUdEQVdnQlJaSTlPblNxaGpWQzQ1Y0szZ0R3Y3JWeVFxdHpCdkJnTlZIUjhFYURCbU1HU2dZcUJnCmhsNW9kSFJ3Y3pvdkwzTmllQzVoY0drdWRISjFjM1JsWkhObGNuWnBZMlZ6TG1sdWRHVnNMbU52YlM5elozZ3ZZMlZ5ZEdsbWFXTmgKZEdsdmJpOTJNeTl3WTJ0amNtdy9ZMkU5Y0d4aGRHWnZjbTBtWlc1amIyUnBibWM5WkdWeU1CMEdBMVVkRGdRV0JCU2lMS2JLVHFNSgpvSHd2K01iRjQ2NmNsUGNQWXpBT0JnTlZIUThCQWY4RUJBTUNCc0F3REFZRFZSMFRBUUgvQkFJd0FEQ0NBamtHQ1NxR1NJYjRUUUVOCkFRU0NBaW93Z2dJbU1CNEdDaXFHU0liNFRRRU5BUUVFRUNDdm84ait5MGZBb2pFZVRMeExiZGd3Z2dGakJnb3Foa2lHK0UwQkRRRUMKTUlJQlV6QVFCZ3NxaGtpRytFMEJEUUVDQVFJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQWdJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQXdJQgpBREFRQmdzcWhraUcrRTBCRFFFQ0JBSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JnSUJBREFRCkJnc3Foa2lHK0UwQkRRRUNCd0lCQURBUUJnc3Foa2lHK0UwQkRRRUNDQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNDUUlCQURBUUJnc3EKaGtpRytFMEJEUUVDQ2dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDQ3dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDREFJQkFEQVFCZ3NxaGtpRworRTBCRFFFQ0RRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0RnSUJBREFRQmdzcWhraUcrRTBCRFFFQ0R3SUJBREFRQmdzcWhraUcrRTBCCkRRRUNFQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNFUUlCQ2pBZkJnc3Foa2lHK0UwQkRRRUNFZ1FRQWdJQUFBQUFBQUFBQUFBQUFBQUEKQURBUUJnb3Foa2lHK0UwQkRRRURCQUlBQURBVUJnb3Foa2lHK0UwQkRRRUVCQVlRWUdvQUFBQXdEd1lLS29aSWh2aE5BUTBCQlFvQgpBVEFlQmdvcWhraUcrRTBCRFFFR0JCQWFnNUxzb1dnaS9QRFJNT3JwNVhzaE1FUUdDaXFHU0liNFRRRU5BUWN3TmpBUUJnc3Foa2lHCitFMEJEUUVIQVFFQi96QVFCZ3NxaGtpRytFMEJEUUVIQWdFQkFEQVFCZ3NxaGtpRytFMEJEUUVIQXdFQi96QUtCZ2dxaGtqT1BRUUQKQWdOSUFEQkZBaUVBcTVzK2hhWHlaRisxVE5CUVVhRExNaTBlN204V2JOTGhRNm54MHphY3NvUUNJQS9aRjIxVk9EMTdCdHcwcHBHTwp3REF5VC9LOEJiMTZ3SjhDTU1FWVljcUEKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLS0tLS0tQkVHSU4gQ0VSVElGSUNBVEUtLS0tLQpNSUlDbWpDQ0FrQ2dBd0lCQWdJVVdTUFRwMHFvWTFRdU9YQ3Q0QThISzFja0tyY3dDZ1lJS29aSXpqMEVBd0l3CmFERWFNQmdHQTFVRUF3d1JTVzUwWld3Z1UwZFlJRkp2YjNRZ1EwRXhHakFZQmdOVkJBb01FVWx1ZEdWc0lFTnYKY25CdmNtRjBhVzl1TVJRd0VnWURWUVFIREF0VFlXNTBZU0JEYkdGeVlURUxNQWtHQTFVRUNBd0NRMEV4Q3pBSgpCZ05WQkFZVEFsVlRNQjRYRFRFNU1UQXpNVEV5TXpNME4xb1hEVE0wTVRBek1URXlNek0wTjFvd2NERWlNQ0FHCkExVUVBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2cKUTI5eWNHOXlZWFJwYjI0eEZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTApNQWtHQTFVRUJoTUNWVk13V1RBVEJnY3Foa2pPUFFJQkJnZ3Foa2pPUFFNQkJ3TkNBQVF3cCtMYytUVUJ0ZzFICitVOEpJc01zYmpIakNrVHRYYjhqUE02cjJkaHU5eklibGhEWjdJTmZxdDNJeDhYY0ZLRDhrME5FWHJrWjY2cUoKWGExS3pMSUtvNEcvTUlHOE1COEdBMVVkSXdRWU1CYUFGT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUZZRwpBMVVkSHdSUE1FMHdTNkJKb0VlR1JXaDBkSEJ6T2k4dmMySjRMV05sY25ScFptbGpZWFJsY3k1MGNuVnpkR1ZrCmMyVnlkbWxqWlhNdWFXNTBaV3d1WTI5dEwwbHVkR1ZzVTBkWVVtOXZkRU5CTG1SbGNqQWRCZ05WSFE0RUZnUVUKV1NQVHAwcW9ZMVF1T1hDdDRBOEhLMWNrS3Jjd0RnWURWUjBQQVFIL0JBUURBZ0VHTUJJR0ExVWRFd0VCL3dRSQpNQVlCQWY4Q0FRQXdDZ1lJS29aSXpqMEVBd0lEU0FBd1JRSWhBSjFxK0ZUeitnVXVWZkJRdUNnSnNGckwyVFRTCmUxYUJaNTNPNTJUakZpZTZBaUFyaVBhUmFoVVg5T2E5a0dMbEFjaFdYS1Q2ajRSV1NSNTBCcWhyTjNVVDRBPT0KLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQotLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJQ2xEQ0NBam1nQXdJQkFnSVZBT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUFvR0NDcUdTTTQ5QkFNQwpNR2d4R2pBWUJnTlZCQU1NRVVsdWRHVnNJRk5IV0NCU2IyOTBJRU5CTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JECmIzSndiM0poZEdsdmJqRVVNQklHQTFVRUJ3d0xVMkZ1ZEdFZ1EyeGhjbUV4Q3pBSkJnTlZCQWdNQWtOQk1Rc3cKQ1FZRFZRUUdFd0pWVXpBZUZ3MHhPVEV3TXpFd09UUTVNakZhRncwME9URXlNekV5TXpVNU5UbGFNR2d4R2pBWQpCZ05WQkFNTUVVbHVkR1ZzSUZOSFdDQlNiMjkwSUVOQk1Sb3dHQVlEVlFRS0RCRkpiblJsYkNCRGIzSndiM0poCmRHbHZiakVVTUJJR0ExVUVCd3dMVTJGdWRHRWdRMnhoY21FeEN6QUpCZ05WQkFnTUFrTkJNUXN3Q1FZRFZRUUcKRXdKVlV6QlpNQk1HQnlxR1NNNDlBZ0VHQ0NxR1NNNDlBd0VIQTBJQUJFLzZELzFXSE5yV3dQbU5NSXlCS01XNQpKNkp6TXNqbzZ4UDJ2a0sxY2RaR2IxUEdSUC9DLzhFQ2dpRGtta2xtendMekxpKzAwMG03TExydEtKQTNvQzJqCmdiOHdnYnd3SHdZRFZSMGpCQmd3Rm9BVTZlaEVVbE0yWEVzWW1oSDhReGdzcGR3Z2dFZ3dWZ1lEVlIwZkJFOHcKVFRCTG9FbWdSNFpGYUhSMGNITTZMeTl6WW5ndFkyVnlkR2xtYVdOaGRHVnpMblJ5ZFhOMFpXUnpaWEoyYVdObApjeTVwYm5SbGJDNWpiMjB2U1c1MFpXeFRSMWhTYjI5MFEwRXVaR1Z5TUIwR0ExVWREZ1FXQkJUcDZFUlNVelpjClN4aWFFZnhER0N5bDNDQ0FTREFPQmdOVkhROEJBZjhFQkFNQ0FRWXdFZ1lEVlIwVEFRSC9CQWd3QmdFQi93SUIKQVRBS0JnZ3Foa2pPUFFRREFnTkpBREJHQWlFQXp3OXpkVWlVSFBNVWQwQzRteDQxamxGWmtyTTN5NWYxbGduVgpPN0Ziak9vQ0lRQ29HdFVtVDRjWHQ3Vit5U0hiSjhIb2I5QWFucHZYTkgxRVIrL2daRitvcFE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
+
+var testQEData = []byte(`{
+	"enclaveIdentity": {
+		"id": "QE",
+		"version": 2,
+		"issueDate": "2022-06-15T06:42:01Z",
+		"nextUpdate": "2022-07-15T06:42:01Z",
+		"tcbEvaluationDataNumber": 5,
+		"miscselect": "00000000",
+		"miscselectMask": "FFFFFFFF",
+		"attributes": "00000000000000000000000000000000",
+		"attributesMask": "FBFFFFFFFFFFFFFF0000000000000000",
+		"mrsigner": "8C4F5775D796503E96137F77C68A829A0056AC8DED70140B081B094490C57BFF",
+		"isvprodid": 1,
+		"tcbLevels": [{
+				"tcb": {
+					"isvsvn": 2
+				},
+				"tcbDate": "2021-05-15T00:00:00Z",
+				"tcbStatus": "UpToDate"
+			},
+			{
+				"tcb": {
+					"isvsvn": 1
+				},
+				"tcbDate": "2020-08-15T00:00:00Z",
+				"tcbStatus": "OutOfDate"
+			}
+		]
+	},
+	"signature": "2c50f0f4297781594e4d86c864ef1bd6797ab77566c9ddc417330ca7f37456f2f998a44e8230c57c2c8f51258ce5044cf0ac0af58e5c953e466f51981dc1390c"
+}
+`)
+
+func getTestQeIdentityJSON(t *testing.T) parser.QeIdentityJSON {
+
+	var qeIDJSON parser.QeIdentityJSON
+
+	err := json.Unmarshal(testQEData, &qeIDJSON)
+	assert.Nil(t, err)
+	return qeIDJSON
+}
+
+func TestVerifyQeIdentityReport(t *testing.T) {
+
+	var qeIdObj parser.QeIdentityData
+	qeIdObj.QEJson = getTestQeIdentityJSON(t)
+
+	quoteObj := mocks.NewMockSGXQuoteParser([]byte("test"))
+
+	// test with valid data
+	err := verifyQeIdentityReport(&qeIdObj, quoteObj)
+	assert.Nil(t, err)
+
+	// test with invalid data
+	invalidQEIDJSON := getTestQeIdentityJSON(t)
+	invalidQEIDJSON.EnclaveIdentity.Attributes = "11000000000000000000000000000000"
+
+	qeIdObj.QEJson = invalidQEIDJSON
+	err = verifyQeIdentityReport(&qeIdObj, quoteObj)
+	assert.NotNil(t, err)
+
+	// test with invalid data
+	invalidQEIDJSON.EnclaveIdentity.Attributes = "000000000000000000000000000000"
+	invalidQEIDJSON.EnclaveIdentity.IsvProdID = 0
+	qeIdObj.QEJson = invalidQEIDJSON
+
+	err = verifyQeIdentityReport(&qeIdObj, quoteObj)
+	assert.NotNil(t, err)
+
+	// test with invalid data
+	invalidQEIDJSON.EnclaveIdentity.Attributes = "000000000000000000000000000000"
+	invalidQEIDJSON.EnclaveIdentity.IsvProdID = 1
+	invalidQEIDJSON.EnclaveIdentity.TcbLevels[0].Tcb.IsvSvn = 0
+	invalidQEIDJSON.EnclaveIdentity.TcbLevels[1].Tcb.IsvSvn = 0
+
+	qeIdObj.QEJson = invalidQEIDJSON
+
+	err = verifyQeIdentityReport(&qeIdObj, quoteObj)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyQeIdentity(t *testing.T) {
+	rootCAFile, intermediateCAFile := createTestCAs(t)
+
+	var qeIdObj parser.QeIdentityData
+	qeIdObj.QEJson = getTestQeIdentityJSON(t)
+	QuoteBlob := testQuote
+
+	quoteObj := mocks.NewMockSGXQuoteParser([]byte(QuoteBlob))
+
+	rootCert := utils.ReadCertFromFile(t, rootCAFile)
+	intermediateCert := utils.ReadCertFromFile(t, intermediateCAFile)
+
+	qeData := &parser.QeIdentityData{
+		QEJson:         getTestQeIdentityJSON(t),
+		RootCA:         []*x509.Certificate{rootCert},
+		IntermediateCA: []*x509.Certificate{intermediateCert},
+	}
+
+	x509Cert := utils.ReadCertFromFile(t, rootCAFile)
+
+	// valid test should pass. Add cert details in mock functions.
+	err := verifyQeIdentity(qeData, quoteObj, x509Cert, time.Now(), 0)
+	assert.NotNil(t, err)
+
+	// valid test should pass. Add cert details in mock functions.
+	err = verifyQeIdentity(&qeIdObj, quoteObj, x509Cert, time.Now(), 0)
+	assert.NotNil(t, err)
+
+	// invalid test should fail.
+	err = verifyQeIdentity(nil, nil, x509Cert, time.Now(), 0)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyTcbInfo(t *testing.T) {
+	rootCAFile, _ := createTestCAs(t)
+
+	certObj := mocks.NewFakePCKCertObj()
+	tcbObj := &parser.TcbInfoStruct{}
+
+	x509Cert := utils.ReadCertFromFile(t, rootCAFile)
+
+	// invalid test should fail. Add test certificate at mocks files.
+	err := verifyTcbInfo(certObj, tcbObj, x509Cert, time.Now(), 0)
+	assert.NotNil(t, err)
+
+}
+
+func TestNewEnclaveReport(t *testing.T) {
+	var report models.ReportBody
+	report.CPUSvn[0] = 0x0a
+	report.MiscSelect = 1
+	// INIT, MODE64BIT and KSS set, XFRM 0x3
+	report.SgxAttributes = [models.AttributeSize]byte{0x85, 0, 0, 0, 0, 0, 0, 0, 0x03}
+	report.MrEnclave[31] = 0xee
+	report.SgxIsvProdID = 0x102
+	report.SgxIsvSvn = 7
+	report.ConfigSvn = 2
+	report.IsvFamilyID[0] = 0xf1
+	report.ReportData[63] = 0xff
+
+	got := newEnclaveReport(report)
+	assert.Equal(t, "0a000000000000000000000000000000", got.CPUSvn)
+	assert.Equal(t, uint32(1), got.MiscSelect)
+	assert.Equal(t, models.EnclaveAttributes{Flags: 0x85, Xfrm: 0x3, Init: true, Mode64Bit: true, Kss: true},
+		got.Attributes)
+	assert.Equal(t, "ee", got.MrEnclave[62:])
+	assert.Equal(t, uint16(0x102), got.IsvProdID)
+	assert.Equal(t, uint16(7), got.IsvSvn)
+	assert.Equal(t, uint16(2), got.ConfigSvn)
+	assert.Equal(t, "f1", got.IsvFamilyID[:2])
+	assert.Equal(t, 128, len(got.ConfigID))
+	assert.Equal(t, 128, len(got.ReportData))
+	assert.Equal(t, "ff", got.ReportData[126:])
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package replay
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// compactSlack is the number of records beyond twice the keys remembered at which the file is compacted, it
+// keeps small caches from being compacted on most additions
+const compactSlack = 1024
+
+// FileBackend remembers keys in memory like a MemoryBackend and appends them to a file, from which they are
+// loaded again on start so that replays are detected across restarts of a single node. The file is compacted
+// when it holds about twice as many records as keys remembered.
+type FileBackend struct {
+	memory *MemoryBackend
+	path   string
+
+	mu      sync.Mutex
+	file    *os.File
+	records int
+}
+
+// NewFileBackend returns a backend remembering up to maxEntries keys in the file at path. The keys in the
+// file which have not expired at now are loaded.
+func NewFileBackend(path string, maxEntries int, now time.Time) (*FileBackend, error) {
+	memory, err := NewMemoryBackend(maxEntries)
+	if err != nil {
+		return nil, err
+	}
+	b := &FileBackend{
+		memory: memory,
+		path:   path,
+	}
+	if err := b.load(now); err != nil {
+		return nil, err
+	}
+	if err := b.compact(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load remembers the keys of the file which have not expired, malformed records are skipped
+func (b *FileBackend) load(now time.Time) error {
+	file, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "replay:NewFileBackend() Could not open replay cache file")
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		expiryNanos, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		expiry := time.Unix(0, expiryNanos)
+		if !expiry.After(now) {
+			continue
+		}
+		if _, ok := b.memory.entries[fields[1]]; !ok {
+			b.memory.insert(fields[1], expiry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "replay:NewFileBackend() Could not read replay cache file")
+	}
+	return nil
+}
+
+// compact rewrites the file with the keys remembered and reopens it for appending. The file is kept open for
+// appending when it can not be compacted.
+func (b *FileBackend) compact() error {
+	b.memory.mu.Lock()
+	entries := b.memory.snapshot()
+	b.memory.mu.Unlock()
+
+	// the file is replaced atomically so that a crash does not lose the keys remembered
+	tmp, err := ioutil.TempFile(filepath.Dir(b.path), ".replay-")
+	if err != nil {
+		return errors.Wrap(err, "Could not create replay cache file")
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	writer := bufio.NewWriter(tmp)
+	for _, entry := range entries {
+		if _, err = writer.WriteString(record(entry.key, entry.expiry)); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "Could not write replay cache file")
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return errors.Wrap(err, "Could not write replay cache file")
+	}
+
+	file, err := os.OpenFile(b.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.Wrap(err, "Could not open replay cache file")
+	}
+	if b.file != nil {
+		if err := b.file.Close(); err != nil {
+			log.WithError(err).Warn("replay:compact() Could not close replay cache file")
+		}
+	}
+	b.file = file
+	b.records = len(entries)
+	return nil
+}
+
+func record(key string, expiry time.Time) string {
+	return fmt.Sprintf("%d %s\n", expiry.UnixNano(), key)
+}
+
+func (b *FileBackend) Add(key string, now, expiry time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file == nil {
+		return false, errors.New("Replay cache file is closed")
+	}
+
+	seen, err := b.memory.Add(key, now, expiry)
+	if err != nil || seen {
+		return seen, err
+	}
+	// the key is remembered in memory until the next restart when it can not be persisted
+	if _, err := b.file.WriteString(record(key, expiry)); err != nil {
+		log.WithError(err).Error("replay:Add() Could not append to replay cache file")
+		return false, nil
+	}
+	b.records++
+	if b.records > 2*b.memory.Len()+compactSlack {
+		if err := b.compact(); err != nil {
+			log.WithError(err).Error("replay:Add() Could not compact replay cache file")
+		}
+	}
+	return false, nil
+}
+
+func (b *FileBackend) Len() int {
+	return b.memory.Len()
+}
+
+func (b *FileBackend) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file == nil {
+		return nil
+	}
+	err := b.file.Close()
+	b.file = nil
+	return errors.Wrap(err, "Could not close replay cache file")
+}
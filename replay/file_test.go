@@ -0,0 +1,77 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package replay
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay-cache")
+	now := time.Now()
+	b, err := NewFileBackend(path, 10, now)
+	assert.NoError(t, err)
+
+	_, _ = b.Add("a", now, now.Add(time.Minute))
+	_, _ = b.Add("b", now, now.Add(time.Hour))
+	seen, err := b.Add("a", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, seen)
+	assert.NoError(t, b.Close())
+	_, err = b.Add("c", now, now.Add(time.Hour))
+	assert.Error(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// the keys which have not expired survive restarts, malformed records are skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	assert.NoError(t, err)
+	_, err = f.WriteString("garbage\nnot-a-number key\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	now = now.Add(2 * time.Minute)
+	b, err = NewFileBackend(path, 10, now)
+	assert.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 1, b.Len())
+	seen, _ = b.Add("b", now, now.Add(time.Hour))
+	assert.True(t, seen)
+	seen, _ = b.Add("a", now, now.Add(time.Hour))
+	assert.False(t, seen)
+
+	// the file is compacted on start
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+func TestFileBackendCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay-cache")
+	now := time.Now()
+	b, err := NewFileBackend(path, 10, now)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	for i := 0; i < 2*compactSlack; i++ {
+		_, err := b.Add(fmt.Sprint(i), now, now.Add(time.Hour))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 10, b.Len())
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Less(t, strings.Count(string(data), "\n"), 2*10+compactSlack+1)
+	seen, _ := b.Add(fmt.Sprint(2*compactSlack-1), now, now.Add(time.Hour))
+	assert.True(t, seen)
+}
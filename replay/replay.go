@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+
+// Package replay detects quotes which are submitted for verification again. Verified quotes are remembered,
+// by the hash of the quote or of its report data, for a time to live in a size bounded backend.
+package replay
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	clog "intel/isecl/lib/common/v5/log"
+	"intel/isecl/sqvs/v5/constants"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var log = clog.GetDefaultLogger()
+
+// Backend remembers the keys of verified quotes. A Backend is safe for concurrent use.
+type Backend interface {
+	// Add remembers key until expiry and reports whether key is remembered already
+	Add(key string, now, expiry time.Time) (bool, error)
+	// Len returns the number of keys remembered
+	Len() int
+	Close() error
+}
+
+// memoryEntry is a key remembered by a MemoryBackend
+type memoryEntry struct {
+	key    string
+	expiry time.Time
+}
+
+// MemoryBackend remembers keys in memory. Once maxEntries keys are remembered the oldest key is forgotten
+// before its expiry, a replay of its quote then goes undetected.
+type MemoryBackend struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries oldest first, which with a fixed time to live is also the order of expiry
+	order *list.List
+}
+
+// NewMemoryBackend returns a backend remembering up to maxEntries keys
+func NewMemoryBackend(maxEntries int) (*MemoryBackend, error) {
+	if maxEntries <= 0 {
+		return nil, errors.New("replay:NewMemoryBackend() Maximum number of entries must be positive")
+	}
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}, nil
+}
+
+func (b *MemoryBackend) Add(key string, now, expiry time.Time) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire(now)
+	if _, ok := b.entries[key]; ok {
+		return true, nil
+	}
+	b.insert(key, expiry)
+	return false, nil
+}
+
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+func (b *MemoryBackend) Close() error {
+	return nil
+}
+
+// insert remembers key, forgetting the oldest keys beyond maxEntries
+func (b *MemoryBackend) insert(key string, expiry time.Time) {
+	b.entries[key] = b.order.PushBack(&memoryEntry{key: key, expiry: expiry})
+	for b.order.Len() > b.maxEntries {
+		b.remove(b.order.Front())
+	}
+}
+
+// expire forgets the keys which expired at now
+func (b *MemoryBackend) expire(now time.Time) {
+	for front := b.order.Front(); front != nil; front = b.order.Front() {
+		if front.Value.(*memoryEntry).expiry.After(now) {
+			return
+		}
+		b.remove(front)
+	}
+}
+
+func (b *MemoryBackend) remove(element *list.Element) {
+	delete(b.entries, element.Value.(*memoryEntry).key)
+	b.order.Remove(element)
+}
+
+// snapshot returns the entries oldest first
+func (b *MemoryBackend) snapshot() []memoryEntry {
+	entries := make([]memoryEntry, 0, b.order.Len())
+	for element := b.order.Front(); element != nil; element = element.Next() {
+		entries = append(entries, *element.Value.(*memoryEntry))
+	}
+	return entries
+}
+
+// Cache detects replayed quotes with the keys remembered by a backend
+type Cache struct {
+	backend Backend
+	keyBy   string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewCache returns a cache which identifies quotes as selected by keyBy, constants.ReplayKeyQuote or
+// constants.ReplayKeyReportData, and remembers them for ttl
+func NewCache(backend Backend, keyBy string, ttl time.Duration) (*Cache, error) {
+	if backend == nil {
+		return nil, errors.New("replay:NewCache() Backend is nil")
+	}
+	if keyBy != constants.ReplayKeyQuote && keyBy != constants.ReplayKeyReportData {
+		return nil, errors.Errorf("replay:NewCache() Invalid replay key %s", keyBy)
+	}
+	if ttl <= 0 {
+		return nil, errors.New("replay:NewCache() Time to live must be positive")
+	}
+	return &Cache{
+		backend: backend,
+		keyBy:   keyBy,
+		ttl:     ttl,
+		now:     time.Now,
+	}, nil
+}
+
+// Check remembers a verified quote and reports whether it has been seen within the time to live
+func (c *Cache) Check(quote, reportData []byte) (bool, error) {
+	identity := quote
+	if c.keyBy == constants.ReplayKeyReportData {
+		identity = reportData
+	}
+	if len(identity) == 0 {
+		return false, errors.Errorf("No %s to check for replay", c.keyBy)
+	}
+	sum := sha256.Sum256(identity)
+	// the keys are prefixed so that switching keyBy does not match keys remembered before
+	key := c.keyBy + ":" + hex.EncodeToString(sum[:])
+	now := c.now()
+	return c.backend.Add(key, now, now.Add(c.ttl))
+}
+
+// Len returns the number of quotes remembered
+func (c *Cache) Len() int {
+	return c.backend.Len()
+}
+
+// Health reports the number of quotes remembered, for the health endpoint
+func (c *Cache) Health() (interface{}, error) {
+	return map[string]int{"entries": c.Len()}, nil
+}
+
+func (c *Cache) Close() error {
+	return c.backend.Close()
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultCache *Cache
+)
+
+// SetDefault sets the service wide replay cache, nil disables replay detection
+func SetDefault(c *Cache) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCache = c
+}
+
+// Default returns the service wide replay cache, nil when replay detection is disabled
+func Default() *Cache {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultCache
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package replay
+
+import (
+	"intel/isecl/sqvs/v5/constants"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	_, err := NewMemoryBackend(0)
+	assert.Error(t, err)
+
+	b, err := NewMemoryBackend(2)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	seen, err := b.Add("a", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, seen)
+	seen, err = b.Add("a", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, seen)
+
+	// the oldest key is forgotten beyond the maximum number of entries
+	_, _ = b.Add("b", now, now.Add(time.Minute))
+	_, _ = b.Add("c", now, now.Add(time.Minute))
+	assert.Equal(t, 2, b.Len())
+	seen, _ = b.Add("a", now, now.Add(time.Minute))
+	assert.False(t, seen)
+
+	// expired keys are forgotten
+	now = now.Add(time.Minute)
+	seen, _ = b.Add("c", now, now.Add(time.Minute))
+	assert.False(t, seen)
+	assert.Equal(t, 1, b.Len())
+	assert.NoError(t, b.Close())
+}
+
+func TestNewCache(t *testing.T) {
+	b, err := NewMemoryBackend(10)
+	assert.NoError(t, err)
+
+	_, err = NewCache(nil, constants.ReplayKeyQuote, time.Hour)
+	assert.Error(t, err)
+	_, err = NewCache(b, "mrEnclave", time.Hour)
+	assert.Error(t, err)
+	_, err = NewCache(b, constants.ReplayKeyQuote, 0)
+	assert.Error(t, err)
+}
+
+func TestCacheCheck(t *testing.T) {
+	b, err := NewMemoryBackend(10)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	byQuote, err := NewCache(b, constants.ReplayKeyQuote, time.Hour)
+	assert.NoError(t, err)
+	byQuote.now = func() time.Time { return now }
+	replayed, err := byQuote.Check([]byte("quote"), []byte("report data"))
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	replayed, err = byQuote.Check([]byte("quote"), []byte("other report data"))
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	replayed, _ = byQuote.Check([]byte("other quote"), []byte("report data"))
+	assert.False(t, replayed)
+
+	// quotes with the same report data are replays when keyed by report data
+	byReportData, err := NewCache(b, constants.ReplayKeyReportData, time.Hour)
+	assert.NoError(t, err)
+	byReportData.now = byQuote.now
+	replayed, _ = byReportData.Check([]byte("quote"), []byte("report data"))
+	assert.False(t, replayed)
+	replayed, _ = byReportData.Check([]byte("third quote"), []byte("report data"))
+	assert.True(t, replayed)
+	_, err = byReportData.Check([]byte("quote"), nil)
+	assert.Error(t, err)
+
+	// quotes are forgotten after the time to live
+	now = now.Add(time.Hour)
+	replayed, _ = byQuote.Check([]byte("quote"), nil)
+	assert.False(t, replayed)
+
+	metrics, err := byQuote.Health()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"entries": 1}, metrics)
+}
+
+func TestDefault(t *testing.T) {
+	assert.Nil(t, Default())
+	b, err := NewMemoryBackend(10)
+	assert.NoError(t, err)
+	c, err := NewCache(b, constants.ReplayKeyQuote, time.Hour)
+	assert.NoError(t, err)
+	SetDefault(c)
+	assert.Equal(t, c, Default())
+	SetDefault(nil)
+	assert.Nil(t, Default())
+}
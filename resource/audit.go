@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	commContext "intel/isecl/lib/common/v5/context"
+	"intel/isecl/sqvs/v5/audit"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRejectionMessage bounds the part of a rejection response recorded as the audit message
+const maxRejectionMessage = 256
+
+type auditRequestKey struct{}
+
+// auditRequest is shared through the request context by AuditRejections, the error paths of the handlers
+// and recordVerification, so that each verification request is audited exactly once
+type auditRequest struct {
+	recorded bool
+	// rejected is the request as seen by the handler or middleware which rejected it, carrying the caller
+	// and tenant once authenticated
+	rejected *http.Request
+	err      error
+}
+
+func getAuditRequest(r *http.Request) *auditRequest {
+	if r == nil {
+		return nil
+	}
+	ar, _ := r.Context().Value(auditRequestKey{}).(*auditRequest)
+	return ar
+}
+
+// noteRejection remembers the error a verification request is rejected with for AuditRejections
+func noteRejection(r *http.Request, err error) {
+	if ar := getAuditRequest(r); ar != nil && !ar.recorded {
+		ar.rejected, ar.err = r, err
+	}
+}
+
+// auditResponseWriter captures the status and the start of the body of a response
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if remaining := maxRejectionMessage - w.body.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// AuditRejections returns a middleware recording the verification requests to the given endpoint which are
+// rejected before the verification decision is recorded, by authentication, authorization, tenant
+// restrictions, throttling or request validation. It must be installed ahead of all other middlewares.
+func AuditRejections(endpoint string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if audit.Default() == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ar := &auditRequest{}
+			rw := &auditResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), auditRequestKey{}, ar)))
+			if ar.recorded || (ar.err == nil && rw.status < http.StatusBadRequest) {
+				return
+			}
+			message := ar.err
+			if message == nil {
+				message = &resourceError{Message: strings.TrimSpace(rw.body.String()), StatusCode: rw.status}
+			}
+			rejected := ar.rejected
+			if rejected == nil {
+				rejected = r
+			}
+			recordRejection(rejected, endpoint, message)
+		})
+	}
+}
+
+// recordRejection appends a verification request rejected with err to the audit log
+func recordRejection(r *http.Request, endpoint string, err error) {
+	rec := audit.Record{
+		Caller:   auditCaller(r),
+		Endpoint: endpoint,
+		Result:   "REJECTED",
+		Message:  err.Error(),
+	}
+	if tenant := GetTenantContext(r); tenant != nil {
+		rec.Tenant = tenant.ID
+	}
+	if err := audit.Default().Log(rec); err != nil {
+		slog.WithError(err).Errorf("resource/audit:recordRejection() Failed to write audit record for rejected request from %s",
+			rec.Caller)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditCaller identifies the caller by token or client certificate subject, falling back to the remote address
+func auditCaller(r *http.Request) string {
+	if subject, err := commContext.GetTokenSubject(r); err == nil && subject != "" {
+		return subject
+	}
+	return r.RemoteAddr
+}
+
+// recordVerification appends the verification decision to the audit log. verifyErr is the error returned by
+// the quote verifier and responseBytes the response body sent to the caller, if any.
+func recordVerification(r *http.Request, endpoint, quoteBlob string, resp models.SGXResponse, verifyErr error,
+	responseBytes []byte) {
+	if ar := getAuditRequest(r); ar != nil {
+		ar.recorded = true
+	}
+	logger := audit.Default()
+	if logger == nil {
+		return
+	}
+
+	rec := audit.Record{
+		Caller:           auditCaller(r),
+		Endpoint:         endpoint,
+		MrEnclave:        resp.EnclaveMeasurement,
+		MrSigner:         resp.EnclaveIssuer,
+		Fmspc:            resp.Fmspc,
+		TcbStatus:        resp.TcbLevel,
+		CollateralSource: resp.CollateralSource,
+		ReplayStatus:     resp.ReplayStatus,
+		VerificationTime: resp.VerificationTime,
+		Result:           resp.Message,
+	}
+	if resp.Platform != nil {
+		rec.Ppid = resp.Platform.PPID
+		rec.PlatformInstance = resp.Platform.PlatformInstanceID
+	}
+	if tenant := GetTenantContext(r); tenant != nil {
+		rec.Tenant = tenant.ID
+	}
+
+	quote, err := base64.StdEncoding.DecodeString(quoteBlob)
+	if err != nil {
+		quote = []byte(quoteBlob)
+	}
+	rec.QuoteHash = sha256Hex(quote)
+
+	if verifyErr != nil {
+		rec.Result = "FAILED"
+		rec.Message = verifyErr.Error()
+		// verification stopped before the response was populated, take the enclave identity from the quote
+		if blob := parser.ParseQuoteBlob(quoteBlob); blob != nil {
+			if quoteObj := parser.NewSGXQuoteParser(blob.GetQuoteBlob()); quoteObj != nil {
+				rec.MrEnclave = fmt.Sprintf("%02x", quoteObj.GetEnclaveReportMrEnclave())
+				rec.MrSigner = fmt.Sprintf("%02x", quoteObj.GetEnclaveMrSigner())
+			}
+		}
+	}
+
+	if resp.Collateral != nil {
+		rec.Collateral = &audit.Collateral{
+			TcbInfoVersion:          resp.Collateral.TcbInfoVersion,
+			TcbInfoIssueDate:        resp.Collateral.TcbInfoIssueDate,
+			TcbEvaluationDataNumber: resp.Collateral.TcbEvaluationDataNumber,
+			QeIdentityVersion:       resp.Collateral.QeIdentityVersion,
+			QeIdentityIssueDate:     resp.Collateral.QeIdentityIssueDate,
+			QeIdentityEvalDataNum:   resp.Collateral.QeIdentityEvaluationNumber,
+			Expiry:                  resp.CollateralExpiry,
+			Expired:                 resp.CollateralExpired,
+		}
+	}
+	if len(responseBytes) > 0 {
+		rec.ResponseHash = sha256Hex(responseBytes)
+	}
+
+	if err = logger.Log(rec); err != nil {
+		slog.WithError(err).Errorf("resource/audit:recordVerification() Failed to write audit record for quote %s",
+			rec.QuoteHash)
+	}
+}
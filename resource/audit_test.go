@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bufio"
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/sqvs/v5/audit"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("Audit", func() {
+	auditKey := []byte("0123456789abcdef0123456789abcdef")
+	var auditDir string
+	var logger *audit.Logger
+
+	readRecords := func() []audit.Record {
+		f, err := os.Open(filepath.Join(auditDir, audit.CurrentFile))
+		Expect(err).NotTo(HaveOccurred())
+		defer f.Close()
+		var records []audit.Record
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec audit.Record
+			Expect(json.Unmarshal(scanner.Bytes(), &rec)).To(Succeed())
+			records = append(records, rec)
+		}
+		return records
+	}
+
+	BeforeEach(func() {
+		var err error
+		auditDir, err = ioutil.TempDir("", "sqvs-audit")
+		Expect(err).NotTo(HaveOccurred())
+		logger, err = audit.NewLogger(auditDir, auditKey, 0, 0)
+		Expect(err).NotTo(HaveOccurred())
+		audit.SetDefault(logger)
+	})
+
+	AfterEach(func() {
+		audit.SetDefault(nil)
+		Expect(logger.Close()).To(Succeed())
+		os.RemoveAll(auditDir)
+	})
+
+	It("Should record successful and failed verifications", func() {
+		req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.RemoteAddr = "10.0.0.1:1234"
+		req = context.SetTokenSubject(req, "client-a")
+
+		resp := models.SGXResponse{}
+		resp.Message = "SGX_QL_QV_RESULT_OK"
+		resp.EnclaveMeasurement = "aa"
+		resp.TcbLevel = "UpToDate"
+		resp.Fmspc = "00906ED50000"
+		resp.Collateral = &models.CollateralInfo{TcbInfoVersion: 2, TcbEvaluationDataNumber: 12}
+		recordVerification(req, constants.EndpointV2, "cXVvdGU=", resp, nil, []byte("response"))
+
+		req, err = http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.RemoteAddr = "10.0.0.2:1234"
+		recordVerification(req, constants.EndpointV1, "cXVvdGU=", models.SGXResponse{}, errors.New("Cannot verify pck cert"), nil)
+
+		records := readRecords()
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].Caller).To(Equal("client-a"))
+		Expect(records[0].Result).To(Equal("SGX_QL_QV_RESULT_OK"))
+		Expect(records[0].Fmspc).To(Equal("00906ED50000"))
+		Expect(records[0].Collateral.TcbEvaluationDataNumber).To(Equal(uint(12)))
+		Expect(records[0].QuoteHash).To(Equal(sha256Hex([]byte("quote"))))
+		Expect(records[0].ResponseHash).To(Equal(sha256Hex([]byte("response"))))
+
+		Expect(records[1].Caller).To(Equal("10.0.0.2:1234"))
+		Expect(records[1].Result).To(Equal("FAILED"))
+		Expect(records[1].Message).To(Equal("Cannot verify pck cert"))
+		Expect(records[1].PrevHash).To(Equal(records[0].Hash))
+
+		_, err = audit.Verify(auditDir, auditKey)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("AuditRejections", func() {
+		newRequest := func() *http.Request {
+			req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+			Expect(err).NotTo(HaveOccurred())
+			req.RemoteAddr = "10.0.0.1:1234"
+			return req
+		}
+
+		serve := func(handler http.Handler) int {
+			w := httptest.NewRecorder()
+			AuditRejections(constants.EndpointV2)(handler).ServeHTTP(w, newRequest())
+			return w.Code
+		}
+
+		It("Should record requests rejected by a middleware writing the response", func() {
+			code := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			}))
+			Expect(code).To(Equal(http.StatusUnauthorized))
+
+			records := readRecords()
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Caller).To(Equal("10.0.0.1:1234"))
+			Expect(records[0].Endpoint).To(Equal(constants.EndpointV2))
+			Expect(records[0].Result).To(Equal("REJECTED"))
+			Expect(records[0].Message).To(Equal("401: Invalid token"))
+		})
+
+		It("Should record the authenticated caller of requests rejected by a handler", func() {
+			handler := errorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return &privilegeError{Message: "Endpoint access unauthorized for tenant", StatusCode: http.StatusForbidden}
+			})
+			code := serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler.ServeHTTP(w, context.SetTokenSubject(r, "client-a"))
+			}))
+			Expect(code).To(Equal(http.StatusForbidden))
+
+			records := readRecords()
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Caller).To(Equal("client-a"))
+			Expect(records[0].Result).To(Equal("REJECTED"))
+			Expect(records[0].Message).To(Equal("403: Endpoint access unauthorized for tenant"))
+		})
+
+		It("Should not record verified requests twice", func() {
+			serve(errorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				recordVerification(r, constants.EndpointV2, "cXVvdGU=", models.SGXResponse{},
+					errors.New("Cannot verify pck cert"), nil)
+				return &resourceError{Message: "Cannot verify pck cert", StatusCode: http.StatusBadRequest}
+			}))
+			serve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			records := readRecords()
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].Result).To(Equal("FAILED"))
+		})
+	})
+})
@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"crypto/x509"
+	commContext "intel/isecl/lib/common/v5/context"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	ct "intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// verifiedClientCert returns the leaf of the first chain verified by the TLS stack against the client CAs
+func verifiedClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+func certHasSAN(cert *x509.Certificate, san string) bool {
+	for _, name := range cert.DNSNames {
+		if strings.EqualFold(name, san) {
+			return true
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if strings.EqualFold(email, san) {
+			return true
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		if ip.String() == san {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if uri.String() == san {
+			return true
+		}
+	}
+	return false
+}
+
+func certMatchesMapping(cert *x509.Certificate, mapping config.ClientCertRoleMapping) bool {
+	if mapping.Subject == "" && mapping.SAN == "" {
+		return false
+	}
+	if mapping.Subject != "" && mapping.Subject != cert.Subject.CommonName && mapping.Subject != cert.Subject.String() {
+		return false
+	}
+	if mapping.SAN != "" && !certHasSAN(cert, mapping.SAN) {
+		return false
+	}
+	return true
+}
+
+// clientCertRoles returns the SQVS roles granted to the certificate by the configured mappings
+func clientCertRoles(cert *x509.Certificate, mappings []config.ClientCertRoleMapping) []ct.RoleInfo {
+	var roles []ct.RoleInfo
+	for _, mapping := range mappings {
+		if !certMatchesMapping(cert, mapping) {
+			continue
+		}
+		for _, name := range mapping.Roles {
+			roles = append(roles, ct.RoleInfo{Service: constants.ServiceName, Name: name, Context: mapping.Context})
+		}
+	}
+	return roles
+}
+
+// NewClientCertAuth returns a middleware authenticating callers by their TLS client certificate. Roles are
+// derived from the certificate subject/SAN so that AuthorizeEndpoint applies unchanged. When tokenAuth is not
+// nil, requests carrying an Authorization header or no client certificate are handed over to token authentication.
+func NewClientCertAuth(mappings []config.ClientCertRoleMapping, tokenAuth mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		var tokenHandler http.Handler
+		if tokenAuth != nil {
+			tokenHandler = tokenAuth(next)
+		}
+		return errorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			log.Trace("resource/client_cert_auth:NewClientCertAuth() Entering")
+			defer log.Trace("resource/client_cert_auth:NewClientCertAuth() Leaving")
+
+			cert := verifiedClientCert(r)
+			if tokenHandler != nil && (cert == nil || r.Header.Get("Authorization") != "") {
+				tokenHandler.ServeHTTP(w, r)
+				return nil
+			}
+
+			if cert == nil {
+				slog.Warningf("resource/client_cert_auth: %s: no client certificate, requested from %s",
+					commLogMsg.AuthenticationFailed, r.RemoteAddr)
+				return &privilegeError{Message: "Client certificate required", StatusCode: http.StatusUnauthorized}
+			}
+
+			roles := clientCertRoles(cert, mappings)
+			if len(roles) == 0 {
+				slog.Warningf("resource/client_cert_auth: %s: no role mapping for client certificate %s, requested from %s",
+					commLogMsg.AuthenticationFailed, cert.Subject.String(), r.RemoteAddr)
+				return &privilegeError{Message: "Client certificate not authorized", StatusCode: http.StatusUnauthorized}
+			}
+
+			r = commContext.SetUserRoles(r, roles)
+			r = commContext.SetTokenSubject(r, cert.Subject.String())
+			slog.Infof("resource/client_cert_auth: %s - client certificate %s", commLogMsg.AuthorizedAccess, cert.Subject.String())
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
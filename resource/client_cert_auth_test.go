@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientCertAuth", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var servedRoles int
+	var tokenAuthCalled bool
+
+	mappings := []config.ClientCertRoleMapping{
+		{Subject: "sqvs-client", Roles: []string{constants.QuoteVerifierGroupName}, Context: "tenant=team-a"},
+		{SAN: "verifier.example.com", Roles: []string{constants.QuoteVerifierGroupName}},
+	}
+
+	fakeTokenAuth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenAuthCalled = true
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	newRequest := func(cert *x509.Certificate) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		if cert != nil {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		return req
+	}
+
+	setupRouter := func(tokenAuth mux.MiddlewareFunc) {
+		router = mux.NewRouter()
+		router.Use(NewClientCertAuth(mappings, tokenAuth))
+		router.HandleFunc("/sgx_qv_verify_quote", func(w http.ResponseWriter, r *http.Request) {
+			roles, _ := context.GetUserRoles(r)
+			servedRoles = len(roles)
+			w.WriteHeader(http.StatusOK)
+		})
+	}
+
+	BeforeEach(func() {
+		servedRoles = 0
+		tokenAuthCalled = false
+		w = httptest.NewRecorder()
+		setupRouter(nil)
+	})
+
+	It("Should map roles by certificate common name", func() {
+		router.ServeHTTP(w, newRequest(&x509.Certificate{Subject: pkix.Name{CommonName: "sqvs-client"}}))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(servedRoles).To(Equal(1))
+	})
+
+	It("Should map roles by certificate SAN", func() {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, DNSNames: []string{"verifier.example.com"}}
+		router.ServeHTTP(w, newRequest(cert))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(servedRoles).To(Equal(1))
+	})
+
+	It("Should reject unmapped certificates", func() {
+		router.ServeHTTP(w, newRequest(&x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}))
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("Should reject requests without client certificate", func() {
+		router.ServeHTTP(w, newRequest(nil))
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("Should hand requests without client certificate over to token auth", func() {
+		setupRouter(fakeTokenAuth)
+		router.ServeHTTP(w, newRequest(nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(tokenAuthCalled).To(BeTrue())
+	})
+
+	It("Should hand requests with bearer token over to token auth", func() {
+		setupRouter(fakeTokenAuth)
+		req := newRequest(&x509.Certificate{Subject: pkix.Name{CommonName: "sqvs-client"}})
+		req.Header.Set("Authorization", "Bearer token")
+		router.ServeHTTP(w, req)
+		Expect(tokenAuthCalled).To(BeTrue())
+	})
+})
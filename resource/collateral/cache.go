@@ -0,0 +1,461 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+
+// Package collateral caches the collateral fetched from the SGX Caching Service, refreshes it in the
+// background ahead of its nextUpdate and persists it on disk.
+package collateral
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	clog "intel/isecl/lib/common/v5/log"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/utils"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var log = clog.GetDefaultLogger()
+
+// Kind is the type of a collateral document served by SCS
+type Kind string
+
+const (
+	KindTcbInfo    Kind = "tcbInfo"
+	KindQeIdentity Kind = "qeIdentity"
+	KindPckCrl     Kind = "pckCrl"
+	KindRootCaCrl  Kind = "rootCaCrl"
+)
+
+// documentKinds maps the SCS paths, relative to the SCS base URL, to the collateral they serve
+var documentKinds = map[string]Kind{
+	"/tcb":         KindTcbInfo,
+	"/qe/identity": KindQeIdentity,
+	"/pckcrl":      KindPckCrl,
+	"/rootcacrl":   KindRootCaCrl,
+}
+
+// chainHeaders are the SCS response headers carrying the issuer chain of a collateral document
+var chainHeaders = map[Kind]string{
+	KindTcbInfo:    "SGX-TCB-Info-Issuer-Chain",
+	KindQeIdentity: "Sgx-Qe-Identity-Issuer-Chain",
+	KindPckCrl:     "SGX-PCK-CRL-Issuer-Chain",
+}
+
+// Entry is a collateral document as served by SCS. Key is the SCS path of the document relative to the SCS
+// base URL including its query, e.g. /tcb?fmspc=00906ed50000.
+type Entry struct {
+	Key  string
+	Kind Kind
+	// Body is the TCB info or QE identity JSON, or the base64 encoded DER CRL
+	Body []byte
+	// IssuerChain is the PEM encoded issuer chain, the Root CA CRL has none
+	IssuerChain string
+	FetchedAt   time.Time
+	IssueDate   time.Time
+	NextUpdate  time.Time
+	// TcbEvaluationDataNumber is set for TCB info and QE identity
+	TcbEvaluationDataNumber uint
+}
+
+// newEntry checks that the document can be parsed and its issuer chain built, and reads its validity period
+func newEntry(key string, kind Kind, body []byte, header http.Header, fetchedAt time.Time) (*Entry, error) {
+	e := &Entry{
+		Key:       key,
+		Kind:      kind,
+		Body:      body,
+		FetchedAt: fetchedAt,
+	}
+
+	if name, ok := chainHeaders[kind]; ok {
+		chain, err := url.QueryUnescape(header.Get(name))
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not decode issuer chain")
+		}
+		e.IssuerChain = chain
+	}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// parse checks the issuer chain and the document of the entry and sets its validity period
+func (e *Entry) parse() error {
+	if _, ok := chainHeaders[e.Kind]; ok {
+		if _, err := e.IssuerCerts(); err != nil {
+			return err
+		}
+	}
+
+	type validity struct {
+		IssueDate               string `json:"issueDate"`
+		NextUpdate              string `json:"nextUpdate"`
+		TcbEvaluationDataNumber uint   `json:"tcbEvaluationDataNumber"`
+	}
+	var v validity
+	switch e.Kind {
+	case KindTcbInfo:
+		var doc struct {
+			TcbInfo validity `json:"tcbInfo"`
+		}
+		if err := json.Unmarshal(e.Body, &doc); err != nil {
+			return errors.Wrap(err, "Could not parse TCB info")
+		}
+		v = doc.TcbInfo
+	case KindQeIdentity:
+		var doc struct {
+			EnclaveIdentity validity `json:"enclaveIdentity"`
+		}
+		if err := json.Unmarshal(e.Body, &doc); err != nil {
+			return errors.Wrap(err, "Could not parse QE identity")
+		}
+		v = doc.EnclaveIdentity
+	case KindPckCrl, KindRootCaCrl:
+		crl, err := e.CRL()
+		if err != nil {
+			return err
+		}
+		e.IssueDate = crl.TBSCertList.ThisUpdate
+		e.NextUpdate = crl.TBSCertList.NextUpdate
+		return nil
+	default:
+		return errors.Errorf("Unknown collateral kind %s", e.Kind)
+	}
+
+	var err error
+	e.TcbEvaluationDataNumber = v.TcbEvaluationDataNumber
+	e.IssueDate, err = time.Parse(time.RFC3339, v.IssueDate)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid issueDate of %s", e.Kind)
+	}
+	e.NextUpdate, err = time.Parse(time.RFC3339, v.NextUpdate)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid nextUpdate of %s", e.Kind)
+	}
+	return nil
+}
+
+// checkRollback fails for TCB info and QE identity whose tcbEvaluationDataNumber is below the one of the cached
+// entry, which is kept rather than replaced by collateral quote verification rejects. Only collateral whose
+// signatures verify is cached, so forged collateral can not raise the number new collateral is held to.
+func (e *Entry) checkRollback(cached *Entry) error {
+	if cached == nil || e.TcbEvaluationDataNumber >= cached.TcbEvaluationDataNumber {
+		return nil
+	}
+	return errors.Errorf("tcbEvaluationDataNumber %d of %s is below %d of the cached collateral",
+		e.TcbEvaluationDataNumber, e.Key, cached.TcbEvaluationDataNumber)
+}
+
+// CRL returns the CRL of a PCK CRL or Root CA CRL entry
+func (e *Entry) CRL() (*pkix.CertificateList, error) {
+	crlDer, err := base64.StdEncoding.DecodeString(string(e.Body))
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not decode CRL")
+	}
+	crl, err := x509.ParseDERCRL(crlDer)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not parse CRL")
+	}
+	return crl, nil
+}
+
+// IssuerCerts returns the issuer chain of the entry ordered from the signing certificate to the root
+func (e *Entry) IssuerCerts() ([]*x509.Certificate, error) {
+	certs, err := utils.GetCertObjList(url.QueryEscape(e.IssuerChain))
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid issuer chain")
+	}
+	chain, err := utils.BuildCertChain(certs)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid issuer chain")
+	}
+	return chain, nil
+}
+
+// response returns the entry the way SCS serves it
+func (e *Entry) response(req *http.Request) *http.Response {
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+	if name, ok := chainHeaders[e.Kind]; ok {
+		// issuer chains are URL encoded in SCS response headers
+		resp.Header.Set(name, url.QueryEscape(e.IssuerChain))
+	}
+	return resp
+}
+
+// Cache is a HttpClient serving SCS collateral requests from memory. Collateral is fetched from the upstream
+// client when it is not cached, has passed its nextUpdate or was fetched longer than the max age ago, so that
+// revocations are picked up before the nextUpdate of the CRLs. The cached collateral is kept, and served, when
+// it can not be fetched. Only collateral whose signatures verify against the trust anchors is cached. Other
+// requests are passed to the upstream client.
+type Cache struct {
+	upstream     domain.HttpClient
+	baseURL      *url.URL
+	trustAnchors []*x509.Certificate
+	maxAge       time.Duration
+	now          func() time.Time
+
+	mu       sync.RWMutex
+	entries  map[string]*Entry
+	lastUsed map[string]time.Time
+	store    *Store
+}
+
+// NewCache returns a cache of the collateral served by SCS at scsBaseURL through upstream, which is verified
+// against trustAnchors
+func NewCache(upstream domain.HttpClient, scsBaseURL string, trustAnchors []*x509.Certificate) (*Cache, error) {
+	if upstream == nil {
+		return nil, errors.New("collateral:NewCache() Upstream client is nil")
+	}
+	if len(trustAnchors) == 0 {
+		return nil, errors.New("collateral:NewCache() No trust anchors given")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(scsBaseURL, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "collateral:NewCache() Invalid SCS base URL")
+	}
+	return &Cache{
+		upstream:     upstream,
+		baseURL:      baseURL,
+		trustAnchors: trustAnchors,
+		maxAge:       constants.DefaultCollateralMaxAge,
+		now:          time.Now,
+		entries:      make(map[string]*Entry),
+		lastUsed:     make(map[string]time.Time),
+	}, nil
+}
+
+// SetMaxAge sets the time after which cached collateral is fetched again regardless of its nextUpdate, zero
+// selects the default
+func (c *Cache) SetMaxAge(maxAge time.Duration) {
+	if maxAge <= 0 {
+		maxAge = constants.DefaultCollateralMaxAge
+	}
+	c.maxAge = maxAge
+}
+
+// fresh reports whether the cached entry can be served without fetching it again
+func (c *Cache) fresh(entry *Entry) bool {
+	now := c.now()
+	return now.Before(entry.NextUpdate) && now.Before(entry.FetchedAt.Add(c.maxAge))
+}
+
+// key returns the cache key and collateral kind of an SCS request
+func (c *Cache) key(u *url.URL) (string, Kind, bool) {
+	if !strings.HasPrefix(u.Path, c.baseURL.Path) {
+		return "", "", false
+	}
+	path := strings.TrimSuffix(strings.TrimPrefix(u.Path, c.baseURL.Path), "/")
+	kind, ok := documentKinds[path]
+	if !ok {
+		return "", "", false
+	}
+	if query := u.Query().Encode(); query != "" {
+		path += "?" + query
+	}
+	return path, kind, true
+}
+
+func (c *Cache) Do(req *http.Request) (*http.Response, error) {
+	key, kind, ok := c.key(req.URL)
+	if req.Method != http.MethodGet || !ok {
+		return c.upstream.Do(req)
+	}
+
+	c.mu.Lock()
+	c.lastUsed[key] = c.now()
+	cached := c.entries[key]
+	c.mu.Unlock()
+
+	if cached != nil && c.fresh(cached) {
+		return cached.response(req), nil
+	}
+
+	resp, err := c.upstream.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		if cached == nil {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		log.Warnf("collateral/cache:Do() Could not fetch %s, serving the cached collateral which expired at %s",
+			key, cached.NextUpdate.Format(time.RFC3339))
+		return cached.response(req), nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "collateral/cache:Do() Could not read %s", key)
+	}
+	entry, err := newEntry(key, kind, body, resp.Header, c.now())
+	if err == nil {
+		err = entry.Verify(c.trustAnchors)
+	}
+	if err == nil {
+		err = entry.checkRollback(cached)
+	}
+	if err != nil {
+		log.WithError(err).Warnf("collateral/cache:Do() Not caching invalid collateral %s", key)
+		if cached != nil {
+			return cached.response(req), nil
+		}
+		// the caller reports the invalid collateral
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+	if err := c.put(entry); err != nil {
+		log.WithError(err).Warnf("collateral/cache:Do() Not caching collateral %s", key)
+	}
+	return entry.response(req), nil
+}
+
+// Refresh fetches the collateral under key from the upstream client and caches it. The cached collateral
+// is kept when it can not be fetched or is invalid.
+func (c *Cache) Refresh(ctx context.Context, key string) error {
+	u, err := c.baseURL.Parse(c.baseURL.Path + key)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid collateral key %s", key)
+	}
+	normalizedKey, kind, ok := c.key(u)
+	if !ok || normalizedKey != key {
+		return errors.Errorf("Invalid collateral key %s", key)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "Could not create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.upstream.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "Could not fetch %s", key)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("Could not fetch %s, status code %d", key, resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "Could not read %s", key)
+	}
+	entry, err := newEntry(key, kind, body, resp.Header, c.now())
+	if err == nil {
+		err = entry.Verify(c.trustAnchors)
+	}
+	if err == nil {
+		err = entry.checkRollback(c.Get(key))
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Invalid collateral %s", key)
+	}
+	return c.put(entry)
+}
+
+// Get returns the cached collateral under key, or nil
+func (c *Cache) Get(key string) *Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[key]
+}
+
+// Put verifies the signatures of the collateral entry and caches it, replacing the entry under the same key.
+// When the cache is backed by a store the entry is persisted first, and not cached when it can not be.
+func (c *Cache) Put(entry *Entry) error {
+	if err := entry.Verify(c.trustAnchors); err != nil {
+		return errors.Wrapf(err, "Not caching unverified collateral %s", entry.Key)
+	}
+	return c.put(entry)
+}
+
+// put persists and caches a verified entry
+func (c *Cache) put(entry *Entry) error {
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+
+	if store != nil {
+		if err := store.Save(entry); err != nil {
+			return errors.Wrapf(err, "Could not persist collateral %s", entry.Key)
+		}
+	}
+	c.mu.Lock()
+	c.entries[entry.Key] = entry
+	c.mu.Unlock()
+	return nil
+}
+
+// UseStore caches the collateral persisted in store and persists the collateral cached from now on, so that
+// the collateral survives restarts. Stored collateral does not replace collateral fetched more recently.
+func (c *Cache) UseStore(store *Store) error {
+	entries, err := store.Load()
+	if err != nil {
+		return errors.Wrap(err, "collateral:UseStore() Could not load stored collateral")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, entry := range entries {
+		if cached, ok := c.entries[entry.Key]; ok && !cached.FetchedAt.Before(entry.FetchedAt) {
+			continue
+		}
+		c.entries[entry.Key] = entry
+	}
+	c.store = store
+	log.Infof("collateral/cache:UseStore() Loaded %d stored collateral entries", len(entries))
+	return nil
+}
+
+// Entries returns the cached collateral ordered by key
+func (c *Cache) Entries() []*Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]*Entry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// UsedSince returns the keys of the collateral requested through the cache since the given time, collateral
+// which has not been requested since is forgotten
+func (c *Cache) UsedSince(since time.Time) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var keys []string
+	for key, lastUsed := range c.lastUsed {
+		if lastUsed.Before(since) {
+			delete(c.lastUsed, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,406 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSCSBaseURL = "https://scs.example.com:9000/scs/sgx/certification/v1"
+
+// fakeSCS serves TCB info, QE identity and CRLs signed by a test CA, which are valid from issued until
+// nextUpdate
+type fakeSCS struct {
+	t                    *testing.T
+	chain                string
+	cert                 *x509.Certificate
+	key                  *ecdsa.PrivateKey
+	mu                   sync.Mutex
+	issued               time.Time
+	nextUpdate           time.Time
+	evaluationDataNumber uint
+	fail                 bool
+	requests             []string
+}
+
+func newFakeSCS(t *testing.T, issued, nextUpdate time.Time) *fakeSCS {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Intel SGX Root CA"},
+		NotBefore:             issued,
+		NotAfter:              nextUpdate.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDer)
+	assert.NoError(t, err)
+	return &fakeSCS{
+		t:                    t,
+		chain:                string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer})),
+		cert:                 cert,
+		key:                  key,
+		issued:               issued,
+		nextUpdate:           nextUpdate,
+		evaluationDataNumber: 12,
+	}
+}
+
+func (s *fakeSCS) setValidity(issued, nextUpdate time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.issued, s.nextUpdate = issued, nextUpdate
+}
+
+func (s *fakeSCS) setEvaluationDataNumber(number uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evaluationDataNumber = number
+}
+
+func (s *fakeSCS) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func (s *fakeSCS) requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *fakeSCS) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.TrimPrefix(req.URL.Path, "/scs/sgx/certification/v1")
+	if req.URL.RawQuery != "" {
+		s.requests = append(s.requests, path+"?"+req.URL.RawQuery)
+	} else {
+		s.requests = append(s.requests, path)
+	}
+	if s.fail {
+		return nil, errors.New("SCS is down")
+	}
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Request: req}
+	var body string
+	dates := fmt.Sprintf(`"issueDate":"%s","nextUpdate":"%s","tcbEvaluationDataNumber":%d`,
+		s.issued.UTC().Format(time.RFC3339), s.nextUpdate.UTC().Format(time.RFC3339), s.evaluationDataNumber)
+	switch path {
+	case "/tcb":
+		body = s.sign("tcbInfo", fmt.Sprintf(`{"fmspc":"%s",%s}`, req.URL.Query().Get("fmspc"), dates))
+		resp.Header.Set("SGX-TCB-Info-Issuer-Chain", url.QueryEscape(s.chain))
+	case "/qe/identity":
+		body = s.sign("enclaveIdentity", fmt.Sprintf(`{%s}`, dates))
+		resp.Header.Set("Sgx-Qe-Identity-Issuer-Chain", url.QueryEscape(s.chain))
+	case "/pckcrl", "/rootcacrl":
+		crlDer, err := s.cert.CreateCRL(rand.Reader, s.key, nil, s.issued, s.nextUpdate)
+		assert.NoError(s.t, err)
+		body = base64.StdEncoding.EncodeToString(crlDer)
+		if path == "/pckcrl" {
+			resp.Header.Set("SGX-PCK-CRL-Issuer-Chain", url.QueryEscape(s.chain))
+		}
+	default:
+		resp.StatusCode = http.StatusNotFound
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(body))
+	return resp, nil
+}
+
+// sign returns a TCB info or QE identity document with the signature over its object
+func (s *fakeSCS) sign(name, object string) string {
+	hash := sha256.Sum256([]byte(object))
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, hash[:])
+	assert.NoError(s.t, err)
+	sigBlob := make([]byte, 64)
+	r.FillBytes(sigBlob[:32])
+	sig.FillBytes(sigBlob[32:])
+	return fmt.Sprintf(`{"%s":%s,"signature":"%s"}`, name, object, hex.EncodeToString(sigBlob))
+}
+
+func newTestCache(t *testing.T, scs *fakeSCS, now *time.Time) *Cache {
+	cache, err := NewCache(scs, testSCSBaseURL, []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+	cache.now = func() time.Time { return *now }
+	return cache
+}
+
+func doRequest(t *testing.T, cache *Cache, path string) (*http.Response, string) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, testSCSBaseURL+path, nil)
+	assert.NoError(t, err)
+	resp, err := cache.Do(req)
+	if err != nil {
+		return nil, ""
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestNewCache(t *testing.T) {
+	scs := newFakeSCS(t, time.Now(), time.Now().Add(time.Hour))
+	_, err := NewCache(nil, testSCSBaseURL, []*x509.Certificate{scs.cert})
+	assert.Error(t, err)
+
+	_, err = NewCache(scs, ":invalid", []*x509.Certificate{scs.cert})
+	assert.Error(t, err)
+
+	_, err = NewCache(scs, testSCSBaseURL, nil)
+	assert.Error(t, err)
+}
+
+func TestCacheDo(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache := newTestCache(t, scs, &now)
+
+	for _, path := range []string{"/tcb?fmspc=00906ed50000", "/qe/identity", "/pckcrl?ca=processor", "/rootcacrl"} {
+		resp, body := doRequest(t, cache, path)
+		if assert.NotNil(t, resp) {
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.NotEmpty(t, body)
+		}
+		cached, _ := doRequest(t, cache, path)
+		if assert.NotNil(t, cached) {
+			for name := range resp.Header {
+				assert.Equal(t, resp.Header.Get(name), cached.Header.Get(name))
+			}
+		}
+	}
+	// every document was fetched once
+	assert.Len(t, scs.requested(), 4)
+	assert.Len(t, cache.Entries(), 4)
+
+	entry := cache.Get("/tcb?fmspc=00906ed50000")
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, KindTcbInfo, entry.Kind)
+		assert.Equal(t, now.Add(time.Hour).UTC().Truncate(time.Second), entry.NextUpdate)
+		assert.Equal(t, scs.chain, entry.IssuerChain)
+		assert.Equal(t, uint(12), entry.TcbEvaluationDataNumber)
+	}
+	if entry := cache.Get("/rootcacrl"); assert.NotNil(t, entry) {
+		assert.Zero(t, entry.TcbEvaluationDataNumber)
+	}
+
+	// other requests are passed through
+	resp, _ := doRequest(t, cache, "/pckcert")
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	assert.Len(t, scs.requested(), 5)
+}
+
+func TestCacheDoExpired(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache := newTestCache(t, scs, &now)
+	_, _ = doRequest(t, cache, "/qe/identity")
+
+	// expired collateral is fetched again
+	now = now.Add(2 * time.Hour)
+	scs.setValidity(now.Add(-time.Minute), now.Add(time.Hour))
+	_, _ = doRequest(t, cache, "/qe/identity")
+	assert.Len(t, scs.requested(), 2)
+	assert.Equal(t, now.Add(time.Hour).UTC().Truncate(time.Second), cache.Get("/qe/identity").NextUpdate)
+
+	// and served from the cache when it can not be fetched
+	now = now.Add(2 * time.Hour)
+	scs.setFail(true)
+	resp, body := doRequest(t, cache, "/qe/identity")
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, body, "enclaveIdentity")
+	}
+
+	// collateral which was never fetched fails
+	resp, _ = doRequest(t, cache, "/rootcacrl")
+	assert.Nil(t, resp)
+}
+
+func TestCacheDoMaxAge(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(30*24*time.Hour))
+	cache := newTestCache(t, scs, &now)
+	_, _ = doRequest(t, cache, "/pckcrl?ca=processor")
+
+	now = now.Add(12 * time.Hour)
+	_, _ = doRequest(t, cache, "/pckcrl?ca=processor")
+	assert.Len(t, scs.requested(), 1)
+
+	// collateral is fetched again after the max age although its nextUpdate has not passed
+	now = now.Add(12 * time.Hour)
+	_, _ = doRequest(t, cache, "/pckcrl?ca=processor")
+	assert.Len(t, scs.requested(), 2)
+
+	cache.SetMaxAge(time.Hour)
+	now = now.Add(time.Hour)
+	_, _ = doRequest(t, cache, "/pckcrl?ca=processor")
+	assert.Len(t, scs.requested(), 3)
+
+	cache.SetMaxAge(0)
+	now = now.Add(time.Hour)
+	_, _ = doRequest(t, cache, "/pckcrl?ca=processor")
+	assert.Len(t, scs.requested(), 3)
+}
+
+func TestCacheRefresh(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache := newTestCache(t, scs, &now)
+
+	assert.NoError(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	assert.NotNil(t, cache.Get("/tcb?fmspc=00906ed50000"))
+	assert.Error(t, cache.Refresh(context.Background(), "/pckcert"))
+	assert.Error(t, cache.Refresh(context.Background(), "/tcb?b=1&a=2"))
+
+	// the cached collateral is kept when the refresh fails
+	scs.setFail(true)
+	assert.Error(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	assert.NotNil(t, cache.Get("/tcb?fmspc=00906ed50000"))
+}
+
+func TestCacheRollback(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache := newTestCache(t, scs, &now)
+	assert.NoError(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+
+	// TCB info rolled back to older TCB evaluation data does not replace the cached TCB info
+	scs.setEvaluationDataNumber(11)
+	assert.Error(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	now = now.Add(2 * time.Hour)
+	scs.setValidity(now.Add(-time.Minute), now.Add(time.Hour))
+	_, body := doRequest(t, cache, "/tcb?fmspc=00906ed50000")
+	assert.Contains(t, body, `"tcbEvaluationDataNumber":12`)
+	assert.Equal(t, uint(12), cache.Get("/tcb?fmspc=00906ed50000").TcbEvaluationDataNumber)
+
+	scs.setEvaluationDataNumber(13)
+	assert.NoError(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	assert.Equal(t, uint(13), cache.Get("/tcb?fmspc=00906ed50000").TcbEvaluationDataNumber)
+
+	// collateral failing signature verification does not raise the number either
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	other.setEvaluationDataNumber(20)
+	otherCache := newTestCache(t, other, &now)
+	assert.NoError(t, otherCache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	assert.Error(t, cache.Put(otherCache.Get("/tcb?fmspc=00906ed50000")))
+	assert.Equal(t, uint(13), cache.Get("/tcb?fmspc=00906ed50000").TcbEvaluationDataNumber)
+
+	scs.setEvaluationDataNumber(14)
+	assert.NoError(t, cache.Refresh(context.Background(), "/tcb?fmspc=00906ed50000"))
+	assert.Equal(t, uint(14), cache.Get("/tcb?fmspc=00906ed50000").TcbEvaluationDataNumber)
+}
+
+func TestCacheUnverified(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache, err := NewCache(other, testSCSBaseURL, []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+	cache.now = func() time.Time { return now }
+
+	// collateral not signed by a trust anchor is passed to the caller but not cached
+	for _, key := range testKeys {
+		resp, body := doRequest(t, cache, key)
+		if assert.NotNil(t, resp, key) {
+			assert.NotEmpty(t, body)
+		}
+		assert.Error(t, cache.Refresh(context.Background(), key), key)
+	}
+	assert.Empty(t, cache.Entries())
+
+	// unverified collateral does not replace the cached collateral
+	trusted := newTestCache(t, scs, &now)
+	assert.NoError(t, trusted.Refresh(context.Background(), "/qe/identity"))
+	for _, e := range fetchTestEntries(t, other, &now) {
+		assert.Error(t, trusted.Put(e), e.Key)
+	}
+	assert.Len(t, trusted.Entries(), 1)
+	assert.Equal(t, scs.chain, trusted.Get("/qe/identity").IssuerChain)
+}
+
+func TestCachePutStore(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	entries := fetchTestEntries(t, scs, &now)
+
+	store, err := NewStore(t.TempDir(), []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+	cache := newTestCache(t, scs, &now)
+	assert.NoError(t, cache.UseStore(store))
+	for _, e := range entries {
+		assert.NoError(t, cache.Put(e), e.Key)
+	}
+	loaded, err := store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(entries))
+
+	// entries the store rejects are not cached either
+	otherStore, err := NewStore(t.TempDir(), []*x509.Certificate{other.cert})
+	assert.NoError(t, err)
+	cache = newTestCache(t, scs, &now)
+	assert.NoError(t, cache.UseStore(otherStore))
+	for _, e := range entries {
+		assert.Error(t, cache.Put(e), e.Key)
+	}
+	assert.Empty(t, cache.Entries())
+}
+
+func TestCacheUsedSince(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	cache := newTestCache(t, scs, &now)
+
+	_, _ = doRequest(t, cache, "/tcb?fmspc=00906ed50000")
+	now = now.Add(time.Hour)
+	_, _ = doRequest(t, cache, "/qe/identity")
+
+	assert.Equal(t, []string{"/qe/identity", "/tcb?fmspc=00906ed50000"}, cache.UsedSince(now.Add(-2*time.Hour)))
+	assert.Equal(t, []string{"/qe/identity"}, cache.UsedSince(now.Add(-time.Minute)))
+	// forgotten collateral is not reported again
+	assert.Equal(t, []string{"/qe/identity"}, cache.UsedSince(now.Add(-2*time.Hour)))
+}
+
+func TestNewEntryInvalid(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	header := http.Header{}
+	header.Set("SGX-TCB-Info-Issuer-Chain", url.QueryEscape(scs.chain))
+
+	_, err := newEntry("/tcb?fmspc=00906ed50000", KindTcbInfo, []byte(`{"tcbInfo":{}}`), header, now)
+	assert.Error(t, err)
+	_, err = newEntry("/tcb?fmspc=00906ed50000", KindTcbInfo, []byte(`{"tcbInfo":{"issueDate":"2022-01-01T00:00:00Z",`+
+		`"nextUpdate":"2022-02-01T00:00:00Z"}}`), http.Header{}, now)
+	assert.Error(t, err)
+	_, err = newEntry("/rootcacrl", KindRootCaCrl, []byte("not a crl"), http.Header{}, now)
+	assert.Error(t, err)
+}
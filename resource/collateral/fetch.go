@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"context"
+	"crypto/x509"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/parser"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// Fetch pulls collateral from SCS with the parsers used by quote verification: the TCB info of each FMSPC, the
+// QE identity, the Root CA CRL and the PCK CRLs of the given CAs (processor, platform). Fetch fails unless all
+// the collateral verifies against trustAnchors.
+func Fetch(ctx context.Context, conf *config.Configuration, client domain.HttpClient,
+	trustAnchors []*x509.Certificate, fmspcs []string, pckCAs []string) ([]*Entry, error) {
+	cache, err := NewCache(client, conf.SCSBaseURL, trustAnchors)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{"/qe/identity", "/rootcacrl"}
+	for _, fmspc := range fmspcs {
+		keys = append(keys, "/tcb?"+url.Values{"fmspc": {fmspc}}.Encode())
+	}
+	for _, ca := range pckCAs {
+		keys = append(keys, "/pckcrl?"+url.Values{"ca": {ca}}.Encode())
+	}
+
+	for _, fmspc := range fmspcs {
+		if _, err := parser.NewTcbInfo(ctx, fmspc, conf, cache); err != nil {
+			return nil, errors.Wrapf(err, "Could not fetch TCB info of FMSPC %s", fmspc)
+		}
+	}
+	if _, err := parser.NewQeIdentity(ctx, conf, cache); err != nil {
+		return nil, errors.Wrap(err, "Could not fetch QE identity")
+	}
+	if _, err := parser.NewRootCACrl(ctx, conf, cache); err != nil {
+		return nil, errors.Wrap(err, "Could not fetch Root CA CRL")
+	}
+	if len(pckCAs) > 0 {
+		crlURLs := make([]string, len(pckCAs))
+		for i, ca := range pckCAs {
+			crlURLs[i] = conf.SCSBaseURL + "/pckcrl?ca=" + ca
+		}
+		pckCert := &parser.PckCert{
+			PckCertObj: &x509.Certificate{CRLDistributionPoints: crlURLs},
+			SCSClient:  cache,
+			Config:     conf,
+		}
+		if err := pckCert.ParsePckCrl(ctx); err != nil {
+			return nil, errors.Wrap(err, "Could not fetch PCK CRL")
+		}
+	}
+
+	// collateral failing signature verification is parsed but not cached
+	for _, key := range keys {
+		if cache.Get(key) == nil {
+			return nil, errors.Errorf("Signature verification of collateral %s failed", key)
+		}
+	}
+	return cache.Entries(), nil
+}
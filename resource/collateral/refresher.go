@@ -0,0 +1,242 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"context"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Stats are the metrics of the collateral refresher
+type Stats struct {
+	Refreshes           int64     `json:"refreshes"`
+	Failures            int64     `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastRefresh         time.Time `json:"lastRefresh,omitempty"`
+	LastFailure         time.Time `json:"lastFailure,omitempty"`
+	LastError           string    `json:"lastError,omitempty"`
+	CachedEntries       int       `json:"cachedEntries"`
+	ScheduledEntries    int       `json:"scheduledEntries"`
+}
+
+// Refresher refreshes the cached collateral in the background ahead of its nextUpdate, so that verifications
+// do not wait for SCS. It refreshes the collateral of the configured FMSPCs and the collateral requested for
+// verifications within the last forgetAfter. Refreshes are scheduled with a random jitter so that they are
+// spread out, a failed refresh keeps the cached collateral and is retried on the next pass.
+type Refresher struct {
+	cache       *Cache
+	conf        config.CollateralRefreshConfig
+	forgetAfter time.Duration
+	now         func() time.Time
+	jitter      func(max time.Duration) time.Duration
+
+	mu        sync.Mutex
+	refreshAt map[string]time.Time
+	stats     Stats
+
+	stop    chan struct{}
+	done    chan struct{}
+	cancel  context.CancelFunc
+	started bool
+}
+
+func randomJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max) + 1))
+}
+
+// NewRefresher returns a refresher of the collateral in cache, zero durations in conf select the defaults
+func NewRefresher(cache *Cache, conf config.CollateralRefreshConfig) *Refresher {
+	if conf.Interval <= 0 {
+		conf.Interval = constants.DefaultCollateralRefreshPeriod
+	}
+	if conf.RefreshAhead <= 0 {
+		conf.RefreshAhead = constants.DefaultCollateralRefreshAhead
+	}
+	if conf.MaxAge <= 0 {
+		conf.MaxAge = constants.DefaultCollateralMaxAge
+	}
+	return &Refresher{
+		cache:       cache,
+		conf:        conf,
+		forgetAfter: constants.DefaultCollateralForgetAfter,
+		now:         time.Now,
+		jitter:      randomJitter,
+		refreshAt:   make(map[string]time.Time),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// keys returns the collateral to keep fresh. The TCB info of configured FMSPCs comes with the QE identity,
+// the PCK CRLs and the Root CA CRL, which are needed to verify any quote.
+func (r *Refresher) keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, fmspc := range r.conf.FMSPCs {
+		add("/tcb?fmspc=" + strings.ToLower(strings.TrimSpace(fmspc)))
+	}
+	if len(keys) != 0 {
+		add("/qe/identity")
+		add("/pckcrl?ca=processor")
+		add("/pckcrl?ca=platform")
+		add("/rootcacrl")
+	}
+	for _, key := range r.cache.UsedSince(r.now().Add(-r.forgetAfter)) {
+		add(key)
+	}
+	return keys
+}
+
+// schedule returns the time the entry is refreshed at, which is RefreshAhead before its nextUpdate but
+// no later than MaxAge after it was fetched, brought forward by up to a tenth of its lifetime
+func (r *Refresher) schedule(entry *Entry) time.Time {
+	at := entry.NextUpdate.Add(-r.conf.RefreshAhead)
+	if maxAt := entry.FetchedAt.Add(r.conf.MaxAge); maxAt.Before(at) {
+		at = maxAt
+	}
+	if lifetime := at.Sub(entry.FetchedAt); lifetime > 0 {
+		at = at.Add(-r.jitter(lifetime / 10))
+	}
+	return at
+}
+
+// due reports whether the collateral under key is missing or has reached its refresh time
+func (r *Refresher) due(key string) bool {
+	entry := r.cache.Get(key)
+	if entry == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.refreshAt[key]
+	if !ok || !entry.FetchedAt.Before(at) {
+		// the entry was cached by a verification since it was scheduled
+		at = r.schedule(entry)
+		r.refreshAt[key] = at
+	}
+	return !r.now().Before(at)
+}
+
+// RefreshDue refreshes the collateral which is due, it returns early when the refresher is stopped
+func (r *Refresher) RefreshDue(ctx context.Context) {
+	keys := r.keys()
+	for _, key := range keys {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		if !r.due(key) {
+			continue
+		}
+
+		err := r.cache.Refresh(ctx, key)
+		r.mu.Lock()
+		if err != nil {
+			r.stats.Failures++
+			r.stats.ConsecutiveFailures++
+			r.stats.LastFailure = r.now()
+			r.stats.LastError = err.Error()
+			r.mu.Unlock()
+			log.WithError(err).Warnf("collateral/refresher:RefreshDue() Failed to refresh %s", key)
+			continue
+		}
+		r.stats.Refreshes++
+		r.stats.ConsecutiveFailures = 0
+		r.stats.LastRefresh = r.now()
+		r.stats.LastError = ""
+		r.mu.Unlock()
+		log.Debugf("collateral/refresher:RefreshDue() Refreshed %s", key)
+	}
+
+	r.mu.Lock()
+	r.stats.ScheduledEntries = len(keys)
+	r.mu.Unlock()
+}
+
+// Start refreshes the due collateral right away and then every Interval until the refresher is stopped
+func (r *Refresher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.cancel = cancel
+	r.started = true
+	r.mu.Unlock()
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.conf.Interval)
+		defer ticker.Stop()
+		for {
+			r.RefreshDue(ctx)
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops scheduling refreshes and waits for the refresh in progress to finish. The refresh is cancelled
+// when ctx is done first.
+func (r *Refresher) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	cancel := r.cancel
+	r.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	select {
+	case <-r.done:
+		cancel()
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// Stats returns the metrics of the refresher
+func (r *Refresher) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats
+	stats.CachedEntries = len(r.cache.Entries())
+	return stats
+}
+
+// Health reports the refresher as failing while the last refresh failed, the cached collateral is still
+// served then
+func (r *Refresher) Health() (interface{}, error) {
+	stats := r.Stats()
+	if stats.ConsecutiveFailures > 0 {
+		return stats, errors.Errorf("%d collateral refreshes failed, last error: %s", stats.ConsecutiveFailures,
+			stats.LastError)
+	}
+	return stats, nil
+}
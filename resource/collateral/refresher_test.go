@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"context"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRefresher(t *testing.T, cache *Cache, conf config.CollateralRefreshConfig, now *time.Time) *Refresher {
+	r := NewRefresher(cache, conf)
+	r.now = func() time.Time { return *now }
+	r.jitter = func(max time.Duration) time.Duration { return max }
+	return r
+}
+
+func TestNewRefresherDefaults(t *testing.T) {
+	r := NewRefresher(nil, config.CollateralRefreshConfig{})
+	assert.Equal(t, constants.DefaultCollateralRefreshPeriod, r.conf.Interval)
+	assert.Equal(t, constants.DefaultCollateralRefreshAhead, r.conf.RefreshAhead)
+	assert.Equal(t, constants.DefaultCollateralMaxAge, r.conf.MaxAge)
+}
+
+func TestRefresherSchedule(t *testing.T) {
+	now := time.Now()
+	r := newTestRefresher(t, nil, config.CollateralRefreshConfig{RefreshAhead: time.Hour, MaxAge: 24 * time.Hour}, &now)
+
+	// refreshed ahead of nextUpdate, brought forward by the jitter
+	entry := &Entry{FetchedAt: now, NextUpdate: now.Add(11 * time.Hour)}
+	assert.Equal(t, now.Add(9*time.Hour), r.schedule(entry))
+
+	// but no later than MaxAge after it was fetched
+	entry = &Entry{FetchedAt: now, NextUpdate: now.Add(30 * 24 * time.Hour)}
+	assert.Equal(t, now.Add(24*time.Hour-144*time.Minute), r.schedule(entry))
+
+	// collateral about to expire is refreshed right away
+	entry = &Entry{FetchedAt: now, NextUpdate: now.Add(time.Minute)}
+	assert.Equal(t, now.Add(-59*time.Minute), r.schedule(entry))
+}
+
+func TestRefresherRefreshDue(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(30*24*time.Hour))
+	cache := newTestCache(t, scs, &now)
+	r := newTestRefresher(t, cache, config.CollateralRefreshConfig{FMSPCs: []string{"00906ED50000"}}, &now)
+
+	// the configured FMSPC is prefetched along with the QE identity, the PCK CRLs and the Root CA CRL
+	r.RefreshDue(context.Background())
+	assert.Equal(t, []string{"/tcb?fmspc=00906ed50000", "/qe/identity", "/pckcrl?ca=processor",
+		"/pckcrl?ca=platform", "/rootcacrl"}, scs.requested())
+	assert.Len(t, cache.Entries(), 5)
+
+	// collateral requested for verifications is learned
+	_, _ = doRequest(t, cache, "/tcb?fmspc=00606a000000")
+	r.RefreshDue(context.Background())
+	assert.Len(t, scs.requested(), 6)
+	stats := r.Stats()
+	assert.Equal(t, int64(5), stats.Refreshes)
+	assert.Equal(t, 6, stats.ScheduledEntries)
+	assert.Equal(t, 6, stats.CachedEntries)
+
+	// nothing is refreshed until MaxAge has passed
+	now = now.Add(12 * time.Hour)
+	r.RefreshDue(context.Background())
+	assert.Len(t, scs.requested(), 6)
+
+	now = now.Add(12 * time.Hour)
+	r.RefreshDue(context.Background())
+	assert.Len(t, scs.requested(), 12)
+	_, err := r.Health()
+	assert.NoError(t, err)
+
+	// failed refreshes keep the cached collateral and are reported
+	now = now.Add(24 * time.Hour)
+	scs.setFail(true)
+	r.RefreshDue(context.Background())
+	metrics, err := r.Health()
+	assert.Error(t, err)
+	assert.Equal(t, int64(6), metrics.(Stats).Failures)
+	assert.Equal(t, 6, metrics.(Stats).ConsecutiveFailures)
+	assert.Len(t, cache.Entries(), 6)
+
+	scs.setFail(false)
+	r.RefreshDue(context.Background())
+	_, err = r.Health()
+	assert.NoError(t, err)
+
+	// collateral no longer requested is forgotten
+	now = now.Add(constants.DefaultCollateralForgetAfter)
+	r.RefreshDue(context.Background())
+	assert.Equal(t, 5, r.Stats().ScheduledEntries)
+}
+
+func TestRefresherStartStop(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(30*24*time.Hour))
+	cache := newTestCache(t, scs, &now)
+	r := NewRefresher(cache, config.CollateralRefreshConfig{FMSPCs: []string{"00906ed50000"}, Interval: time.Hour})
+
+	// stopping a refresher which was not started does nothing
+	assert.NoError(t, r.Stop(context.Background()))
+
+	r.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, r.Stop(ctx))
+	assert.NoError(t, r.Stop(ctx))
+}
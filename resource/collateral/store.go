@@ -0,0 +1,307 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	entryFileSuffix = ".json"
+	// maxEntryFileSize bounds the size of a collateral entry read from the store or a bundle
+	maxEntryFileSize = 4 << 20
+)
+
+// entryFile is the representation of an entry on disk and in bundles
+type entryFile struct {
+	Key         string    `json:"key"`
+	Kind        Kind      `json:"kind"`
+	Body        string    `json:"body"`
+	IssuerChain string    `json:"issuerChain,omitempty"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+func marshalEntry(e *Entry) ([]byte, error) {
+	return json.MarshalIndent(entryFile{
+		Key:         e.Key,
+		Kind:        e.Kind,
+		Body:        string(e.Body),
+		IssuerChain: e.IssuerChain,
+		FetchedAt:   e.FetchedAt,
+	}, "", "  ")
+}
+
+// unmarshalEntry parses an entry and reads its validity period from the document
+func unmarshalEntry(data []byte) (*Entry, error) {
+	var f entryFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrap(err, "Could not parse collateral entry")
+	}
+	e := &Entry{
+		Key:         f.Key,
+		Kind:        f.Kind,
+		Body:        []byte(f.Body),
+		IssuerChain: f.IssuerChain,
+		FetchedAt:   f.FetchedAt,
+	}
+	if kind, ok := documentKinds[strings.SplitN(e.Key, "?", 2)[0]]; !ok || kind != e.Kind {
+		return nil, errors.Errorf("Invalid key %s of %s collateral entry", e.Key, e.Kind)
+	}
+	if err := e.parse(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid collateral entry %s", e.Key)
+	}
+	return e, nil
+}
+
+// entryFileName names the file of an entry after the hash of its key, which is not a valid file name
+func entryFileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:]) + entryFileSuffix
+}
+
+// Store persists collateral in a directory so that it survives restarts and the service can verify quotes
+// while SCS is unreachable. Only collateral whose signatures verify against the trust anchors is stored,
+// and the signatures are verified again when the collateral is loaded.
+type Store struct {
+	dir          string
+	trustAnchors []*x509.Certificate
+}
+
+// NewStore returns a store of the collateral in dir, which is created when it does not exist
+func NewStore(dir string, trustAnchors []*x509.Certificate) (*Store, error) {
+	if len(trustAnchors) == 0 {
+		return nil, errors.New("collateral:NewStore() No trust anchors given")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "collateral:NewStore() Could not create collateral directory")
+	}
+	return &Store{dir: dir, trustAnchors: trustAnchors}, nil
+}
+
+// Save verifies the entry and writes it to the store, replacing the entry under the same key
+func (s *Store) Save(e *Entry) error {
+	if err := e.Verify(s.trustAnchors); err != nil {
+		return errors.Wrapf(err, "Not storing unverified collateral %s", e.Key)
+	}
+	data, err := marshalEntry(e)
+	if err != nil {
+		return errors.Wrapf(err, "Could not encode collateral %s", e.Key)
+	}
+
+	// the entry is replaced atomically so that a crash does not leave a partial entry behind
+	tmp, err := ioutil.TempFile(s.dir, ".entry-")
+	if err != nil {
+		return errors.Wrap(err, "Could not create collateral file")
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "Could not write collateral %s", e.Key)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, entryFileName(e.Key))); err != nil {
+		return errors.Wrapf(err, "Could not write collateral %s", e.Key)
+	}
+	return nil
+}
+
+// Load returns the stored collateral whose signatures verify, other entries are skipped and reported
+func (s *Store) Load() ([]*Entry, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read collateral directory")
+	}
+	var entries []*Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), entryFileSuffix) {
+			continue
+		}
+		e, err := s.loadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			log.WithError(err).Warnf("collateral/store:Load() Skipping collateral file %s", file.Name())
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (s *Store) loadFile(path string) (*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := ioutil.ReadAll(io.LimitReader(f, maxEntryFileSize))
+	if err != nil {
+		return nil, err
+	}
+	e, err := unmarshalEntry(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.Verify(s.trustAnchors); err != nil {
+		return nil, errors.Wrapf(err, "Signature verification of collateral %s failed", e.Key)
+	}
+	return e, nil
+}
+
+// TrustAnchors returns the certificates the stored collateral is verified against
+func (s *Store) TrustAnchors() []*x509.Certificate {
+	return s.trustAnchors
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Get returns the stored collateral under key after verifying its signatures
+func (s *Store) Get(key string) (*Entry, error) {
+	e, err := s.loadFile(filepath.Join(s.dir, entryFileName(key)))
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, errors.Errorf("Collateral %s is not stored", key)
+	}
+	return e, err
+}
+
+// Purge removes all the stored collateral and returns the number of removed entries
+func (s *Store) Purge() (int, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "Could not read collateral directory")
+	}
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), entryFileSuffix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, file.Name())); err != nil {
+			return removed, errors.Wrap(err, "Could not remove collateral file")
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Import verifies the collateral of a bundle and stores it, it returns the imported entries. Nothing is
+// stored when an entry of the bundle does not verify.
+func (s *Store) Import(r io.Reader) ([]*Entry, error) {
+	entries, err := ReadBundle(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if err := e.Verify(s.trustAnchors); err != nil {
+			return nil, errors.Wrapf(err, "Signature verification of collateral %s failed", e.Key)
+		}
+	}
+	for _, e := range entries {
+		if err := s.Save(e); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Export writes the stored collateral as a bundle
+func (s *Store) Export(w io.Writer) error {
+	entries, err := s.Load()
+	if err != nil {
+		return err
+	}
+	return WriteBundle(w, entries)
+}
+
+// WriteBundle writes the entries as a gzip compressed tarball with one file per entry
+func WriteBundle(w io.Writer, entries []*Entry) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		data, err := marshalEntry(e)
+		if err != nil {
+			return errors.Wrapf(err, "Could not encode collateral %s", e.Key)
+		}
+		hdr := &tar.Header{
+			Name:    entryFileName(e.Key),
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: e.FetchedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrap(err, "Could not write collateral bundle")
+		}
+		if _, err := tw.Write(data); err != nil {
+			return errors.Wrap(err, "Could not write collateral bundle")
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "Could not write collateral bundle")
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, "Could not write collateral bundle")
+	}
+	return nil
+}
+
+// ReadBundle parses the entries of a bundle written by WriteBundle, their signatures are not verified
+func ReadBundle(r io.Reader) ([]*Entry, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read collateral bundle")
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+	tr := tar.NewReader(gz)
+	var entries []*Entry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not read collateral bundle")
+		}
+		if hdr.Typeflag != tar.TypeReg || !strings.HasSuffix(hdr.Name, entryFileSuffix) {
+			continue
+		}
+		if hdr.Size > maxEntryFileSize {
+			return nil, errors.Errorf("Collateral bundle file %s is too large", hdr.Name)
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(tr, maxEntryFileSize))
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not read collateral bundle")
+		}
+		e, err := unmarshalEntry(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid collateral bundle file %s", hdr.Name)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
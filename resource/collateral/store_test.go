@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testKeys = []string{"/tcb?fmspc=00906ed50000", "/qe/identity", "/pckcrl?ca=processor", "/rootcacrl"}
+
+func fetchTestEntries(t *testing.T, scs *fakeSCS, now *time.Time) []*Entry {
+	cache := newTestCache(t, scs, now)
+	for _, key := range testKeys {
+		assert.NoError(t, cache.Refresh(context.Background(), key))
+	}
+	return cache.Entries()
+}
+
+func TestEntryVerify(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	anchors := []*x509.Certificate{scs.cert}
+
+	entries := fetchTestEntries(t, scs, &now)
+	assert.Len(t, entries, len(testKeys))
+	for _, e := range entries {
+		assert.NoError(t, e.Verify(anchors), e.Key)
+		assert.Error(t, e.Verify([]*x509.Certificate{other.cert}), e.Key)
+		assert.Error(t, e.Verify(nil), e.Key)
+	}
+
+	// a tampered document no longer matches its signature
+	tcbInfo := *entries[len(entries)-1]
+	assert.Equal(t, KindTcbInfo, tcbInfo.Kind)
+	tcbInfo.Body = bytes.Replace(tcbInfo.Body, []byte("00906ed50000"), []byte("00906ed50001"), 1)
+	assert.Error(t, tcbInfo.Verify(anchors))
+
+	// as does a document signed by another key
+	otherEntries := fetchTestEntries(t, other, &now)
+	for _, e := range otherEntries {
+		forged := *e
+		forged.IssuerChain = scs.chain
+		assert.Error(t, forged.Verify(anchors), e.Key)
+	}
+}
+
+func TestStore(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	dir := filepath.Join(t.TempDir(), "collateral")
+
+	_, err := NewStore(dir, nil)
+	assert.Error(t, err)
+	store, err := NewStore(dir, []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+
+	entries := fetchTestEntries(t, scs, &now)
+	for _, e := range entries {
+		assert.NoError(t, store.Save(e))
+	}
+	// saving again replaces the entry
+	assert.NoError(t, store.Save(entries[0]))
+
+	loaded, err := store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(entries))
+
+	// unverified collateral is neither stored nor loaded
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	otherEntries := fetchTestEntries(t, other, &now)
+	assert.Error(t, store.Save(otherEntries[0]))
+
+	data, err := marshalEntry(otherEntries[0])
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, entryFileName(otherEntries[0].Key)), data, 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "corrupt.json"), []byte("{"), 0600))
+	loaded, err = store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(entries)-1)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, file := range files {
+		assert.Equal(t, os.FileMode(0600), file.Mode().Perm(), file.Name())
+	}
+}
+
+func TestStoreGetPurge(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	dir := t.TempDir()
+	store, err := NewStore(dir, []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+	for _, e := range fetchTestEntries(t, scs, &now) {
+		assert.NoError(t, store.Save(e))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("kept"), 0600))
+
+	entry, err := store.Get("/qe/identity")
+	assert.NoError(t, err)
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, KindQeIdentity, entry.Kind)
+		assert.Equal(t, uint(12), entry.TcbEvaluationDataNumber)
+	}
+	_, err = store.Get("/tcb?fmspc=00906ed50001")
+	assert.Error(t, err)
+
+	removed, err := store.Purge()
+	assert.NoError(t, err)
+	assert.Equal(t, len(testKeys), removed)
+	loaded, err := store.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, loaded)
+	_, err = os.Stat(filepath.Join(dir, "README"))
+	assert.NoError(t, err)
+}
+
+func TestStoreBundle(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	anchors := []*x509.Certificate{scs.cert}
+
+	source, err := NewStore(t.TempDir(), anchors)
+	assert.NoError(t, err)
+	for _, e := range fetchTestEntries(t, scs, &now) {
+		assert.NoError(t, source.Save(e))
+	}
+	var bundle bytes.Buffer
+	assert.NoError(t, source.Export(&bundle))
+
+	target, err := NewStore(t.TempDir(), anchors)
+	assert.NoError(t, err)
+	imported, err := target.Import(bytes.NewReader(bundle.Bytes()))
+	assert.NoError(t, err)
+	assert.Len(t, imported, len(testKeys))
+	loaded, err := target.Load()
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(testKeys))
+
+	// a bundle signed by another CA is rejected as a whole
+	other := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	var otherBundle bytes.Buffer
+	assert.NoError(t, WriteBundle(&otherBundle, fetchTestEntries(t, other, &now)))
+	rejecting, err := NewStore(t.TempDir(), anchors)
+	assert.NoError(t, err)
+	_, err = rejecting.Import(&otherBundle)
+	assert.Error(t, err)
+	loaded, err = rejecting.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, loaded)
+
+	_, err = ReadBundle(strings.NewReader("not a bundle"))
+	assert.Error(t, err)
+}
+
+func TestCacheUseStore(t *testing.T) {
+	now := time.Now()
+	scs := newFakeSCS(t, now.Add(-time.Hour), now.Add(time.Hour))
+	store, err := NewStore(t.TempDir(), []*x509.Certificate{scs.cert})
+	assert.NoError(t, err)
+
+	// collateral fetched through a cache backed by the store is persisted
+	cache := newTestCache(t, scs, &now)
+	assert.NoError(t, cache.UseStore(store))
+	for _, key := range testKeys {
+		_, _ = doRequest(t, cache, key)
+	}
+	loaded, err := store.Load()
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(testKeys))
+
+	// and served after a restart while SCS is down
+	scs.setFail(true)
+	restarted := newTestCache(t, scs, &now)
+	assert.NoError(t, restarted.UseStore(store))
+	for _, key := range testKeys {
+		resp, body := doRequest(t, restarted, key)
+		if assert.NotNil(t, resp, key) {
+			assert.NotEmpty(t, body)
+		}
+	}
+	assert.Len(t, scs.requested(), len(testKeys))
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package collateral
+
+import (
+	"bytes"
+	"crypto/x509"
+	"intel/isecl/sqvs/v5/resource/verifier"
+
+	"github.com/pkg/errors"
+)
+
+// isTrustAnchor reports whether cert is one of the trust anchors
+func isTrustAnchor(cert *x509.Certificate, trustAnchors []*x509.Certificate) bool {
+	for _, anchor := range trustAnchors {
+		if bytes.Equal(cert.Raw, anchor.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks the signatures of the entry: its issuer chain must lead to one of the trust anchors and the
+// document must be signed by the first certificate of the chain, the Root CA CRL by a trust anchor. It keeps
+// forged collateral out of the cache and the store, quotes are verified against the same signatures whatever
+// the source of their collateral. Validity periods are left to the verification of quotes, which may accept
+// expired collateral.
+func (e *Entry) Verify(trustAnchors []*x509.Certificate) error {
+	if len(trustAnchors) == 0 {
+		return errors.New("No trust anchors given")
+	}
+
+	if e.Kind == KindRootCaCrl {
+		crl, err := e.CRL()
+		if err != nil {
+			return err
+		}
+		for _, anchor := range trustAnchors {
+			if anchor.CheckCRLSignature(crl) == nil {
+				return nil
+			}
+		}
+		return errors.New("Root CA CRL is not signed by a trust anchor")
+	}
+
+	chain, err := e.IssuerCerts()
+	if err != nil {
+		return err
+	}
+	root := chain[len(chain)-1]
+	if !isTrustAnchor(root, trustAnchors) {
+		return errors.Errorf("Issuer chain root %s is not a trust anchor", root.Subject.String())
+	}
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return errors.Wrapf(err, "Invalid signature of %s in issuer chain", chain[i].Subject.String())
+		}
+	}
+
+	switch e.Kind {
+	case KindTcbInfo, KindQeIdentity:
+		object := "tcbInfo"
+		if e.Kind == KindQeIdentity {
+			object = "enclaveIdentity"
+		}
+		if err := verifier.VerifyCollateralDocumentSignature(e.Body, object, chain[0]); err != nil {
+			return errors.Wrapf(err, "Invalid signature of %s", e.Kind)
+		}
+	case KindPckCrl:
+		crl, err := e.CRL()
+		if err != nil {
+			return err
+		}
+		if err := chain[0].CheckCRLSignature(crl); err != nil {
+			return errors.Wrap(err, "Invalid signature of PCK CRL")
+		}
+	}
+	return nil
+}
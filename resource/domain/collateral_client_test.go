@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package domain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/utils"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCollateral(t *testing.T) *models.Collateral {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Intel SGX Root CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(certDer)
+	assert.NoError(t, err)
+	crlDer, err := cert.CreateCRL(rand.Reader, key, nil, time.Now(), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	chain := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}))
+	return &models.Collateral{
+		PckCrl:                string(pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDer})),
+		PckCrlIssuerChain:     chain,
+		RootCaCrl:             base64.StdEncoding.EncodeToString(crlDer),
+		TcbInfo:               `{"tcbInfo":{}}`,
+		TcbInfoIssuerChain:    chain,
+		QeIdentity:            `{"enclaveIdentity":{}}`,
+		QeIdentityIssuerChain: chain,
+	}
+}
+
+func doBundleRequest(t *testing.T, client HttpClient, url string) (*http.Response, string) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestCollateralBundleClient(t *testing.T) {
+	collateral := testCollateral(t)
+	client, err := NewCollateralBundleClient(collateral)
+	assert.NoError(t, err)
+
+	resp, body := doBundleRequest(t, client, "https://scs/scs/sgx/certification/v1/tcb?fmspc=00906ED50000")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, collateral.TcbInfo, body)
+	certs, err := utils.GetCertObjList(resp.Header.Get("SGX-TCB-Info-Issuer-Chain"))
+	assert.NoError(t, err)
+	assert.Len(t, certs, 1)
+
+	resp, body = doBundleRequest(t, client, "https://scs/scs/sgx/certification/v1/qe/identity")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, collateral.QeIdentity, body)
+	assert.NotEmpty(t, resp.Header.Get("Sgx-Qe-Identity-Issuer-Chain"))
+
+	// PEM CRLs are served base64 DER encoded like SCS does
+	resp, body = doBundleRequest(t, client, "https://scs/scs/sgx/certification/v1/pckcrl?ca=processor")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	crlDer, err := base64.StdEncoding.DecodeString(body)
+	assert.NoError(t, err)
+	_, err = x509.ParseDERCRL(crlDer)
+	assert.NoError(t, err)
+
+	resp, body = doBundleRequest(t, client, "https://scs/scs/sgx/certification/v1/rootcacrl")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, collateral.RootCaCrl, body)
+
+	resp, _ = doBundleRequest(t, client, "https://scs/scs/sgx/certification/v1/pckcert")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCollateralBundleClientInvalidBundle(t *testing.T) {
+	_, err := NewCollateralBundleClient(nil)
+	assert.Error(t, err)
+
+	collateral := testCollateral(t)
+	collateral.QeIdentityIssuerChain = ""
+	_, err = NewCollateralBundleClient(collateral)
+	assert.Error(t, err)
+
+	collateral = testCollateral(t)
+	collateral.PckCrl = "not a crl!"
+	_, err = NewCollateralBundleClient(collateral)
+	assert.Error(t, err)
+
+	collateral = testCollateral(t)
+	collateral.RootCaCrl = ""
+	_, err = NewCollateralBundleClient(collateral)
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@
 package domain
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -20,6 +21,9 @@ type HttpClient interface {
 type (
 	SGXQuoteParser interface {
 		GetSHA256Hash() []byte
+		GetReportData() []byte
+		GetQuoteHeader() models.QuoteHeader
+		GetEnclaveReport() models.ReportBody
 		GetQeReportBlob() ([]byte, error)
 		GetHeaderAndEnclaveReportBlob() ([]byte, error)
 		GetQeReportAttributes() [models.AttributeSize]byte
@@ -50,6 +54,7 @@ type (
 		GetPckCertRequiredSgxExtMap() map[string]asn1.ObjectIdentifier
 		GenCertObj(certBlob []byte) error
 		GetFmspcValue() string
+		GetPlatformInfo() models.PlatformInfo
 		GetPckCertTcbLevels() []byte
 		ParseFMSPCValue() error
 		ParseTcbExtensions() error
@@ -58,11 +63,12 @@ type (
 		GetPckCrlObj() []*pkix.CertificateList
 		GetPckCrlInterCaList() []*x509.Certificate
 		GetPckCrlRootCaList() []*x509.Certificate
-		ParsePckCrl() error
+		ParsePckCrl(ctx context.Context) error
 	}
 
+	// SGXQuoteVerifier verifies quotes, verification is abandoned once ctx is done
 	SGXQuoteVerifier interface {
-		SgxEcdsaQuoteVerify(data models.QuoteDataWithChallenge, scsClient HttpClient, config *config.Configuration,
+		SgxEcdsaQuoteVerify(ctx context.Context, data models.QuoteDataWithChallenge, scsClient HttpClient, config *config.Configuration,
 			trustedSGXRootCAFile string) (models.SGXResponse, error)
 	}
 )
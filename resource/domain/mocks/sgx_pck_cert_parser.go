@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -12,6 +13,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/models"
 )
 
 type FakePCKCert struct {
@@ -27,6 +29,7 @@ func (fe *FakePCKCert) GetPckCertRequiredExtMap() map[string]asn1.ObjectIdentifi
 func (fe *FakePCKCert) GetPckCertRequiredSgxExtMap() map[string]asn1.ObjectIdentifier { return nil }
 func (fe *FakePCKCert) GenCertObj(certBlob []byte) error                              { return nil }
 func (fe *FakePCKCert) GetFmspcValue() string                                         { return "" }
+func (fe *FakePCKCert) GetPlatformInfo() models.PlatformInfo                          { return models.PlatformInfo{} }
 func (fe *FakePCKCert) GetPckCertTcbLevels() []byte                                   { return nil }
 func (fe *FakePCKCert) ParseFMSPCValue() error                                        { return nil }
 func (fe *FakePCKCert) ParseTcbExtensions() error                                     { return nil }
@@ -53,4 +56,4 @@ func (fe *FakePCKCert) GetPckCrlObj() []*pkix.CertificateList {
 
 func (fe *FakePCKCert) GetPckCrlInterCaList() []*x509.Certificate { return nil }
 func (fe *FakePCKCert) GetPckCrlRootCaList() []*x509.Certificate  { return nil }
-func (fe *FakePCKCert) ParsePckCrl() error                        { return nil }
+func (fe *FakePCKCert) ParsePckCrl(ctx context.Context) error     { return nil }
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	"context"
 	"fmt"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/resource/domain"
@@ -32,7 +33,7 @@ func NewFakeSGXEcdsaQuoteVerifier(statusCode int) domain.SGXQuoteVerifier {
 	}
 }
 
-func (fseqv *FakeSgxEcdsaQuoteVerifier) SgxEcdsaQuoteVerify(data models.QuoteDataWithChallenge, scsClient domain.HttpClient, config *config.Configuration,
+func (fseqv *FakeSgxEcdsaQuoteVerifier) SgxEcdsaQuoteVerify(ctx context.Context, data models.QuoteDataWithChallenge, scsClient domain.HttpClient, config *config.Configuration,
 	trustedSGXRootCAFile string) (models.SGXResponse, error) {
 
 	if trustedSGXRootCAFile == "" {
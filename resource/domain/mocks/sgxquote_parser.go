@@ -34,6 +34,15 @@ func NewMockSGXQuoteParser(rawBlob []byte) domain.SGXQuoteParser {
 func (fe *FakeSGXQuoteParsed) GetSHA256Hash() []byte {
 	return nil
 }
+func (fe *FakeSGXQuoteParsed) GetReportData() []byte {
+	return nil
+}
+func (fe *FakeSGXQuoteParsed) GetQuoteHeader() models.QuoteHeader {
+	return models.QuoteHeader{}
+}
+func (fe *FakeSGXQuoteParsed) GetEnclaveReport() models.ReportBody {
+	return models.ReportBody{}
+}
 func (fe *FakeSGXQuoteParsed) GetQeReportBlob() ([]byte, error) {
 	return nil, nil
 }
@@ -12,8 +12,29 @@ type QuoteData struct {
 type QuoteDataWithChallenge struct {
 	QuoteData
 	Challenge string `json:"challenge"`
-	//For future use
-	Nonce string `json:"nonce"`
+	// Nonce is the base64 encoded nonce bound to the report data together with the user data by the
+	// nonce-pubkey report data binding
+	Nonce      string      `json:"nonce"`
+	Collateral *Collateral `json:"collateral,omitempty"`
+	// ReportDataBinding selects how UserData is bound to the enclave report data, sha256 when empty.
+	// With StrictReportData a mismatch fails verification instead of being reported in userDataMatch.
+	ReportDataBinding string `json:"reportDataBinding,omitempty"`
+	StrictReportData  bool   `json:"strictReportData,omitempty"`
+	// VerificationTime is the RFC3339 point in time the quote and its collateral are verified at, the
+	// current time when empty
+	VerificationTime string `json:"verificationTime,omitempty"`
+}
+
+// Collateral is a quote verification collateral bundle as returned by sgx_ql_get_quote_verification_collateral.
+// Issuer chains are PEM encoded, CRLs PEM or base64 encoded DER, TCBInfo and QE identity the signed JSON documents.
+type Collateral struct {
+	PckCrl                string `json:"pckCrl"`
+	PckCrlIssuerChain     string `json:"pckCrlIssuerChain"`
+	RootCaCrl             string `json:"rootCaCrl"`
+	TcbInfo               string `json:"tcbInfo"`
+	TcbInfoIssuerChain    string `json:"tcbInfoIssuerChain"`
+	QeIdentity            string `json:"qeIdentity"`
+	QeIdentityIssuerChain string `json:"qeIdentityIssuerChain"`
 }
 
 type SGXResponse struct {
@@ -31,4 +52,88 @@ type AdditionalQuoteData struct {
 	TcbLevel            string `json:"TcbLevel,omitempty"`
 	Quote               string `json:"Quote,omitempty"`
 	Challenge           string `json:"Challenge,omitempty"`
+	CollateralSource    string `json:"CollateralSource,omitempty"`
+	VerificationTime    string `json:"VerificationTime,omitempty"`
+	// CollateralExpired reports collateral past its nextUpdate which has been accepted within the configured
+	// grace period, CollateralExpiry is the earliest expiry across all collateral
+	CollateralExpired bool   `json:"CollateralExpired,omitempty"`
+	CollateralExpiry  string `json:"CollateralExpiry,omitempty"`
+	// TcbEvaluationDataNumber and QeIdentityTcbEvaluationDataNumber identify the TCB evaluation data of the TCB
+	// info and QE identity used for verification
+	TcbEvaluationDataNumber           uint   `json:"TcbEvaluationDataNumber,omitempty"`
+	QeIdentityTcbEvaluationDataNumber uint16 `json:"QeIdentityTcbEvaluationDataNumber,omitempty"`
+	// ReplayStatus reports whether the quote has been verified before, FIRST_SEEN or REPLAYED, when replay
+	// detection is enabled
+	ReplayStatus string `json:"ReplayStatus,omitempty"`
+	// EnclaveReport and Platform describe the verified enclave and the platform it runs on
+	EnclaveReport *EnclaveReport `json:"enclaveReport,omitempty"`
+	Platform      *Platform      `json:"platform,omitempty"`
+	// PlatformPolicy is only reported for quotes with a PCK certificate issued by the PCK Platform CA
+	PlatformPolicy *PlatformPolicy `json:"platformPolicy,omitempty"`
+	// not part of the response, used to record the verification decision
+	Fmspc      string          `json:"-"`
+	Collateral *CollateralInfo `json:"-"`
+}
+
+// EnclaveReport is the report of the enclave which generated a verified quote. Byte arrays are hex encoded,
+// the KSS fields IsvExtProdID, ConfigID, ConfigSvn and IsvFamilyID are zero for enclaves not using KSS.
+type EnclaveReport struct {
+	CPUSvn       string            `json:"cpuSvn"`
+	MiscSelect   uint32            `json:"miscSelect"`
+	Attributes   EnclaveAttributes `json:"attributes"`
+	MrEnclave    string            `json:"mrEnclave"`
+	MrSigner     string            `json:"mrSigner"`
+	IsvProdID    uint16            `json:"isvProdId"`
+	IsvSvn       uint16            `json:"isvSvn"`
+	IsvExtProdID string            `json:"isvExtProdId"`
+	IsvFamilyID  string            `json:"isvFamilyId"`
+	ConfigID     string            `json:"configId"`
+	ConfigSvn    uint16            `json:"configSvn"`
+	ReportData   string            `json:"reportData"`
+}
+
+// EnclaveAttributes are the ATTRIBUTES of an enclave report, Flags and Xfrm as raw values along with the
+// decoded flag bits
+type EnclaveAttributes struct {
+	Flags         uint64 `json:"flags"`
+	Xfrm          uint64 `json:"xfrm"`
+	Init          bool   `json:"init"`
+	Debug         bool   `json:"debug"`
+	Mode64Bit     bool   `json:"mode64Bit"`
+	ProvisionKey  bool   `json:"provisionKey"`
+	EinitTokenKey bool   `json:"einitTokenKey"`
+	Kss           bool   `json:"kss"`
+}
+
+// Platform identifies the platform and the quoting enclave TCB a quote was generated on. PlatformInstanceID and
+// Configuration are only reported for platforms with a PCK certificate issued by the PCK Platform CA, the PPID
+// only when the configuration includes it.
+type Platform struct {
+	Fmspc              string                 `json:"fmspc"`
+	PceID              string                 `json:"pceId"`
+	QeSvn              uint16                 `json:"qeSvn"`
+	PceSvn             uint16                 `json:"pceSvn"`
+	PPID               string                 `json:"ppid,omitempty"`
+	SgxType            string                 `json:"sgxType"`
+	PlatformInstanceID string                 `json:"platformInstanceId,omitempty"`
+	Configuration      *PlatformConfiguration `json:"configuration,omitempty"`
+}
+
+// PlatformPolicy reports the platform configuration flags evaluated against the platform policy and whether
+// the policy allows them
+type PlatformPolicy struct {
+	SmtEnabled             bool `json:"smtEnabled"`
+	SmtEnabledAllowed      bool `json:"smtEnabledAllowed"`
+	DynamicPlatform        bool `json:"dynamicPlatform"`
+	DynamicPlatformAllowed bool `json:"dynamicPlatformAllowed"`
+}
+
+// CollateralInfo identifies the versions of the collateral used to verify a quote
+type CollateralInfo struct {
+	TcbInfoVersion             int
+	TcbInfoIssueDate           string
+	TcbEvaluationDataNumber    uint
+	QeIdentityVersion          uint16
+	QeIdentityIssueDate        string
+	QeIdentityEvaluationNumber uint16
 }
@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package models
+
+const (
+	PPIDSize               = 16
+	PceIDSize              = 2
+	FmspcSize              = 6
+	PlatformInstanceIDSize = 16
+)
+
+// SGX Type values of the PCK certificate SGX extension
+const (
+	SgxTypeStandard              = "Standard"
+	SgxTypeScalable              = "Scalable"
+	SgxTypeScalableWithIntegrity = "ScalableWithIntegrity"
+)
+
+// PlatformInfo is the platform identity carried in the SGX extension of a PCK certificate. Byte values are hex
+// encoded. PlatformInstanceID and Configuration are only present in certificates issued by the PCK Platform CA.
+type PlatformInfo struct {
+	PPID               string
+	PceID              string
+	Fmspc              string
+	SgxType            string
+	PlatformInstanceID string
+	Configuration      *PlatformConfiguration
+}
+
+// PlatformConfiguration holds the platform configuration flags of the SGX extension, flags missing from the
+// extension are reported as false
+type PlatformConfiguration struct {
+	DynamicPlatform bool `json:"dynamicPlatform"`
+	CachedKeys      bool `json:"cachedKeys"`
+	SmtEnabled      bool `json:"smtEnabled"`
+}
@@ -5,10 +5,13 @@
 package models
 
 const (
-	ReportReserved1Bytes     = 28
+	ReportReserved1Bytes     = 12
 	ReportReserved2Bytes     = 32
-	ReportReserved3Bytes     = 96
-	ReportReserved4Bytes     = 60
+	ReportReserved3Bytes     = 32
+	ReportReserved4Bytes     = 42
+	IsvExtProdIDSize         = 16
+	ConfigIDSize             = 64
+	IsvFamilyIDSize          = 16
 	EnclaveReportLength      = 384
 	AttributeSize            = 16
 	UserDataSize             = 20
@@ -20,6 +23,16 @@ const (
 	Ecdsa256BitPubkeySize    = 64
 )
 
+// Enclave ATTRIBUTES flag bits
+const (
+	SgxFlagsInitted       = 0x01
+	SgxFlagsDebug         = 0x02
+	SgxFlagsMode64Bit     = 0x04
+	SgxFlagsProvisionKey  = 0x10
+	SgxFlagsEinitTokenKey = 0x20
+	SgxFlagsKss           = 0x80
+)
+
 // Ecdsa Quote Header
 type QuoteHeader struct {
 	Version            uint16               /* (0) Version of Quote data structure */
@@ -37,14 +50,18 @@ type ReportBody struct {
 	CPUSvn        [CPUsvnSize]byte           /* (0) Security Version of the CPU */
 	MiscSelect    uint32                     /* (16) Which fields defined in SSA.MISC */
 	Reserved1     [ReportReserved1Bytes]byte /* (20) */
+	IsvExtProdID  [IsvExtProdIDSize]byte     /* (32) Extended Product ID of the Enclave, KSS only */
 	SgxAttributes [AttributeSize]byte        /* (48) Any special Capabilities the Enclave possess */
 	MrEnclave     [HashSize]byte             /* (64) The value of the enclave's ENCLAVE measurement */
 	Reserved2     [ReportReserved2Bytes]byte /* (96) */
 	MrSigner      [HashSize]byte             /* (128) The value of the enclave's SIGNER measurement */
 	Reserved3     [ReportReserved3Bytes]byte /* (160) */
+	ConfigID      [ConfigIDSize]byte         /* (192) Configuration ID of the Enclave, KSS only */
 	SgxIsvProdID  uint16                     /* (256) Product ID of the Enclave */
 	SgxIsvSvn     uint16                     /* (258) Security Version of the Enclave */
-	Reserved4     [ReportReserved4Bytes]byte /* (260) */
+	ConfigSvn     uint16                     /* (260) Configuration Security Version of the Enclave, KSS only */
+	Reserved4     [ReportReserved4Bytes]byte /* (262) */
+	IsvFamilyID   [IsvFamilyIDSize]byte      /* (304) Family ID of the Enclave, KSS only */
 	ReportData    [ReportDataSize]byte       /* (320) Data provided by the user */
 }
 
@@ -7,18 +7,240 @@ package domain
 import (
 	"intel/isecl/lib/clients/v5"
 	clog "intel/isecl/lib/common/v5/log"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 var log = clog.GetDefaultLogger()
 
-func NewSCSClient(trustedCAsStoreDir string) HttpClient {
+// ErrCircuitOpen is returned without contacting SCS while the circuit breaker is open
+var ErrCircuitOpen = errors.New("SCS circuit breaker is open, SGX Caching Service is unavailable")
+
+// NewSCSClient returns a client for the SGX Caching Service trusting the CA certificates in trustedCAsStoreDir.
+// Requests are subject to the timeouts, retries and circuit breaker configured in conf.
+func NewSCSClient(trustedCAsStoreDir string, conf config.SCSClientConfig) (HttpClient, error) {
 	log.Trace("domain/scs_client.go:NewSCSClient() Entering")
 	defer log.Trace("resource/scs_client.go:NewSCSClient() Leaving")
 
 	client, err := clients.HTTPClientWithCADir(trustedCAsStoreDir)
 	if err != nil {
-		log.Errorf("domain/scs_client.go:NewSCSClient() Error in getting client object %v", err)
+		return nil, errors.Wrap(err, "domain/scs_client.go:NewSCSClient() Error in getting client object")
+	}
+	conf = scsClientDefaults(conf)
+	client.Timeout = conf.Timeout
+	return NewResilientClient(client, conf), nil
+}
+
+func scsClientDefaults(conf config.SCSClientConfig) config.SCSClientConfig {
+	if conf.Timeout <= 0 {
+		conf.Timeout = constants.DefaultSCSTimeout
+	}
+	if conf.MaxRetries == 0 {
+		conf.MaxRetries = constants.DefaultSCSMaxRetries
+	} else if conf.MaxRetries < 0 {
+		conf.MaxRetries = 0
+	}
+	if conf.RetryBackoff <= 0 {
+		conf.RetryBackoff = constants.DefaultSCSRetryBackoff
+	}
+	if conf.MaxRetryBackoff <= 0 {
+		conf.MaxRetryBackoff = constants.DefaultSCSMaxRetryBackoff
+	}
+	if conf.BreakerThreshold <= 0 {
+		conf.BreakerThreshold = constants.DefaultSCSBreakerThreshold
+	}
+	if conf.BreakerCooldown <= 0 {
+		conf.BreakerCooldown = constants.DefaultSCSBreakerCooldown
+	}
+	return conf
+}
+
+// ResilientClient wraps a HttpClient with bounded retries using jittered exponential backoff for network
+// errors and 5xx responses, and a circuit breaker failing fast once the server keeps failing.
+type ResilientClient struct {
+	client  HttpClient
+	conf    config.SCSClientConfig
+	breaker *circuitBreaker
+	sleep   func(req *http.Request, d time.Duration) error
+}
+
+// NewResilientClient wraps client, zero values in conf select the defaults
+func NewResilientClient(client HttpClient, conf config.SCSClientConfig) *ResilientClient {
+	conf = scsClientDefaults(conf)
+	return &ResilientClient{
+		client:  client,
+		conf:    conf,
+		breaker: newCircuitBreaker(conf.BreakerThreshold, conf.BreakerCooldown),
+		sleep:   sleepWithContext,
+	}
+}
+
+func sleepWithContext(req *http.Request, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
 		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// backoff returns the delay before the given retry, picked at random from the upper half of the
+// exponentially growing backoff window
+func (rc *ResilientClient) backoff(retry int) time.Duration {
+	d := rc.conf.RetryBackoff << uint(retry)
+	if d <= 0 || d > rc.conf.MaxRetryBackoff {
+		d = rc.conf.MaxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryable reports whether a request failed on the side of SCS
+func retryable(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// abandoned reports whether a request failed because its context is done, which says nothing about SCS.
+// Abandoned requests are neither retried nor counted by the circuit breaker.
+func abandoned(req *http.Request, err error) bool {
+	return err != nil && req.Context().Err() != nil
+}
+
+// drain discards the body of a response which is not handed to the caller so the connection can be reused
+func drain(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func (rc *ResilientClient) Do(req *http.Request) (*http.Response, error) {
+	if !rc.breaker.allow() {
+		log.Warnf("domain/scs_client.go:Do() %s, not sending request to %s", ErrCircuitOpen.Error(), req.URL.Path)
+		return nil, ErrCircuitOpen
+	}
+
+	// requests with a body can only be retried when the body can be recreated
+	maxRetries := rc.conf.MaxRetries
+	if req.Body != nil && req.GetBody == nil {
+		maxRetries = 0
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				err = errors.Wrap(berr, "domain/scs_client.go:Do() Failed to recreate request body")
+				break
+			}
+			req.Body = body
+		}
+
+		resp, err = rc.client.Do(req)
+		if !retryable(resp, err) || abandoned(req, err) || attempt >= maxRetries {
+			break
+		}
+
+		if err != nil {
+			log.WithError(err).Warnf("domain/scs_client.go:Do() Request to %s failed, attempt %d of %d",
+				req.URL.Path, attempt+1, maxRetries+1)
+		} else {
+			log.Warnf("domain/scs_client.go:Do() Request to %s failed with status %d, attempt %d of %d",
+				req.URL.Path, resp.StatusCode, attempt+1, maxRetries+1)
+		}
+		drain(resp)
+		if serr := rc.sleep(req, rc.backoff(attempt)); serr != nil {
+			resp, err = nil, errors.Wrap(serr, "domain/scs_client.go:Do() Request cancelled while waiting to retry")
+			break
+		}
+	}
+
+	switch {
+	case abandoned(req, err):
+		rc.breaker.abandoned()
+	case retryable(resp, err):
+		rc.breaker.failure()
+	default:
+		rc.breaker.success()
+	}
+	return resp, err
+}
+
+// circuitBreaker opens after threshold consecutive failures. Once cooldown has passed a single trial
+// request is let through, closing the breaker on success and reopening it on failure.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures < cb.threshold {
+		return true
+	}
+	if cb.trial || cb.now().Sub(cb.openedAt) < cb.cooldown {
+		return false
+	}
+	cb.trial = true
+	return true
+}
+
+func (cb *circuitBreaker) success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.failures >= cb.threshold {
+		log.Info("domain/scs_client.go: SCS circuit breaker closed")
+	}
+	cb.failures = 0
+	cb.trial = false
+}
+
+// abandoned lets another trial request through when the trial request was abandoned by its caller
+func (cb *circuitBreaker) abandoned() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+}
+
+func (cb *circuitBreaker) failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		if !cb.trial {
+			log.Errorf("domain/scs_client.go: SCS circuit breaker opened after %d consecutive failures", cb.failures)
+		}
+		cb.openedAt = cb.now()
+		cb.trial = false
 	}
-	return client
 }
@@ -0,0 +1,172 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package domain
+
+import (
+	"context"
+	"errors"
+	"intel/isecl/sqvs/v5/config"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClient struct {
+	calls     int
+	responses []int
+}
+
+// Do replies with the next configured status code, 0 simulates a network error
+func (fc *fakeClient) Do(req *http.Request) (*http.Response, error) {
+	code := fc.responses[len(fc.responses)-1]
+	if fc.calls < len(fc.responses) {
+		code = fc.responses[fc.calls]
+	}
+	fc.calls++
+	if code == 0 {
+		return nil, errors.New("connection refused")
+	}
+	return &http.Response{StatusCode: code, Body: ioutil.NopCloser(strings.NewReader("body"))}, nil
+}
+
+func newTestClient(fc *fakeClient, conf config.SCSClientConfig) *ResilientClient {
+	rc := NewResilientClient(fc, conf)
+	rc.sleep = func(req *http.Request, d time.Duration) error { return nil }
+	return rc
+}
+
+func newTestRequest(t *testing.T) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, "https://scs/tcb", nil)
+	assert.NoError(t, err)
+	return req
+}
+
+func TestResilientClientRetries(t *testing.T) {
+	fc := &fakeClient{responses: []int{0, http.StatusServiceUnavailable, http.StatusOK}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: 2})
+
+	resp, err := rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, fc.calls)
+}
+
+func TestResilientClientNoRetryOnClientError(t *testing.T) {
+	fc := &fakeClient{responses: []int{http.StatusNotFound}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: 2})
+
+	resp, err := rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, 1, fc.calls)
+}
+
+func TestResilientClientRetriesDisabled(t *testing.T) {
+	fc := &fakeClient{responses: []int{0}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: -1})
+
+	_, err := rc.Do(newTestRequest(t))
+	assert.Error(t, err)
+	assert.Equal(t, 1, fc.calls)
+}
+
+func TestResilientClientCircuitBreaker(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := &fakeClient{responses: []int{http.StatusInternalServerError}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: -1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+	rc.breaker.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		resp, err := rc.Do(newTestRequest(t))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	}
+
+	// breaker is open, SCS is not contacted
+	_, err := rc.Do(newTestRequest(t))
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, 2, fc.calls)
+
+	// failed trial request reopens the breaker
+	now = now.Add(time.Minute)
+	_, err = rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	_, err = rc.Do(newTestRequest(t))
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, 3, fc.calls)
+
+	// successful trial request closes the breaker
+	now = now.Add(time.Minute)
+	fc.responses = []int{http.StatusOK}
+	fc.calls = 0
+	_, err = rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	_, err = rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fc.calls)
+}
+
+func TestResilientClientCanceledRequest(t *testing.T) {
+	fc := &fakeClient{responses: []int{0}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: 2, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+
+	// requests failing because the caller gave up are neither retried nor counted by the circuit breaker
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := rc.Do(newTestRequest(t).WithContext(ctx))
+	assert.Error(t, err)
+	assert.Equal(t, 1, fc.calls)
+
+	fc.responses = []int{http.StatusOK}
+	_, err = rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fc.calls)
+}
+
+func TestResilientClientCanceledTrialRequest(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fc := &fakeClient{responses: []int{0}}
+	rc := newTestClient(fc, config.SCSClientConfig{MaxRetries: -1, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+	rc.breaker.now = func() time.Time { return now }
+
+	_, err := rc.Do(newTestRequest(t))
+	assert.Error(t, err)
+	_, err = rc.Do(newTestRequest(t))
+	assert.Equal(t, ErrCircuitOpen, err)
+
+	// an abandoned trial request lets the next request through as trial
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rc.Do(newTestRequest(t).WithContext(ctx))
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrCircuitOpen, err)
+
+	fc.responses = []int{http.StatusOK}
+	_, err = rc.Do(newTestRequest(t))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fc.calls)
+}
+
+func TestResilientClientBackoff(t *testing.T) {
+	rc := NewResilientClient(&fakeClient{}, config.SCSClientConfig{RetryBackoff: time.Second, MaxRetryBackoff: 3 * time.Second})
+	for retry := 0; retry < 5; retry++ {
+		d := rc.backoff(retry)
+		assert.True(t, d >= 500*time.Millisecond)
+		assert.True(t, d <= 3*time.Second)
+	}
+}
+
+func TestNewSCSClient(t *testing.T) {
+	client, err := NewSCSClient("", config.SCSClientConfig{Timeout: 5 * time.Second})
+	assert.NoError(t, err)
+	rc, ok := client.(*ResilientClient)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, rc.client.(*http.Client).Timeout)
+}
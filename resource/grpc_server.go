@@ -0,0 +1,320 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"bytes"
+	"context"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/grpcapi"
+	"intel/isecl/sqvs/v5/version"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCQuoteVerifier serves the QuoteVerifier gRPC API. Each call is passed through the middlewares of the
+// REST API for the called endpoint, so that token and client certificate authentication, tenant restrictions
+// and throttling apply unchanged, and is verified by the v2 quote verifier.
+type GRPCQuoteVerifier struct {
+	grpcapi.UnimplementedQuoteVerifierServer
+	config        *config.Configuration
+	quoteVerifier *SgxQuoteVerifierCBAndSign
+	middlewares   func(endpoint string) []mux.MiddlewareFunc
+	readiness     *Readiness
+}
+
+func NewGRPCQuoteVerifier(conf *config.Configuration, scsClient domain.HttpClient, trustedSGXRootCAFile string,
+	sgxQuoteVerifier domain.SGXQuoteVerifier, privateKeyLocation, publicKeyLocation string,
+	middlewares func(endpoint string) []mux.MiddlewareFunc, readiness *Readiness) *GRPCQuoteVerifier {
+	return &GRPCQuoteVerifier{
+		config: conf,
+		quoteVerifier: NewSGXQuoteVerifierCBAndSign(conf, scsClient, trustedSGXRootCAFile, sgxQuoteVerifier,
+			privateKeyLocation, publicKeyLocation),
+		middlewares: middlewares,
+		readiness:   readiness,
+	}
+}
+
+// grpcResponseRecorder captures the response written by a middleware rejecting a gRPC call
+type grpcResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *grpcResponseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *grpcResponseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(data)
+}
+
+func (rec *grpcResponseRecorder) WriteHeader(statusCode int) {
+	if rec.status == 0 {
+		rec.status = statusCode
+	}
+}
+
+// newGRPCRequest returns an HTTP request carrying the credentials of the gRPC call, the bearer token from the
+// authorization metadata and the TLS state of the connection
+func newGRPCRequest(ctx context.Context) (*http.Request, error) {
+	method, _ := grpc.Method(ctx)
+	r, err := http.NewRequest(http.MethodPost, method, nil)
+	if err != nil {
+		return nil, err
+	}
+	r = r.WithContext(ctx)
+	r.RequestURI = method
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, value := range md.Get("authorization") {
+			r.Header.Add("Authorization", value)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &tlsInfo.State
+		}
+	}
+	return r, nil
+}
+
+// grpcStatusFromHTTP converts an HTTP error response to a gRPC status error
+func grpcStatusFromHTTP(statusCode int, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	code := codes.Unknown
+	switch {
+	case statusCode == http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case statusCode == http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case statusCode == http.StatusForbidden:
+		code = codes.PermissionDenied
+	case statusCode == http.StatusNotFound:
+		code = codes.NotFound
+	case statusCode == http.StatusConflict:
+		code = codes.AlreadyExists
+	case statusCode == http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case statusCode == http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	case statusCode == http.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case statusCode >= http.StatusInternalServerError:
+		code = codes.Internal
+	}
+	return status.Error(code, message)
+}
+
+// grpcStatusFromError converts the errors returned by the quote verifier to gRPC status errors
+func grpcStatusFromError(err error) error {
+	switch t := err.(type) {
+	case *resourceError:
+		return grpcStatusFromHTTP(t.StatusCode, t.Message)
+	case resourceError:
+		return grpcStatusFromHTTP(t.StatusCode, t.Message)
+	case *privilegeError:
+		return grpcStatusFromHTTP(t.StatusCode, t.Message)
+	case privilegeError:
+		return grpcStatusFromHTTP(t.StatusCode, t.Message)
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// serve runs fn once the middlewares of the endpoint and the role check of the REST API have accepted the
+// gRPC call. Errors are returned as gRPC status errors.
+func (s *GRPCQuoteVerifier) serve(ctx context.Context, endpoint string, fn func(r *http.Request) error) error {
+	r, err := newGRPCRequest(ctx)
+	if err != nil {
+		log.WithError(err).Error("resource/grpc_server:serve() Could not create request")
+		return status.Error(codes.Internal, "Could not create request")
+	}
+
+	served := false
+	var serveErr error
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+		if s.config.AuthorizationEnabled() {
+			serveErr = AuthorizeEndpoint(r, constants.QuoteVerifierGroupName, true)
+			if serveErr != nil {
+				slog.WithError(serveErr).Error("resource/grpc_server:serve() Authorization Error")
+				noteRejection(r, serveErr)
+				return
+			}
+		}
+		serveErr = fn(r)
+		if serveErr != nil {
+			noteRejection(r, serveErr)
+		}
+	})
+	if s.middlewares != nil {
+		middlewares := s.middlewares(endpoint)
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			handler = middlewares[i](handler)
+		}
+	}
+
+	rec := &grpcResponseRecorder{header: make(http.Header)}
+	handler.ServeHTTP(rec, r)
+	if !served {
+		slog.Errorf("resource/grpc_server:serve() %s call rejected with status %d", r.RequestURI, rec.status)
+		return grpcStatusFromHTTP(rec.status, rec.body.String())
+	}
+	if serveErr != nil {
+		return grpcStatusFromError(serveErr)
+	}
+	return nil
+}
+
+func quoteDataFromRequest(req *grpcapi.VerifyQuoteRequest) models.QuoteDataWithChallenge {
+	data := models.QuoteDataWithChallenge{
+		QuoteData: models.QuoteData{
+			QuoteBlob: req.GetQuote(),
+			UserData:  req.GetUserData(),
+		},
+		Challenge:         req.GetChallenge(),
+		Nonce:             req.GetNonce(),
+		ReportDataBinding: req.GetReportDataBinding(),
+		StrictReportData:  req.GetStrictReportData(),
+		VerificationTime:  req.GetVerificationTime(),
+	}
+	if collateral := req.GetCollateral(); collateral != nil {
+		data.Collateral = &models.Collateral{
+			PckCrl:                collateral.GetPckCrl(),
+			PckCrlIssuerChain:     collateral.GetPckCrlIssuerChain(),
+			RootCaCrl:             collateral.GetRootCaCrl(),
+			TcbInfo:               collateral.GetTcbInfo(),
+			TcbInfoIssuerChain:    collateral.GetTcbInfoIssuerChain(),
+			QeIdentity:            collateral.GetQeIdentity(),
+			QeIdentityIssuerChain: collateral.GetQeIdentityIssuerChain(),
+		}
+	}
+	return data
+}
+
+func newVerifyQuoteResponse(resp v2QuoteResponse) *grpcapi.VerifyQuoteResponse {
+	return &grpcapi.VerifyQuoteResponse{
+		QuoteData:        resp.QuoteData,
+		Signature:        resp.Signature,
+		CertificateChain: resp.CertificateChain,
+	}
+}
+
+func (s *GRPCQuoteVerifier) VerifyQuote(ctx context.Context, req *grpcapi.VerifyQuoteRequest) (
+	*grpcapi.VerifyQuoteResponse, error) {
+	log.Trace("resource/grpc_server:VerifyQuote() Entering")
+	defer log.Trace("resource/grpc_server:VerifyQuote() Leaving")
+
+	var resp *grpcapi.VerifyQuoteResponse
+	err := s.serve(ctx, constants.EndpointV2, func(r *http.Request) error {
+		// checked once the call is authorized, like the request body by the REST API
+		if req.GetQuote() == "" {
+			return &resourceError{Message: "SGX_QL_ERROR_INVALID_PARAMETER", StatusCode: http.StatusBadRequest}
+		}
+		v2Resp, err := s.quoteVerifier.verifyQuoteAndSign(r, constants.EndpointV2, quoteDataFromRequest(req))
+		if err != nil {
+			return err
+		}
+		resp = newVerifyQuoteResponse(v2Resp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *GRPCQuoteVerifier) BatchVerifyQuotes(ctx context.Context, req *grpcapi.BatchVerifyQuotesRequest) (
+	*grpcapi.BatchVerifyQuotesResponse, error) {
+	log.Trace("resource/grpc_server:BatchVerifyQuotes() Entering")
+	defer log.Trace("resource/grpc_server:BatchVerifyQuotes() Leaving")
+
+	quoteReqs := req.GetRequests()
+	if len(quoteReqs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No quotes provided")
+	}
+	if len(quoteReqs) > constants.MaxBatchVerifyQuotes {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d quotes can be verified in a batch",
+			constants.MaxBatchVerifyQuotes)
+	}
+
+	// each quote is served on its own, so that tenant rate limits, daily quotas and throttling are charged
+	// per quote rather than per batch
+	resp := &grpcapi.BatchVerifyQuotesResponse{}
+	for _, quoteReq := range quoteReqs {
+		if err := ctx.Err(); err != nil {
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+		result := &grpcapi.BatchVerifyQuoteResult{Code: int32(codes.OK)}
+		resp.Results = append(resp.Results, result)
+		if quoteReq.GetQuote() == "" {
+			result.Code = int32(codes.InvalidArgument)
+			result.Message = "SGX_QL_ERROR_INVALID_PARAMETER"
+			continue
+		}
+
+		var verifyErr error
+		err := s.serve(ctx, constants.EndpointBatch, func(r *http.Request) error {
+			v2Resp, err := s.quoteVerifier.verifyQuoteAndSign(r, constants.EndpointBatch, quoteDataFromRequest(quoteReq))
+			if err != nil {
+				// the error fails this quote only, the call is served on
+				noteRejection(r, err)
+				verifyErr = grpcStatusFromError(err)
+				return nil
+			}
+			result.Response = newVerifyQuoteResponse(v2Resp)
+			return nil
+		})
+		if err == nil {
+			err = verifyErr
+		} else if status.Code(err) != codes.ResourceExhausted {
+			// the caller is not authorized, which applies to the whole batch
+			return nil, err
+		}
+		if err != nil {
+			st := status.Convert(err)
+			result.Code = int32(st.Code())
+			result.Message = st.Message()
+		}
+	}
+	return resp, nil
+}
+
+// Health is not authorized, like the REST version endpoint. It reports NOT_SERVING once the service is
+// draining.
+func (s *GRPCQuoteVerifier) Health(ctx context.Context, req *grpcapi.HealthRequest) (*grpcapi.HealthResponse, error) {
+	healthStatus := grpcapi.HealthResponse_SERVING
+	if !s.readiness.Ready() {
+		healthStatus = grpcapi.HealthResponse_NOT_SERVING
+	}
+	return &grpcapi.HealthResponse{
+		Status:  healthStatus,
+		Version: version.GetVersion(),
+	}, nil
+}
@@ -0,0 +1,372 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	ctx "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/mocks"
+	"intel/isecl/sqvs/v5/resource/grpcapi"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCTestCert returns a certificate for the given common name issued by parent, or a self-signed CA
+// certificate when parent is nil
+func newGRPCTestCert(commonName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate,
+	*ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{commonName},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(certDer)
+	Expect(err).NotTo(HaveOccurred())
+	return cert, key
+}
+
+var _ = Describe("GRPCQuoteVerifier", func() {
+	scsClient := mocks.NewClientMock(http.StatusOK)
+	testConfig := config.Load(testConfigFilePath)
+
+	var endpoints []string
+	var authHeader string
+
+	// withRoles stands in for the authentication middleware and grants the given roles
+	withRoles := func(roles ...string) func(endpoint string) []mux.MiddlewareFunc {
+		return func(endpoint string) []mux.MiddlewareFunc {
+			endpoints = append(endpoints, endpoint)
+			return []mux.MiddlewareFunc{func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					authHeader = r.Header.Get("Authorization")
+					var roleInfo []aas.RoleInfo
+					for _, role := range roles {
+						roleInfo = append(roleInfo, aas.RoleInfo{Service: constants.ServiceName, Name: role})
+					}
+					next.ServeHTTP(w, context.SetUserRoles(r, roleInfo))
+				})
+			}}
+		}
+	}
+
+	rejectAll := func(endpoint string) []mux.MiddlewareFunc {
+		return []mux.MiddlewareFunc{func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+			})
+		}}
+	}
+
+	newContext := func() ctx.Context {
+		c := metadata.NewIncomingContext(ctx.Background(), metadata.Pairs("authorization", "Bearer test-token"))
+		return peer.NewContext(c, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}})
+	}
+
+	BeforeEach(func() {
+		endpoints = nil
+		authHeader = ""
+	})
+
+	It("Should verify a quote with the authorization metadata of the call", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, withRoles(constants.QuoteVerifierGroupName), nil)
+		resp, err := server.VerifyQuote(newContext(), &grpcapi.VerifyQuoteRequest{Quote: "quote"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal([]string{constants.EndpointV2}))
+		Expect(authHeader).To(Equal("Bearer test-token"))
+
+		var quoteData map[string]interface{}
+		Expect(json.Unmarshal(resp.QuoteData, &quoteData)).To(Succeed())
+		Expect(quoteData["Message"]).To(Equal("SGX_QL_QV_RESULT_OK"))
+	})
+
+	It("Should return InvalidArgument for an empty quote", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, withRoles(constants.QuoteVerifierGroupName), nil)
+		_, err := server.VerifyQuote(newContext(), &grpcapi.VerifyQuoteRequest{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+
+	It("Should return Unauthenticated when the authentication middleware rejects the call", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, rejectAll, nil)
+		_, err := server.VerifyQuote(newContext(), &grpcapi.VerifyQuoteRequest{Quote: "quote"})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+		Expect(status.Convert(err).Message()).To(Equal("Invalid token"))
+	})
+
+	It("Should return PermissionDenied without the quote verifier role", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, withRoles("Other"), nil)
+		_, err := server.VerifyQuote(newContext(), &grpcapi.VerifyQuoteRequest{Quote: "quote"})
+		Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+	})
+
+	It("Should report the outcome of each quote of a batch", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, withRoles(constants.QuoteVerifierGroupName), nil)
+		resp, err := server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{
+			Requests: []*grpcapi.VerifyQuoteRequest{{Quote: "quote"}, {}, {Quote: "quote"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal([]string{constants.EndpointBatch, constants.EndpointBatch}))
+		Expect(resp.Results).To(HaveLen(3))
+		Expect(resp.Results[0].Code).To(Equal(int32(codes.OK)))
+		Expect(resp.Results[0].Response).NotTo(BeNil())
+		Expect(resp.Results[1].Code).To(Equal(int32(codes.InvalidArgument)))
+		Expect(resp.Results[1].Response).To(BeNil())
+		Expect(resp.Results[2].Code).To(Equal(int32(codes.OK)))
+	})
+
+	It("Should charge tenant quotas and rate limits per quote of a batch", func() {
+		tenantAuthorizer := NewTenantAuthorizer(map[string]config.TenantConfig{
+			"team-a": {DailyQuota: 2},
+		})
+		withTenant := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName,
+					Context: "type=SQVS,tenant=team-a"}}
+				next.ServeHTTP(w, context.SetUserRoles(r, roleInfo))
+			})
+		}
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, func(endpoint string) []mux.MiddlewareFunc {
+				return []mux.MiddlewareFunc{withTenant, tenantAuthorizer.Middleware(endpoint)}
+			}, nil)
+		requests := []*grpcapi.VerifyQuoteRequest{{Quote: "quote"}, {Quote: "quote"}, {Quote: "quote"}}
+
+		resp, err := server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{Requests: requests})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Results).To(HaveLen(3))
+		Expect(resp.Results[0].Code).To(Equal(int32(codes.OK)))
+		Expect(resp.Results[1].Code).To(Equal(int32(codes.OK)))
+		Expect(resp.Results[2].Code).To(Equal(int32(codes.ResourceExhausted)))
+		Expect(resp.Results[2].Message).To(Equal("Tenant daily quota exhausted"))
+
+		throttler := NewThrottler(config.RateLimitConfig{ClientRate: 0.001, ClientBurst: 2})
+		server = NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, func(endpoint string) []mux.MiddlewareFunc {
+				return []mux.MiddlewareFunc{withTenant, throttler.Middleware()}
+			}, nil)
+
+		resp, err = server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{Requests: requests})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Results[1].Code).To(Equal(int32(codes.OK)))
+		Expect(resp.Results[2].Code).To(Equal(int32(codes.ResourceExhausted)))
+		Expect(resp.Results[2].Message).To(Equal("Client rate limit exceeded"))
+	})
+
+	It("Should return Unauthenticated for a batch when the authentication middleware rejects the call", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, rejectAll, nil)
+		_, err := server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{
+			Requests: []*grpcapi.VerifyQuoteRequest{{Quote: "quote"}, {Quote: "quote"}},
+		})
+		Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+	})
+
+	It("Should reject empty and oversized batches", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, withRoles(constants.QuoteVerifierGroupName), nil)
+		_, err := server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		requests := make([]*grpcapi.VerifyQuoteRequest, constants.MaxBatchVerifyQuotes+1)
+		for i := range requests {
+			requests[i] = &grpcapi.VerifyQuoteRequest{Quote: "quote"}
+		}
+		_, err = server.BatchVerifyQuotes(newContext(), &grpcapi.BatchVerifyQuotesRequest{Requests: requests})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		Expect(endpoints).To(BeEmpty())
+	})
+
+	It("Should report the health without authorization", func() {
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, rejectAll, nil)
+		resp, err := server.Health(ctx.Background(), &grpcapi.HealthRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Status).To(Equal(grpcapi.HealthResponse_SERVING))
+	})
+
+	It("Should report not serving while draining", func() {
+		readiness := NewReadiness()
+		readiness.SetReady(true)
+		server := NewGRPCQuoteVerifier(testConfig, scsClient, trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200),
+			privateKeyLocation, pubKeyLocation, rejectAll, readiness)
+		resp, err := server.Health(ctx.Background(), &grpcapi.HealthRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Status).To(Equal(grpcapi.HealthResponse_SERVING))
+
+		readiness.SetReady(false)
+		resp, err = server.Health(ctx.Background(), &grpcapi.HealthRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Status).To(Equal(grpcapi.HealthResponse_NOT_SERVING))
+	})
+
+	It("Should map HTTP status codes to gRPC codes", func() {
+		Expect(status.Code(grpcStatusFromHTTP(http.StatusBadRequest, ""))).To(Equal(codes.InvalidArgument))
+		Expect(status.Code(grpcStatusFromHTTP(http.StatusTooManyRequests, ""))).To(Equal(codes.ResourceExhausted))
+		Expect(status.Code(grpcStatusFromHTTP(http.StatusBadGateway, ""))).To(Equal(codes.Internal))
+		Expect(status.Code(grpcStatusFromError(&resourceError{Message: "Invalid quote", StatusCode: http.StatusBadRequest}))).
+			To(Equal(codes.InvalidArgument))
+		Expect(status.Code(grpcStatusFromError(&privilegeError{Message: "Denied", StatusCode: http.StatusForbidden}))).
+			To(Equal(codes.PermissionDenied))
+	})
+
+	Describe("Round trip", func() {
+		var caCert *x509.Certificate
+		var caKey *ecdsa.PrivateKey
+		var listener *bufconn.Listener
+		var grpcServer *grpc.Server
+
+		// the middlewares of the REST API with client certificate authentication, as installed by the app
+		mappings := []config.ClientCertRoleMapping{
+			{Subject: "team-a-client", Roles: []string{constants.QuoteVerifierGroupName}, Context: "type=SQVS,tenant=team-a"},
+			{Subject: "team-b-client", Roles: []string{constants.QuoteVerifierGroupName}, Context: "type=SQVS,tenant=team-b"},
+			{Subject: "other-client", Roles: []string{"Other"}},
+		}
+		tenantAuthorizer := NewTenantAuthorizer(map[string]config.TenantConfig{
+			"team-a": {Endpoints: []string{constants.EndpointV2}},
+			"team-b": {Endpoints: []string{constants.EndpointV1}},
+		})
+		middlewares := func(endpoint string) []mux.MiddlewareFunc {
+			return []mux.MiddlewareFunc{NewClientCertAuth(mappings, nil), tenantAuthorizer.Middleware(endpoint)}
+		}
+
+		tlsCertificate := func(commonName string) tls.Certificate {
+			cert, key := newGRPCTestCert(commonName, caCert, caKey)
+			return tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+		}
+
+		// newClient returns a client connected to the server over TLS, presenting a client certificate for the
+		// given common name unless it is empty
+		newClient := func(commonName string) grpcapi.QuoteVerifierClient {
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(caCert)
+			tlsConfig := &tls.Config{RootCAs: rootCAs, ServerName: "sqvs", MinVersion: tls.VersionTLS12}
+			if commonName != "" {
+				tlsConfig.Certificates = []tls.Certificate{tlsCertificate(commonName)}
+			}
+			conn, err := grpc.DialContext(ctx.Background(), "bufnet",
+				grpc.WithContextDialer(func(ctx.Context, string) (net.Conn, error) {
+					return listener.Dial()
+				}),
+				grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(conn.Close)
+			return grpcapi.NewQuoteVerifierClient(conn)
+		}
+
+		BeforeEach(func() {
+			caCert, caKey = newGRPCTestCert("SQVS Test CA", nil, nil)
+			clientCAs := x509.NewCertPool()
+			clientCAs.AddCert(caCert)
+
+			listener = bufconn.Listen(1024 * 1024)
+			grpcServer = grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+				Certificates: []tls.Certificate{tlsCertificate("sqvs")},
+				ClientCAs:    clientCAs,
+				ClientAuth:   tls.VerifyClientCertIfGiven,
+				MinVersion:   tls.VersionTLS12,
+			})))
+			grpcapi.RegisterQuoteVerifierServer(grpcServer, NewGRPCQuoteVerifier(testConfig, scsClient,
+				trustedSGXRootCA, mocks.NewFakeSGXEcdsaQuoteVerifier(200), privateKeyLocation, pubKeyLocation,
+				middlewares, nil))
+			go func() {
+				defer GinkgoRecover()
+				Expect(grpcServer.Serve(listener)).To(Succeed())
+			}()
+		})
+
+		AfterEach(func() {
+			grpcServer.Stop()
+		})
+
+		It("Should verify a quote for a tenant authenticated by its client certificate", func() {
+			resp, err := newClient("team-a-client").VerifyQuote(ctx.Background(),
+				&grpcapi.VerifyQuoteRequest{Quote: "quote"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var quoteData map[string]interface{}
+			Expect(json.Unmarshal(resp.QuoteData, &quoteData)).To(Succeed())
+			Expect(quoteData["Message"]).To(Equal("SGX_QL_QV_RESULT_OK"))
+		})
+
+		It("Should return Unauthenticated without a client certificate", func() {
+			_, err := newClient("").VerifyQuote(ctx.Background(), &grpcapi.VerifyQuoteRequest{Quote: "quote"})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			Expect(status.Convert(err).Message()).To(Equal("Client certificate required"))
+		})
+
+		It("Should return Unauthenticated for an unmapped client certificate", func() {
+			_, err := newClient("unknown-client").VerifyQuote(ctx.Background(),
+				&grpcapi.VerifyQuoteRequest{Quote: "quote"})
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+			Expect(status.Convert(err).Message()).To(Equal("Client certificate not authorized"))
+		})
+
+		It("Should return PermissionDenied without the quote verifier role", func() {
+			_, err := newClient("other-client").VerifyQuote(ctx.Background(),
+				&grpcapi.VerifyQuoteRequest{Quote: "quote"})
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+		})
+
+		It("Should return PermissionDenied for a tenant not allowed on the endpoint", func() {
+			client := newClient("team-b-client")
+			_, err := client.VerifyQuote(ctx.Background(), &grpcapi.VerifyQuoteRequest{Quote: "quote"})
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			Expect(status.Convert(err).Message()).To(Equal("Endpoint access unauthorized for tenant"))
+
+			resp, err := client.BatchVerifyQuotes(ctx.Background(), &grpcapi.BatchVerifyQuotesRequest{
+				Requests: []*grpcapi.VerifyQuoteRequest{{Quote: "quote"}},
+			})
+			Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			Expect(resp).To(BeNil())
+		})
+
+		It("Should report the health without a client certificate", func() {
+			resp, err := newClient("").Health(ctx.Background(), &grpcapi.HealthRequest{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(grpcapi.HealthResponse_SERVING))
+		})
+	})
+})
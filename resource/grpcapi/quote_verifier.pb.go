@@ -0,0 +1,864 @@
+//
+// Copyright (C) 2022 Intel Corporation
+// SPDX-License-Identifier: BSD-3-Clause
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.0
+// 	protoc        (unknown)
+// source: quote_verifier.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HealthResponse_ServingStatus int32
+
+const (
+	HealthResponse_UNKNOWN     HealthResponse_ServingStatus = 0
+	HealthResponse_SERVING     HealthResponse_ServingStatus = 1
+	HealthResponse_NOT_SERVING HealthResponse_ServingStatus = 2
+)
+
+// Enum value maps for HealthResponse_ServingStatus.
+var (
+	HealthResponse_ServingStatus_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "SERVING",
+		2: "NOT_SERVING",
+	}
+	HealthResponse_ServingStatus_value = map[string]int32{
+		"UNKNOWN":     0,
+		"SERVING":     1,
+		"NOT_SERVING": 2,
+	}
+)
+
+func (x HealthResponse_ServingStatus) Enum() *HealthResponse_ServingStatus {
+	p := new(HealthResponse_ServingStatus)
+	*p = x
+	return p
+}
+
+func (x HealthResponse_ServingStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthResponse_ServingStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_quote_verifier_proto_enumTypes[0].Descriptor()
+}
+
+func (HealthResponse_ServingStatus) Type() protoreflect.EnumType {
+	return &file_quote_verifier_proto_enumTypes[0]
+}
+
+func (x HealthResponse_ServingStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthResponse_ServingStatus.Descriptor instead.
+func (HealthResponse_ServingStatus) EnumDescriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{7, 0}
+}
+
+// Collateral is a quote verification collateral bundle, see the collateral of the REST request
+type Collateral struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PckCrl                string `protobuf:"bytes,1,opt,name=pck_crl,json=pckCrl,proto3" json:"pck_crl,omitempty"`
+	PckCrlIssuerChain     string `protobuf:"bytes,2,opt,name=pck_crl_issuer_chain,json=pckCrlIssuerChain,proto3" json:"pck_crl_issuer_chain,omitempty"`
+	RootCaCrl             string `protobuf:"bytes,3,opt,name=root_ca_crl,json=rootCaCrl,proto3" json:"root_ca_crl,omitempty"`
+	TcbInfo               string `protobuf:"bytes,4,opt,name=tcb_info,json=tcbInfo,proto3" json:"tcb_info,omitempty"`
+	TcbInfoIssuerChain    string `protobuf:"bytes,5,opt,name=tcb_info_issuer_chain,json=tcbInfoIssuerChain,proto3" json:"tcb_info_issuer_chain,omitempty"`
+	QeIdentity            string `protobuf:"bytes,6,opt,name=qe_identity,json=qeIdentity,proto3" json:"qe_identity,omitempty"`
+	QeIdentityIssuerChain string `protobuf:"bytes,7,opt,name=qe_identity_issuer_chain,json=qeIdentityIssuerChain,proto3" json:"qe_identity_issuer_chain,omitempty"`
+}
+
+func (x *Collateral) Reset() {
+	*x = Collateral{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Collateral) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Collateral) ProtoMessage() {}
+
+func (x *Collateral) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Collateral.ProtoReflect.Descriptor instead.
+func (*Collateral) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Collateral) GetPckCrl() string {
+	if x != nil {
+		return x.PckCrl
+	}
+	return ""
+}
+
+func (x *Collateral) GetPckCrlIssuerChain() string {
+	if x != nil {
+		return x.PckCrlIssuerChain
+	}
+	return ""
+}
+
+func (x *Collateral) GetRootCaCrl() string {
+	if x != nil {
+		return x.RootCaCrl
+	}
+	return ""
+}
+
+func (x *Collateral) GetTcbInfo() string {
+	if x != nil {
+		return x.TcbInfo
+	}
+	return ""
+}
+
+func (x *Collateral) GetTcbInfoIssuerChain() string {
+	if x != nil {
+		return x.TcbInfoIssuerChain
+	}
+	return ""
+}
+
+func (x *Collateral) GetQeIdentity() string {
+	if x != nil {
+		return x.QeIdentity
+	}
+	return ""
+}
+
+func (x *Collateral) GetQeIdentityIssuerChain() string {
+	if x != nil {
+		return x.QeIdentityIssuerChain
+	}
+	return ""
+}
+
+// VerifyQuoteRequest carries the fields of the REST verification request
+type VerifyQuoteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// base64 encoded quote
+	Quote             string      `protobuf:"bytes,1,opt,name=quote,proto3" json:"quote,omitempty"`
+	UserData          string      `protobuf:"bytes,2,opt,name=user_data,json=userData,proto3" json:"user_data,omitempty"`
+	Challenge         string      `protobuf:"bytes,3,opt,name=challenge,proto3" json:"challenge,omitempty"`
+	Nonce             string      `protobuf:"bytes,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Collateral        *Collateral `protobuf:"bytes,5,opt,name=collateral,proto3" json:"collateral,omitempty"`
+	ReportDataBinding string      `protobuf:"bytes,6,opt,name=report_data_binding,json=reportDataBinding,proto3" json:"report_data_binding,omitempty"`
+	StrictReportData  bool        `protobuf:"varint,7,opt,name=strict_report_data,json=strictReportData,proto3" json:"strict_report_data,omitempty"`
+	// RFC3339 point in time the quote is verified at
+	VerificationTime string `protobuf:"bytes,8,opt,name=verification_time,json=verificationTime,proto3" json:"verification_time,omitempty"`
+}
+
+func (x *VerifyQuoteRequest) Reset() {
+	*x = VerifyQuoteRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyQuoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyQuoteRequest) ProtoMessage() {}
+
+func (x *VerifyQuoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyQuoteRequest.ProtoReflect.Descriptor instead.
+func (*VerifyQuoteRequest) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *VerifyQuoteRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *VerifyQuoteRequest) GetUserData() string {
+	if x != nil {
+		return x.UserData
+	}
+	return ""
+}
+
+func (x *VerifyQuoteRequest) GetChallenge() string {
+	if x != nil {
+		return x.Challenge
+	}
+	return ""
+}
+
+func (x *VerifyQuoteRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *VerifyQuoteRequest) GetCollateral() *Collateral {
+	if x != nil {
+		return x.Collateral
+	}
+	return nil
+}
+
+func (x *VerifyQuoteRequest) GetReportDataBinding() string {
+	if x != nil {
+		return x.ReportDataBinding
+	}
+	return ""
+}
+
+func (x *VerifyQuoteRequest) GetStrictReportData() bool {
+	if x != nil {
+		return x.StrictReportData
+	}
+	return false
+}
+
+func (x *VerifyQuoteRequest) GetVerificationTime() string {
+	if x != nil {
+		return x.VerificationTime
+	}
+	return ""
+}
+
+// VerifyQuoteResponse carries the quoteData of the REST response as JSON. When the response is signed the
+// signature is computed over the base64 encoding of quote_data, as for the REST API.
+type VerifyQuoteResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	QuoteData        []byte `protobuf:"bytes,1,opt,name=quote_data,json=quoteData,proto3" json:"quote_data,omitempty"`
+	Signature        string `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	CertificateChain string `protobuf:"bytes,3,opt,name=certificate_chain,json=certificateChain,proto3" json:"certificate_chain,omitempty"`
+}
+
+func (x *VerifyQuoteResponse) Reset() {
+	*x = VerifyQuoteResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyQuoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyQuoteResponse) ProtoMessage() {}
+
+func (x *VerifyQuoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyQuoteResponse.ProtoReflect.Descriptor instead.
+func (*VerifyQuoteResponse) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *VerifyQuoteResponse) GetQuoteData() []byte {
+	if x != nil {
+		return x.QuoteData
+	}
+	return nil
+}
+
+func (x *VerifyQuoteResponse) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *VerifyQuoteResponse) GetCertificateChain() string {
+	if x != nil {
+		return x.CertificateChain
+	}
+	return ""
+}
+
+type BatchVerifyQuotesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Requests []*VerifyQuoteRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+}
+
+func (x *BatchVerifyQuotesRequest) Reset() {
+	*x = BatchVerifyQuotesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchVerifyQuotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifyQuotesRequest) ProtoMessage() {}
+
+func (x *BatchVerifyQuotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifyQuotesRequest.ProtoReflect.Descriptor instead.
+func (*BatchVerifyQuotesRequest) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchVerifyQuotesRequest) GetRequests() []*VerifyQuoteRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+// BatchVerifyQuoteResult is the outcome of one quote of a batch, code is the gRPC status code of a failed
+// verification and OK on success
+type BatchVerifyQuoteResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Response *VerifyQuoteResponse `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	Code     int32                `protobuf:"varint,2,opt,name=code,proto3" json:"code,omitempty"`
+	Message  string               `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *BatchVerifyQuoteResult) Reset() {
+	*x = BatchVerifyQuoteResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchVerifyQuoteResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifyQuoteResult) ProtoMessage() {}
+
+func (x *BatchVerifyQuoteResult) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifyQuoteResult.ProtoReflect.Descriptor instead.
+func (*BatchVerifyQuoteResult) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchVerifyQuoteResult) GetResponse() *VerifyQuoteResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *BatchVerifyQuoteResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *BatchVerifyQuoteResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// BatchVerifyQuotesResponse holds the results in the order of the requests
+type BatchVerifyQuotesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*BatchVerifyQuoteResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *BatchVerifyQuotesResponse) Reset() {
+	*x = BatchVerifyQuotesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BatchVerifyQuotesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchVerifyQuotesResponse) ProtoMessage() {}
+
+func (x *BatchVerifyQuotesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchVerifyQuotesResponse.ProtoReflect.Descriptor instead.
+func (*BatchVerifyQuotesResponse) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchVerifyQuotesResponse) GetResults() []*BatchVerifyQuoteResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type HealthRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{6}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  HealthResponse_ServingStatus `protobuf:"varint,1,opt,name=status,proto3,enum=sqvs.v2.HealthResponse_ServingStatus" json:"status,omitempty"`
+	Version string                       `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_quote_verifier_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_quote_verifier_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_quote_verifier_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HealthResponse) GetStatus() HealthResponse_ServingStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HealthResponse_UNKNOWN
+}
+
+func (x *HealthResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+var File_quote_verifier_proto protoreflect.FileDescriptor
+
+var file_quote_verifier_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x72,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x22,
+	0x9e, 0x02, 0x0a, 0x0a, 0x43, 0x6f, 0x6c, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x12, 0x17,
+	0x0a, 0x07, 0x70, 0x63, 0x6b, 0x5f, 0x63, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x70, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x12, 0x2f, 0x0a, 0x14, 0x70, 0x63, 0x6b, 0x5f, 0x63,
+	0x72, 0x6c, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x70, 0x63, 0x6b, 0x43, 0x72, 0x6c, 0x49, 0x73, 0x73,
+	0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x1e, 0x0a, 0x0b, 0x72, 0x6f, 0x6f, 0x74,
+	0x5f, 0x63, 0x61, 0x5f, 0x63, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x6f, 0x6f, 0x74, 0x43, 0x61, 0x43, 0x72, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x63, 0x62, 0x5f,
+	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x63, 0x62, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x31, 0x0a, 0x15, 0x74, 0x63, 0x62, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x5f,
+	0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x12, 0x74, 0x63, 0x62, 0x49, 0x6e, 0x66, 0x6f, 0x49, 0x73, 0x73, 0x75, 0x65,
+	0x72, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x71, 0x65, 0x5f, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x71, 0x65, 0x49,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x37, 0x0a, 0x18, 0x71, 0x65, 0x5f, 0x69, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f, 0x69, 0x73, 0x73, 0x75, 0x65, 0x72, 0x5f, 0x63, 0x68,
+	0x61, 0x69, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x71, 0x65, 0x49, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x49, 0x73, 0x73, 0x75, 0x65, 0x72, 0x43, 0x68, 0x61, 0x69, 0x6e,
+	0x22, 0xbb, 0x02, 0x0a, 0x12, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x1b, 0x0a,
+	0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x44, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x68,
+	0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63,
+	0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x33,
+	0x0a, 0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6f, 0x6c,
+	0x6c, 0x61, 0x74, 0x65, 0x72, 0x61, 0x6c, 0x52, 0x0a, 0x63, 0x6f, 0x6c, 0x6c, 0x61, 0x74, 0x65,
+	0x72, 0x61, 0x6c, 0x12, 0x2e, 0x0a, 0x13, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x64, 0x61,
+	0x74, 0x61, 0x5f, 0x62, 0x69, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x11, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x44, 0x61, 0x74, 0x61, 0x42, 0x69, 0x6e, 0x64,
+	0x69, 0x6e, 0x67, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x5f, 0x72, 0x65,
+	0x70, 0x6f, 0x72, 0x74, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x10, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x44, 0x61, 0x74,
+	0x61, 0x12, 0x2b, 0x0a, 0x11, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x76, 0x65,
+	0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x7f,
+	0x0a, 0x13, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x5f, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x44, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x63,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22,
+	0x53, 0x0a, 0x18, 0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75,
+	0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x08, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75,
+	0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x08, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x22, 0x80, 0x01, 0x0a, 0x16, 0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12,
+	0x38, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1c, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52,
+	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x56, 0x0a, 0x19, 0x42, 0x61, 0x74, 0x63, 0x68,
+	0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x39, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22,
+	0x0f, 0x0a, 0x0d, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x22, 0xa5, 0x01, 0x0a, 0x0e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x25, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x3a, 0x0a, 0x0d,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a,
+	0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x45,
+	0x52, 0x56, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x4f, 0x54, 0x5f, 0x53,
+	0x45, 0x52, 0x56, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x32, 0xf0, 0x01, 0x0a, 0x0d, 0x51, 0x75, 0x6f,
+	0x74, 0x65, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x72, 0x12, 0x48, 0x0a, 0x0b, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x1b, 0x2e, 0x73, 0x71, 0x76, 0x73,
+	0x2e, 0x76, 0x32, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32,
+	0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5a, 0x0a, 0x11, 0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x73, 0x12, 0x21, 0x2e, 0x73, 0x71, 0x76, 0x73,
+	0x2e, 0x76, 0x32, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x51,
+	0x75, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x73,
+	0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x79, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x39, 0x0a, 0x06, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x16, 0x2e, 0x73, 0x71, 0x76,
+	0x73, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x17, 0x2e, 0x73, 0x71, 0x76, 0x73, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x26, 0x5a, 0x24, 0x69,
+	0x6e, 0x74, 0x65, 0x6c, 0x2f, 0x69, 0x73, 0x65, 0x63, 0x6c, 0x2f, 0x73, 0x71, 0x76, 0x73, 0x2f,
+	0x76, 0x35, 0x2f, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x2f, 0x67, 0x72, 0x70, 0x63,
+	0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_quote_verifier_proto_rawDescOnce sync.Once
+	file_quote_verifier_proto_rawDescData = file_quote_verifier_proto_rawDesc
+)
+
+func file_quote_verifier_proto_rawDescGZIP() []byte {
+	file_quote_verifier_proto_rawDescOnce.Do(func() {
+		file_quote_verifier_proto_rawDescData = protoimpl.X.CompressGZIP(file_quote_verifier_proto_rawDescData)
+	})
+	return file_quote_verifier_proto_rawDescData
+}
+
+var file_quote_verifier_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_quote_verifier_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_quote_verifier_proto_goTypes = []interface{}{
+	(HealthResponse_ServingStatus)(0), // 0: sqvs.v2.HealthResponse.ServingStatus
+	(*Collateral)(nil),                // 1: sqvs.v2.Collateral
+	(*VerifyQuoteRequest)(nil),        // 2: sqvs.v2.VerifyQuoteRequest
+	(*VerifyQuoteResponse)(nil),       // 3: sqvs.v2.VerifyQuoteResponse
+	(*BatchVerifyQuotesRequest)(nil),  // 4: sqvs.v2.BatchVerifyQuotesRequest
+	(*BatchVerifyQuoteResult)(nil),    // 5: sqvs.v2.BatchVerifyQuoteResult
+	(*BatchVerifyQuotesResponse)(nil), // 6: sqvs.v2.BatchVerifyQuotesResponse
+	(*HealthRequest)(nil),             // 7: sqvs.v2.HealthRequest
+	(*HealthResponse)(nil),            // 8: sqvs.v2.HealthResponse
+}
+var file_quote_verifier_proto_depIdxs = []int32{
+	1, // 0: sqvs.v2.VerifyQuoteRequest.collateral:type_name -> sqvs.v2.Collateral
+	2, // 1: sqvs.v2.BatchVerifyQuotesRequest.requests:type_name -> sqvs.v2.VerifyQuoteRequest
+	3, // 2: sqvs.v2.BatchVerifyQuoteResult.response:type_name -> sqvs.v2.VerifyQuoteResponse
+	5, // 3: sqvs.v2.BatchVerifyQuotesResponse.results:type_name -> sqvs.v2.BatchVerifyQuoteResult
+	0, // 4: sqvs.v2.HealthResponse.status:type_name -> sqvs.v2.HealthResponse.ServingStatus
+	2, // 5: sqvs.v2.QuoteVerifier.VerifyQuote:input_type -> sqvs.v2.VerifyQuoteRequest
+	4, // 6: sqvs.v2.QuoteVerifier.BatchVerifyQuotes:input_type -> sqvs.v2.BatchVerifyQuotesRequest
+	7, // 7: sqvs.v2.QuoteVerifier.Health:input_type -> sqvs.v2.HealthRequest
+	3, // 8: sqvs.v2.QuoteVerifier.VerifyQuote:output_type -> sqvs.v2.VerifyQuoteResponse
+	6, // 9: sqvs.v2.QuoteVerifier.BatchVerifyQuotes:output_type -> sqvs.v2.BatchVerifyQuotesResponse
+	8, // 10: sqvs.v2.QuoteVerifier.Health:output_type -> sqvs.v2.HealthResponse
+	8, // [8:11] is the sub-list for method output_type
+	5, // [5:8] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_quote_verifier_proto_init() }
+func file_quote_verifier_proto_init() {
+	if File_quote_verifier_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_quote_verifier_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Collateral); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyQuoteRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyQuoteResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchVerifyQuotesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchVerifyQuoteResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BatchVerifyQuotesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_quote_verifier_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HealthResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_quote_verifier_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_quote_verifier_proto_goTypes,
+		DependencyIndexes: file_quote_verifier_proto_depIdxs,
+		EnumInfos:         file_quote_verifier_proto_enumTypes,
+		MessageInfos:      file_quote_verifier_proto_msgTypes,
+	}.Build()
+	File_quote_verifier_proto = out.File
+	file_quote_verifier_proto_rawDesc = nil
+	file_quote_verifier_proto_goTypes = nil
+	file_quote_verifier_proto_depIdxs = nil
+}
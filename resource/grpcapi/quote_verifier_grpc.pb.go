@@ -0,0 +1,179 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// QuoteVerifierClient is the client API for QuoteVerifier service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QuoteVerifierClient interface {
+	// VerifyQuote verifies an SGX ECDSA quote, equivalent to POST /svs/v2/sgx_qv_verify_quote
+	VerifyQuote(ctx context.Context, in *VerifyQuoteRequest, opts ...grpc.CallOption) (*VerifyQuoteResponse, error)
+	// BatchVerifyQuotes verifies several quotes, each quote is verified independently of the others
+	BatchVerifyQuotes(ctx context.Context, in *BatchVerifyQuotesRequest, opts ...grpc.CallOption) (*BatchVerifyQuotesResponse, error)
+	// Health reports whether the service is serving requests
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+}
+
+type quoteVerifierClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuoteVerifierClient(cc grpc.ClientConnInterface) QuoteVerifierClient {
+	return &quoteVerifierClient{cc}
+}
+
+func (c *quoteVerifierClient) VerifyQuote(ctx context.Context, in *VerifyQuoteRequest, opts ...grpc.CallOption) (*VerifyQuoteResponse, error) {
+	out := new(VerifyQuoteResponse)
+	err := c.cc.Invoke(ctx, "/sqvs.v2.QuoteVerifier/VerifyQuote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteVerifierClient) BatchVerifyQuotes(ctx context.Context, in *BatchVerifyQuotesRequest, opts ...grpc.CallOption) (*BatchVerifyQuotesResponse, error) {
+	out := new(BatchVerifyQuotesResponse)
+	err := c.cc.Invoke(ctx, "/sqvs.v2.QuoteVerifier/BatchVerifyQuotes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quoteVerifierClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/sqvs.v2.QuoteVerifier/Health", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QuoteVerifierServer is the server API for QuoteVerifier service.
+// All implementations must embed UnimplementedQuoteVerifierServer
+// for forward compatibility
+type QuoteVerifierServer interface {
+	// VerifyQuote verifies an SGX ECDSA quote, equivalent to POST /svs/v2/sgx_qv_verify_quote
+	VerifyQuote(context.Context, *VerifyQuoteRequest) (*VerifyQuoteResponse, error)
+	// BatchVerifyQuotes verifies several quotes, each quote is verified independently of the others
+	BatchVerifyQuotes(context.Context, *BatchVerifyQuotesRequest) (*BatchVerifyQuotesResponse, error)
+	// Health reports whether the service is serving requests
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	mustEmbedUnimplementedQuoteVerifierServer()
+}
+
+// UnimplementedQuoteVerifierServer must be embedded to have forward compatible implementations.
+type UnimplementedQuoteVerifierServer struct {
+}
+
+func (UnimplementedQuoteVerifierServer) VerifyQuote(context.Context, *VerifyQuoteRequest) (*VerifyQuoteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyQuote not implemented")
+}
+func (UnimplementedQuoteVerifierServer) BatchVerifyQuotes(context.Context, *BatchVerifyQuotesRequest) (*BatchVerifyQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchVerifyQuotes not implemented")
+}
+func (UnimplementedQuoteVerifierServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedQuoteVerifierServer) mustEmbedUnimplementedQuoteVerifierServer() {}
+
+// UnsafeQuoteVerifierServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuoteVerifierServer will
+// result in compilation errors.
+type UnsafeQuoteVerifierServer interface {
+	mustEmbedUnimplementedQuoteVerifierServer()
+}
+
+func RegisterQuoteVerifierServer(s grpc.ServiceRegistrar, srv QuoteVerifierServer) {
+	s.RegisterService(&QuoteVerifier_ServiceDesc, srv)
+}
+
+func _QuoteVerifier_VerifyQuote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyQuoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteVerifierServer).VerifyQuote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sqvs.v2.QuoteVerifier/VerifyQuote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteVerifierServer).VerifyQuote(ctx, req.(*VerifyQuoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteVerifier_BatchVerifyQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchVerifyQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteVerifierServer).BatchVerifyQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sqvs.v2.QuoteVerifier/BatchVerifyQuotes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteVerifierServer).BatchVerifyQuotes(ctx, req.(*BatchVerifyQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuoteVerifier_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuoteVerifierServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sqvs.v2.QuoteVerifier/Health",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuoteVerifierServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// QuoteVerifier_ServiceDesc is the grpc.ServiceDesc for QuoteVerifier service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QuoteVerifier_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sqvs.v2.QuoteVerifier",
+	HandlerType: (*QuoteVerifierServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "VerifyQuote",
+			Handler:    _QuoteVerifier_VerifyQuote_Handler,
+		},
+		{
+			MethodName: "BatchVerifyQuotes",
+			Handler:    _QuoteVerifier_BatchVerifyQuotes_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _QuoteVerifier_Health_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "quote_verifier.proto",
+}
@@ -15,6 +15,7 @@ import (
 	"intel/isecl/sqvs/v5/constants"
 	"intel/isecl/sqvs/v5/resource/domain"
 	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/utils"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -29,8 +30,8 @@ type SgxQuoteParsed struct {
 	QuoteSignLen       uint32
 	QuoteSignatureData models.QuoteAuthData
 	PCKCert            *x509.Certificate
-	RootCA             map[string]*x509.Certificate
-	InterMediateCA     map[string]*x509.Certificate
+	RootCA             []*x509.Certificate
+	InterMediateCA     []*x509.Certificate
 }
 
 type SkcBlobParsed struct {
@@ -86,6 +87,20 @@ func (e *SgxQuoteParsed) GetSHA256Hash() []byte {
 	return hashValue
 }
 
+func (e *SgxQuoteParsed) GetReportData() []byte {
+	reportData := make([]byte, models.ReportDataSize)
+	copy(reportData, e.EnclaveReport.ReportData[:])
+	return reportData
+}
+
+func (e *SgxQuoteParsed) GetQuoteHeader() models.QuoteHeader {
+	return e.Header
+}
+
+func (e *SgxQuoteParsed) GetEnclaveReport() models.ReportBody {
+	return e.EnclaveReport
+}
+
 func (e *SgxQuoteParsed) GetQeReportBlob() ([]byte, error) {
 	QeReportBlob, err := restruct.Pack(binary.LittleEndian, &e.QuoteSignatureData.QeReport)
 	if err != nil {
@@ -207,21 +222,13 @@ func (e *SgxQuoteParsed) GetQuotePckCertObj() *x509.Certificate {
 
 func (e *SgxQuoteParsed) GetQuotePckCertInterCAList() []*x509.Certificate {
 	interMediateCAArr := make([]*x509.Certificate, len(e.InterMediateCA))
-	var i int
-	for _, v := range e.InterMediateCA {
-		interMediateCAArr[i] = v
-		i++
-	}
+	copy(interMediateCAArr, e.InterMediateCA)
 	return interMediateCAArr
 }
 
 func (e *SgxQuoteParsed) GetQuotePckCertRootCAList() []*x509.Certificate {
 	rootCAArr := make([]*x509.Certificate, len(e.RootCA))
-	var i int
-	for _, v := range e.RootCA {
-		rootCAArr[i] = v
-		i++
-	}
+	copy(rootCAArr, e.RootCA)
 	return rootCAArr
 }
 
@@ -237,10 +244,7 @@ func (e *SgxQuoteParsed) ParseQuoteCerts() error {
 	if numCerts < constants.MinCertsInCertChain {
 		return errors.New("ParseQuoteCerts: Cert chain should contain atleast 3 certificates")
 	}
-	var pckCertCount, intermediateCACount, rootCACount int
-
-	e.RootCA = make(map[string]*x509.Certificate)
-	e.InterMediateCA = make(map[string]*x509.Certificate)
+	certList := make([]*x509.Certificate, numCerts)
 	for i := 0; i < numCerts; i++ {
 		block, _ := pem.Decode([]byte(certs[i]))
 		if block == nil {
@@ -253,29 +257,26 @@ func (e *SgxQuoteParsed) ParseQuoteCerts() error {
 			return errors.Wrap(err, "ParseQuoteCerts: ParseCertificate error")
 		}
 
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX PCK Certificate") {
-			pckCertCount++
-			e.PCKCert = cert
-		}
-
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX Root CA") {
-			rootCACount++
-			e.RootCA[cert.Subject.String()] = cert
-		}
-
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX PCK Processor CA") ||
-			strings.Contains(cert.Subject.String(), "CN=Intel SGX PCK Platform CA") {
-			intermediateCACount++
-			e.InterMediateCA[cert.Subject.String()] = cert
-		}
+		certList[i] = cert
 		log.Debug("Cert[", i, "]Issuer:", cert.Issuer.String(), ", Subject:", cert.Subject.String())
 	}
 
-	if pckCertCount == 0 || rootCACount == 0 || intermediateCACount == 0 {
-		return errors.New(fmt.Sprintf("Quote Certificate Data invalid count: Pck Cert Count:%d, IntermediateCA Count:%d, RootCA Count:%d", pckCertCount, intermediateCACount, rootCACount))
+	// the chain is ordered PCK certificate, intermediate CA, root CA, subjects are checked by the verifier
+	chain, err := utils.BuildCertChain(certList)
+	if err != nil {
+		return errors.Wrap(err, "ParseQuoteCerts: Invalid PCK Certchain in Quote")
 	}
+	if len(chain) < constants.MinCertsInCertChain {
+		return errors.New(fmt.Sprintf("ParseQuoteCerts: PCK Certchain in Quote has %d certificates, expected "+
+			"atleast %d", len(chain), constants.MinCertsInCertChain))
+	}
+
+	e.PCKCert = chain[0]
+	e.InterMediateCA = chain[1 : len(chain)-1]
+	e.RootCA = chain[len(chain)-1:]
 
-	log.Debug(fmt.Sprintf("Quote Certificate Data Info: Pck Cert Count:%d, IntermediateCA Count:%d, RootCA Count:%d", pckCertCount, intermediateCACount, rootCACount))
+	log.Debug(fmt.Sprintf("Quote Certificate Data Info: IntermediateCA Count:%d, RootCA Count:%d",
+		len(e.InterMediateCA), len(e.RootCA)))
 	return nil
 }
 
@@ -5,6 +5,8 @@
 package parser
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -16,10 +18,12 @@ import (
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
 	"intel/isecl/sqvs/v5/resource/domain"
+	"intel/isecl/sqvs/v5/resource/domain/models"
 	"intel/isecl/sqvs/v5/resource/utils"
 	"intel/isecl/sqvs/v5/resource/verifier"
 	"io/ioutil"
 	"net/http"
+	neturl "net/url"
 	"regexp"
 	"strings"
 
@@ -29,13 +33,14 @@ import (
 type PckCRL struct {
 	PckCRLURLs     []string
 	PckCRLObjs     []*pkix.CertificateList
-	RootCA         map[string]*x509.Certificate
-	IntermediateCA map[string]*x509.Certificate
+	RootCA         []*x509.Certificate
+	IntermediateCA []*x509.Certificate
 }
 
 type PckCert struct {
 	PckCertObj           *x509.Certificate
 	FmspcStr             string
+	PlatformInfo         models.PlatformInfo
 	TcbCompLevels        []byte
 	PckCRL               PckCRL
 	RequiredExtension    map[string]asn1.ObjectIdentifier
@@ -46,48 +51,75 @@ type PckCert struct {
 	TrustedCAsStoreDir string
 }
 
-func NewPCKCertObj(certBlob []byte, client domain.HttpClient, config *config.Configuration) domain.PCKCertParser {
-	parsedPck := new(PckCert)
+// NewPCKCertObj parses the PCK certificate and fetches its CRLs, the CRL requests are bound to ctx
+func NewPCKCertObj(ctx context.Context, certBlob []byte, client domain.HttpClient,
+	config *config.Configuration) domain.PCKCertParser {
+	parsedPck, err := newPckCert(certBlob)
+	if err != nil {
+		log.Error("NewPCKCertObj: ", err.Error())
+		return nil
+	}
 
 	parsedPck.Config = config
 	parsedPck.SCSClient = client
+	err = parsedPck.ParsePckCrl(ctx)
+	if err != nil {
+		log.Error("NewPCKCertObj: PCK CRL Parse error", err.Error())
+		return nil
+	}
+	return parsedPck
+}
+
+// PckCrlFetcher returns the base64 DER encoded PCK CRL published at a CRL distribution point of a PCK
+// certificate, along with the PEM encoded issuer chain of the CRL
+type PckCrlFetcher func(distributionPoint string) (crl []byte, issuerChain string, err error)
+
+// ParsePCKCertObj parses the PCK certificate and the CRLs returned by fetchCrl for its CRL distribution points
+func ParsePCKCertObj(certBlob []byte, fetchCrl PckCrlFetcher) (domain.PCKCertParser, error) {
+	parsedPck, err := newPckCert(certBlob)
+	if err != nil {
+		return nil, errors.Wrap(err, "ParsePCKCertObj")
+	}
+	if err = parsedPck.parsePckCrls(fetchCrl); err != nil {
+		return nil, errors.Wrap(err, "ParsePCKCertObj: PCK CRL Parse error")
+	}
+	return parsedPck, nil
+}
+
+// newPckCert parses the PCK certificate and its SGX extensions
+func newPckCert(certBlob []byte) (*PckCert, error) {
+	parsedPck := new(PckCert)
 
 	err := parsedPck.GenCertObj(certBlob)
 	if err != nil {
-		log.Error("NewPCKCertObj: Generate Certificate Object Error", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "Generate Certificate Object Error")
 	}
 	parsedPck.GenPckCertRequiredExtMap()
 	err = verifier.CheckMandatoryExt(parsedPck.PckCertObj, parsedPck.GetPckCertRequiredExtMap())
 	if err != nil {
-		log.Error("NewPCKCertObj: VerifyRequiredExtensions not found", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "VerifyRequiredExtensions not found")
 	}
 	parsedPck.GenPckCertRequiredSgxExtMap()
 	err = verifier.CheckMandatorySGXExt(parsedPck.PckCertObj, parsedPck.GetPckCertRequiredSgxExtMap())
 	if err != nil {
-		log.Error("NewPCKCertObj: VerifyRequiredSGXExtensions not found", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "VerifyRequiredSGXExtensions not found")
 	}
 
 	err = parsedPck.ParseFMSPCValue()
 	if err != nil {
-		log.Error("NewPCKCertObj: Fmspc Parse error", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "Fmspc Parse error")
 	}
 
-	err = parsedPck.ParseTcbExtensions()
+	err = parsedPck.ParsePlatformInfo()
 	if err != nil {
-		log.Error("NewPCKCertObj: Tcb Extensions Parse error", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "Platform Info Parse error")
 	}
 
-	err = parsedPck.ParsePckCrl()
+	err = parsedPck.ParseTcbExtensions()
 	if err != nil {
-		log.Error("NewPCKCertObj: PCK CRL Parse error", err.Error())
-		return nil
+		return nil, errors.Wrap(err, "Tcb Extensions Parse error")
 	}
-	return parsedPck
+	return parsedPck, nil
 }
 
 func (e *PckCert) GenPckCertRequiredExtMap() {
@@ -129,6 +161,15 @@ func (e *PckCert) GetFmspcValue() string {
 	return e.FmspcStr
 }
 
+func (e *PckCert) GetPlatformInfo() models.PlatformInfo {
+	platformInfo := e.PlatformInfo
+	if platformInfo.Configuration != nil {
+		configuration := *platformInfo.Configuration
+		platformInfo.Configuration = &configuration
+	}
+	return platformInfo
+}
+
 func (e *PckCert) GetPckCertTcbLevels() []byte {
 	return e.TcbCompLevels
 }
@@ -165,6 +206,129 @@ func (e *PckCert) ParseFMSPCValue() error {
 	return errors.Wrap(err, "Fmspc Value not found in Extension")
 }
 
+// ParsePlatformInfo parses the platform identity from the SGX extension of the PCK certificate
+func (e *PckCert) ParsePlatformInfo() error {
+	for i := 0; i < len(e.PckCertObj.Extensions); i++ {
+		ext := e.PckCertObj.Extensions[i]
+		if !verifier.ExtSgxOid.Equal(ext.Id) {
+			continue
+		}
+
+		platformInfo, err := parseSgxExtension(ext.Value)
+		if err != nil {
+			return errors.Wrap(err, "ParsePlatformInfo: Invalid SGX Extension")
+		}
+		e.PlatformInfo = platformInfo
+		log.WithField("PPID", platformInfo.PPID).WithField("PCE ID", platformInfo.PceID).
+			WithField("SGX Type", platformInfo.SgxType).Debug("Platform Info from cert")
+		return nil
+	}
+	return errors.New("ParsePlatformInfo: SGX Extension not found")
+}
+
+type sgxExtensionValue struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue
+}
+
+// parseSgxExtension decodes the values of the SGX extension which identify the platform. The TCB is left
+// to ParseTcbExtensions.
+func parseSgxExtension(extValue []byte) (models.PlatformInfo, error) {
+	var platformInfo models.PlatformInfo
+	var sgxExts []sgxExtensionValue
+	rest, err := asn1.Unmarshal(extValue, &sgxExts)
+	if err != nil {
+		return platformInfo, errors.Wrap(err, "Asn1 Extension Unmarshal failed")
+	}
+	if len(rest) != 0 {
+		return platformInfo, errors.New("trailing data after SGX Extension")
+	}
+
+	for _, sgxExt := range sgxExts {
+		switch {
+		case verifier.ExtSgxPPIDOid.Equal(sgxExt.ID):
+			platformInfo.PPID, err = parseSgxExtOctetString(sgxExt, models.PPIDSize)
+		case verifier.ExtSgxPCEIDOid.Equal(sgxExt.ID):
+			platformInfo.PceID, err = parseSgxExtOctetString(sgxExt, models.PceIDSize)
+		case verifier.ExtSgxFMSPCOid.Equal(sgxExt.ID):
+			platformInfo.Fmspc, err = parseSgxExtOctetString(sgxExt, models.FmspcSize)
+		case verifier.ExtSgxSGXTypeOid.Equal(sgxExt.ID):
+			platformInfo.SgxType, err = parseSgxType(sgxExt)
+		case verifier.ExtSgxPlatformInstanceIDOid.Equal(sgxExt.ID):
+			platformInfo.PlatformInstanceID, err = parseSgxExtOctetString(sgxExt, models.PlatformInstanceIDSize)
+		case verifier.ExtSgxConfigurationOid.Equal(sgxExt.ID):
+			platformInfo.Configuration, err = parseSgxConfiguration(sgxExt)
+		}
+		if err != nil {
+			return platformInfo, err
+		}
+	}
+
+	if platformInfo.PPID == "" || platformInfo.PceID == "" || platformInfo.Fmspc == "" ||
+		platformInfo.SgxType == "" {
+		return platformInfo, errors.New("PPID, PCE ID, FMSPC or SGX Type not found in SGX Extension")
+	}
+	return platformInfo, nil
+}
+
+func parseSgxExtOctetString(sgxExt sgxExtensionValue, size int) (string, error) {
+	var value []byte
+	_, err := asn1.Unmarshal(sgxExt.Value.FullBytes, &value)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("SGX Extension %s is not an octet string", sgxExt.ID.String()))
+	}
+	if len(value) != size {
+		return "", errors.New(fmt.Sprintf("SGX Extension %s has length %d, expected %d", sgxExt.ID.String(),
+			len(value), size))
+	}
+	return hex.EncodeToString(value), nil
+}
+
+func parseSgxType(sgxExt sgxExtensionValue) (string, error) {
+	var sgxType asn1.Enumerated
+	_, err := asn1.Unmarshal(sgxExt.Value.FullBytes, &sgxType)
+	if err != nil {
+		return "", errors.Wrap(err, "SGX Type is not an enumerated value")
+	}
+	switch sgxType {
+	case 0:
+		return models.SgxTypeStandard, nil
+	case 1:
+		return models.SgxTypeScalable, nil
+	case 2:
+		return models.SgxTypeScalableWithIntegrity, nil
+	}
+	return "", errors.New(fmt.Sprintf("unknown SGX Type %d", sgxType))
+}
+
+func parseSgxConfiguration(sgxExt sgxExtensionValue) (*models.PlatformConfiguration, error) {
+	var configExts []sgxExtensionValue
+	_, err := asn1.Unmarshal(sgxExt.Value.FullBytes, &configExts)
+	if err != nil {
+		return nil, errors.Wrap(err, "SGX Configuration Unmarshal failed")
+	}
+
+	configuration := new(models.PlatformConfiguration)
+	for _, configExt := range configExts {
+		var flag *bool
+		switch {
+		case verifier.ExtSgxDynamicPlatformOid.Equal(configExt.ID):
+			flag = &configuration.DynamicPlatform
+		case verifier.ExtSgxCachedKeysOid.Equal(configExt.ID):
+			flag = &configuration.CachedKeys
+		case verifier.ExtSgxSmtEnabledOid.Equal(configExt.ID):
+			flag = &configuration.SmtEnabled
+		default:
+			continue
+		}
+		_, err = asn1.Unmarshal(configExt.Value.FullBytes, flag)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("SGX Configuration %s is not a boolean", configExt.ID.String()))
+		}
+	}
+	return configuration, nil
+}
+
 type TcbExtn struct {
 	ID    asn1.ObjectIdentifier
 	Value int
@@ -228,30 +392,36 @@ func (e *PckCert) GetPckCrlObj() []*pkix.CertificateList {
 
 func (e *PckCert) GetPckCrlInterCaList() []*x509.Certificate {
 	interMediateCAArr := make([]*x509.Certificate, len(e.PckCRL.IntermediateCA))
-	var i int
-	for _, v := range e.PckCRL.IntermediateCA {
-		interMediateCAArr[i] = v
-		i++
-	}
+	copy(interMediateCAArr, e.PckCRL.IntermediateCA)
 	log.Debug("GetPckCrlInterCaList:", len(interMediateCAArr))
 	return interMediateCAArr
 }
 
 func (e *PckCert) GetPckCrlRootCaList() []*x509.Certificate {
 	rootCAArr := make([]*x509.Certificate, len(e.PckCRL.RootCA))
-	var i int
-	for _, v := range e.PckCRL.RootCA {
-		rootCAArr[i] = v
-		i++
-	}
+	copy(rootCAArr, e.PckCRL.RootCA)
 	log.Debug("GetPckCrlRootCaList:", len(rootCAArr))
 	return rootCAArr
 }
 
-func (e *PckCert) ParsePckCrl() error {
-	e.PckCRL.PckCRLURLs = e.PckCertObj.CRLDistributionPoints
-	e.PckCRL.PckCRLObjs = make([]*pkix.CertificateList, len(e.PckCRL.PckCRLURLs))
+// appendCert appends the certificates not yet contained in certList
+func appendCert(certList []*x509.Certificate, certs ...*x509.Certificate) []*x509.Certificate {
+	for _, cert := range certs {
+		found := false
+		for _, c := range certList {
+			if bytes.Equal(c.Raw, cert.Raw) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			certList = append(certList, cert)
+		}
+	}
+	return certList
+}
 
+func (e *PckCert) ParsePckCrl(ctx context.Context) error {
 	if e.Config == nil {
 		return errors.Wrap(errors.New("parsePckCrl: Configuration pointer is null"), "Config error")
 	}
@@ -260,23 +430,21 @@ func (e *PckCert) ParsePckCrl() error {
 		return errors.Wrap(errors.New("parsePckCrl: HTTPClient pointer is null"), "Config error")
 	}
 
-	for i := 0; i < len(e.PckCRL.PckCRLURLs); i++ {
-		url := e.PckCRL.PckCRLURLs[i]
-
+	return e.parsePckCrls(func(url string) ([]byte, string, error) {
 		scsURL := e.Config.SCSBaseURL
 		if !strings.Contains(url, scsURL) {
 			a := regexp.MustCompile(`v\d`)
 			splitURL := a.Split(url, -1)
 			if len(splitURL) != 2 {
-				return errors.New("parsePckCrl: Invalid PCK CRL URL")
+				return nil, "", errors.New("parsePckCrl: Invalid PCK CRL URL")
 			}
 			finalURL := strings.Trim(splitURL[1], "&encoding")
 			url = scsURL + finalURL
 		}
 
-		req, err := http.NewRequest("GET", url, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			return errors.Wrap(err, "parsePckCrl: Failed to Get New request")
+			return nil, "", errors.Wrap(err, "parsePckCrl: Failed to Get New request")
 		}
 
 		req.Header.Set("Accept", "application/json")
@@ -291,16 +459,40 @@ func (e *PckCert) ParsePckCrl() error {
 		}
 
 		if err != nil {
-			return errors.Wrap(err, "failed to get pckcrl response from scs")
+			return nil, "", errors.Wrap(err, "failed to get pckcrl response from scs")
 		}
 
 		if resp.StatusCode != 200 {
-			return errors.New(fmt.Sprintf("parsePckCrl: Invalid status code received:%d", resp.StatusCode))
+			return nil, "", errors.New(fmt.Sprintf("parsePckCrl: Invalid status code received:%d", resp.StatusCode))
 		}
 
 		crlBody, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return errors.Wrap(err, "parsePckCrl: failed to read pckcrl response body")
+			return nil, "", errors.Wrap(err, "parsePckCrl: failed to read pckcrl response body")
+		}
+
+		issuerChain, err := neturl.QueryUnescape(resp.Header.Get("SGX-PCK-CRL-Issuer-Chain"))
+		if err != nil {
+			return nil, "", errors.Wrap(err, "parsePckCrl: failed to get cert list")
+		}
+		return crlBody, issuerChain, nil
+	})
+}
+
+// parsePckCrls parses the CRLs returned by fetchCrl for the CRL distribution points of the PCK certificate
+func (e *PckCert) parsePckCrls(fetchCrl PckCrlFetcher) error {
+	e.PckCRL.PckCRLURLs = e.PckCertObj.CRLDistributionPoints
+	e.PckCRL.PckCRLObjs = make([]*pkix.CertificateList, len(e.PckCRL.PckCRLURLs))
+
+	// a certificate may list several CRL distribution points, the issuer chains of all CRLs are collected
+	// so that each CRL can be matched to its issuing CA
+	e.PckCRL.RootCA = nil
+	e.PckCRL.IntermediateCA = nil
+
+	for i, url := range e.PckCRL.PckCRLURLs {
+		crlBody, issuerChain, err := fetchCrl(url)
+		if err != nil {
+			return err
 		}
 
 		crlDer, err := base64.StdEncoding.DecodeString(string(crlBody))
@@ -314,33 +506,26 @@ func (e *PckCert) ParsePckCrl() error {
 		}
 
 		e.PckCRL.PckCRLObjs[i] = crlObj
-		certChainList, err := utils.GetCertObjList(resp.Header.Get("SGX-PCK-CRL-Issuer-Chain"))
+		certChainList, err := utils.GetCertObjListFromPem(issuerChain)
 		if err != nil {
 			return errors.Wrap(err, "parsePckCrl: failed to get cert list")
 		}
 
-		e.PckCRL.RootCA = make(map[string]*x509.Certificate)
-		e.PckCRL.IntermediateCA = make(map[string]*x509.Certificate)
-
-		var intermediateCACount int
-		var rootCACount int
-		for i := 0; i < len(certChainList); i++ {
-			cert := certChainList[i]
-			if strings.Contains(cert.Subject.String(), "CN=Intel SGX Root CA") {
-				rootCACount++
-				e.PckCRL.RootCA[cert.Subject.String()] = cert
-			}
-			if strings.Contains(cert.Subject.String(), "CN=Intel SGX PCK Processor CA") ||
-				strings.Contains(cert.Subject.String(), "CN=Intel SGX PCK Platform CA") {
-				intermediateCACount++
-				e.PckCRL.IntermediateCA[cert.Subject.String()] = cert
-			}
-			log.Debug("Cert[", i, "] - Issuer:", cert.Issuer.String(), ", Subject:", cert.Subject.String())
+		// the issuer chain is ordered CRL issuing CA, root CA, subjects are checked by the verifier
+		chain, err := utils.BuildCertChain(certChainList)
+		if err != nil {
+			return errors.Wrap(err, "parsePckCrl: Invalid PCK CRL Issuer Chain")
 		}
-
-		if intermediateCACount == 0 || rootCACount == 0 {
-			return errors.Wrap(err, "parsePckCrl: PCK CRL- Root CA/Intermediate CA Invalid count")
+		if len(chain) < 2 {
+			return errors.New("parsePckCrl: PCK CRL Issuer Chain has no intermediate CA")
+		}
+		for j := 0; j < len(chain); j++ {
+			log.Debug("Cert[", j, "] - Issuer:", chain[j].Issuer.String(), ", Subject:", chain[j].Subject.String())
 		}
+
+		// CRLs issued by the same CA share an issuer chain
+		e.PckCRL.IntermediateCA = appendCert(e.PckCRL.IntermediateCA, chain[:len(chain)-1]...)
+		e.PckCRL.RootCA = appendCert(e.PckCRL.RootCA, chain[len(chain)-1])
 	}
 	return nil
 }
@@ -5,13 +5,17 @@
 package parser
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/asn1"
 	"encoding/pem"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/resource/domain/mocks"
+	"intel/isecl/sqvs/v5/resource/domain/models"
 	"intel/isecl/sqvs/v5/resource/utils"
+	"intel/isecl/sqvs/v5/resource/verifier"
 	testutils "intel/isecl/sqvs/v5/test/utils"
 	"io/ioutil"
 	"net/http"
@@ -41,7 +45,7 @@ func TestPckCertParser(t *testing.T) {
 	scsClient := mocks.NewClientMock(http.StatusOK)
 	testConfig := config.Load(testConfigFilePath)
 
-	pckCert := NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	pckCert := NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.NotNil(t, pckCert)
 
 	caPrivateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
@@ -97,7 +101,7 @@ func TestPckCertParser(t *testing.T) {
 	pckCrlRootCaList := pckCert.GetPckCrlRootCaList()
 	assert.NotNil(t, pckCrlRootCaList)
 
-	err = pckCert.ParsePckCrl()
+	err = pckCert.ParsePckCrl(context.Background())
 	assert.Nil(t, err)
 
 	// Remove test files and the end.
@@ -119,7 +123,7 @@ func TestNewPCKCertObj(t *testing.T) {
 
 	testCertPem, _ := pem.Decode(trustedSGXRootCABytes)
 	assert.NotNil(t, testCertPem)
-	got := NewPCKCertObj(pem.EncodeToMemory(testCertPem), nil, nil)
+	got := NewPCKCertObj(context.Background(), pem.EncodeToMemory(testCertPem), nil, nil)
 	assert.Nil(t, got)
 	QuoteBlob := "AwACAAAAAAAFAAoAk5pyM/ecTKmUCg2zlX8GB1ePHvTyaJq7KWtZvEB5i5QAAAAAAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABwAAAAAAAADnAAAAAAAAAK1GdJ7UHrqiMnJSBB7nRtN5Gp8kMYMP7giD95k8rzFqAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACD1xnnferKFHD2uvYqTXdDA8iZ22kCD5xw7h38CMfOngAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAU850rHdoyZhtjHHze/xDF6e/hNwogmoRd40iZZB/v+AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA1BAAAGp1IMlI7P+lVMltAJ3xTyeLmrqsZgK/0WBajiIPqCrhxAagIIu0l+QPoAuYmEmHm4oBrgjHhUspUmzqguHHofFM5sfwb/QU4hRFUhtwVAno0GAfyGz8nHVy64xAtRNnv7Vvk/GjislKD73UamghpdNaH5pz0/u5JhOp37YoDNVfAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAFQAAAAAAAADnAAAAAAAAAGDYWvKL6NHECgjZiwCdX4rME4Sjhc9GCADkeHkdGpecAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACMT1d115ZQPpYTf3fGioKaAFasje1wFAsIGwlEkMV7/wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEABQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAADNWDh6dvJehw5sQSZBtNlOVBGafQaMeOQkvnxUAIAuYgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAhguSX/JsCRh+Rjbg+dTLhT3/rzHPoMboaUH2fSWNyk7h+hUPh2QloKd8slEi8ZPnXYzzhcYXqTUXwlGHkr3nkiAAAAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8FAGwOAAAtLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJRTlEQ0NCSnFnQXdJQkFnSVVkK3p1Yi94WlhaSVZtd0d6MXFDUzBVcG9sNlF3Q2dZSUtvWkl6ajBFQXdJd2NERWlNQ0FHQTFVRQpBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2dRMjl5Y0c5eVlYUnBiMjR4CkZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTE1Ba0dBMVVFQmhNQ1ZWTXdIaGNOTWpFd016QTUKTURZek5USTJXaGNOTWpnd016QTVNRFl6TlRJMldqQndNU0l3SUFZRFZRUUREQmxKYm5SbGJDQlRSMWdnVUVOTElFTmxjblJwWm1sagpZWFJsTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JEYjNKd2IzSmhkR2x2YmpFVU1CSUdBMVVFQnd3TFUyRnVkR0VnUTJ4aGNtRXhDekFKCkJnTlZCQWdNQWtOQk1Rc3dDUVlEVlFRR0V3SlZVekJaTUJNR0J5cUdTTTQ5QWdFR0NDcUdTTTQ5QXdFSEEwSUFCTXhuYWJ0c0VxRlUKblNvVE50Y0kraG1xQlA3eXcvR2FldlllS3UzTVNsc21ZQVloc0RuNWNTczRObFNabkJWQ1F4NU9XaWpHNTUrZUd3QTJzWHRCZ2VhagpnZ01RTUlJREREQWZCZ05WSFNNRUdEQVdnQlJaSTlPblNxaGpWQzQ1Y0szZ0R3Y3JWeVFxdHpCdkJnTlZIUjhFYURCbU1HU2dZcUJnCmhsNW9kSFJ3Y3pvdkwzTmllQzVoY0drdWRISjFjM1JsWkhObGNuWnBZMlZ6TG1sdWRHVnNMbU52YlM5elozZ3ZZMlZ5ZEdsbWFXTmgKZEdsdmJpOTJNeTl3WTJ0amNtdy9ZMkU5Y0d4aGRHWnZjbTBtWlc1amIyUnBibWM5WkdWeU1CMEdBMVVkRGdRV0JCU2lMS2JLVHFNSgpvSHd2K01iRjQ2NmNsUGNQWXpBT0JnTlZIUThCQWY4RUJBTUNCc0F3REFZRFZSMFRBUUgvQkFJd0FEQ0NBamtHQ1NxR1NJYjRUUUVOCkFRU0NBaW93Z2dJbU1CNEdDaXFHU0liNFRRRU5BUUVFRUNDdm84ait5MGZBb2pFZVRMeExiZGd3Z2dGakJnb3Foa2lHK0UwQkRRRUMKTUlJQlV6QVFCZ3NxaGtpRytFMEJEUUVDQVFJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQWdJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQXdJQgpBREFRQmdzcWhraUcrRTBCRFFFQ0JBSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JnSUJBREFRCkJnc3Foa2lHK0UwQkRRRUNCd0lCQURBUUJnc3Foa2lHK0UwQkRRRUNDQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNDUUlCQURBUUJnc3EKaGtpRytFMEJEUUVDQ2dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDQ3dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDREFJQkFEQVFCZ3NxaGtpRworRTBCRFFFQ0RRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0RnSUJBREFRQmdzcWhraUcrRTBCRFFFQ0R3SUJBREFRQmdzcWhraUcrRTBCCkRRRUNFQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNFUUlCQ2pBZkJnc3Foa2lHK0UwQkRRRUNFZ1FRQWdJQUFBQUFBQUFBQUFBQUFBQUEKQURBUUJnb3Foa2lHK0UwQkRRRURCQUlBQURBVUJnb3Foa2lHK0UwQkRRRUVCQVlRWUdvQUFBQXdEd1lLS29aSWh2aE5BUTBCQlFvQgpBVEFlQmdvcWhraUcrRTBCRFFFR0JCQWFnNUxzb1dnaS9QRFJNT3JwNVhzaE1FUUdDaXFHU0liNFRRRU5BUWN3TmpBUUJnc3Foa2lHCitFMEJEUUVIQVFFQi96QVFCZ3NxaGtpRytFMEJEUUVIQWdFQkFEQVFCZ3NxaGtpRytFMEJEUUVIQXdFQi96QUtCZ2dxaGtqT1BRUUQKQWdOSUFEQkZBaUVBcTVzK2hhWHlaRisxVE5CUVVhRExNaTBlN204V2JOTGhRNm54MHphY3NvUUNJQS9aRjIxVk9EMTdCdHcwcHBHTwp3REF5VC9LOEJiMTZ3SjhDTU1FWVljcUEKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLS0tLS0tQkVHSU4gQ0VSVElGSUNBVEUtLS0tLQpNSUlDbWpDQ0FrQ2dBd0lCQWdJVVdTUFRwMHFvWTFRdU9YQ3Q0QThISzFja0tyY3dDZ1lJS29aSXpqMEVBd0l3CmFERWFNQmdHQTFVRUF3d1JTVzUwWld3Z1UwZFlJRkp2YjNRZ1EwRXhHakFZQmdOVkJBb01FVWx1ZEdWc0lFTnYKY25CdmNtRjBhVzl1TVJRd0VnWURWUVFIREF0VFlXNTBZU0JEYkdGeVlURUxNQWtHQTFVRUNBd0NRMEV4Q3pBSgpCZ05WQkFZVEFsVlRNQjRYRFRFNU1UQXpNVEV5TXpNME4xb1hEVE0wTVRBek1URXlNek0wTjFvd2NERWlNQ0FHCkExVUVBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2cKUTI5eWNHOXlZWFJwYjI0eEZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTApNQWtHQTFVRUJoTUNWVk13V1RBVEJnY3Foa2pPUFFJQkJnZ3Foa2pPUFFNQkJ3TkNBQVF3cCtMYytUVUJ0ZzFICitVOEpJc01zYmpIakNrVHRYYjhqUE02cjJkaHU5eklibGhEWjdJTmZxdDNJeDhYY0ZLRDhrME5FWHJrWjY2cUoKWGExS3pMSUtvNEcvTUlHOE1COEdBMVVkSXdRWU1CYUFGT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUZZRwpBMVVkSHdSUE1FMHdTNkJKb0VlR1JXaDBkSEJ6T2k4dmMySjRMV05sY25ScFptbGpZWFJsY3k1MGNuVnpkR1ZrCmMyVnlkbWxqWlhNdWFXNTBaV3d1WTI5dEwwbHVkR1ZzVTBkWVVtOXZkRU5CTG1SbGNqQWRCZ05WSFE0RUZnUVUKV1NQVHAwcW9ZMVF1T1hDdDRBOEhLMWNrS3Jjd0RnWURWUjBQQVFIL0JBUURBZ0VHTUJJR0ExVWRFd0VCL3dRSQpNQVlCQWY4Q0FRQXdDZ1lJS29aSXpqMEVBd0lEU0FBd1JRSWhBSjFxK0ZUeitnVXVWZkJRdUNnSnNGckwyVFRTCmUxYUJaNTNPNTJUakZpZTZBaUFyaVBhUmFoVVg5T2E5a0dMbEFjaFdYS1Q2ajRSV1NSNTBCcWhyTjNVVDRBPT0KLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQotLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJQ2xEQ0NBam1nQXdJQkFnSVZBT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUFvR0NDcUdTTTQ5QkFNQwpNR2d4R2pBWUJnTlZCQU1NRVVsdWRHVnNJRk5IV0NCU2IyOTBJRU5CTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JECmIzSndiM0poZEdsdmJqRVVNQklHQTFVRUJ3d0xVMkZ1ZEdFZ1EyeGhjbUV4Q3pBSkJnTlZCQWdNQWtOQk1Rc3cKQ1FZRFZRUUdFd0pWVXpBZUZ3MHhPVEV3TXpFd09UUTVNakZhRncwME9URXlNekV5TXpVNU5UbGFNR2d4R2pBWQpCZ05WQkFNTUVVbHVkR1ZzSUZOSFdDQlNiMjkwSUVOQk1Sb3dHQVlEVlFRS0RCRkpiblJsYkNCRGIzSndiM0poCmRHbHZiakVVTUJJR0ExVUVCd3dMVTJGdWRHRWdRMnhoY21FeEN6QUpCZ05WQkFnTUFrTkJNUXN3Q1FZRFZRUUcKRXdKVlV6QlpNQk1HQnlxR1NNNDlBZ0VHQ0NxR1NNNDlBd0VIQTBJQUJFLzZELzFXSE5yV3dQbU5NSXlCS01XNQpKNkp6TXNqbzZ4UDJ2a0sxY2RaR2IxUEdSUC9DLzhFQ2dpRGtta2xtendMekxpKzAwMG03TExydEtKQTNvQzJqCmdiOHdnYnd3SHdZRFZSMGpCQmd3Rm9BVTZlaEVVbE0yWEVzWW1oSDhReGdzcGR3Z2dFZ3dWZ1lEVlIwZkJFOHcKVFRCTG9FbWdSNFpGYUhSMGNITTZMeTl6WW5ndFkyVnlkR2xtYVdOaGRHVnpMblJ5ZFhOMFpXUnpaWEoyYVdObApjeTVwYm5SbGJDNWpiMjB2U1c1MFpXeFRSMWhTYjI5MFEwRXVaR1Z5TUIwR0ExVWREZ1FXQkJUcDZFUlNVelpjClN4aWFFZnhER0N5bDNDQ0FTREFPQmdOVkhROEJBZjhFQkFNQ0FRWXdFZ1lEVlIwVEFRSC9CQWd3QmdFQi93SUIKQVRBS0JnZ3Foa2pPUFFRREFnTkpBREJHQWlFQXp3OXpkVWlVSFBNVWQwQzRteDQxamxGWmtyTTN5NWYxbGduVgpPN0Ziak9vQ0lRQ29HdFVtVDRjWHQ3Vit5U0hiSjhIb2I5QWFucHZYTkgxRVIrL2daRitvcFE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="
 
@@ -132,34 +136,113 @@ func TestNewPCKCertObj(t *testing.T) {
 	scsClient := mocks.NewClientMock(http.StatusOK)
 	testConfig := config.Load(testConfigFilePath)
 
-	got = NewPCKCertObj(pckCertBytes, scsClient, nil)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, nil)
 	assert.Nil(t, got)
 
-	got = NewPCKCertObj(pckCertBytes, nil, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, nil, testConfig)
 	assert.Nil(t, got)
 
-	got = NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.NotNil(t, got)
 
 	// test with negative clients
 
 	scsClient = mocks.NewClientMock(400)
-	got = NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.Nil(t, got)
 
 	scsClient = mocks.NewClientMock(401)
-	got = NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.Nil(t, got)
 
 	scsClient = mocks.NewClientMock(204)
-	got = NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.Nil(t, got)
 
 	scsClient = mocks.NewClientMock(202)
-	got = NewPCKCertObj(pckCertBytes, scsClient, testConfig)
+	got = NewPCKCertObj(context.Background(), pckCertBytes, scsClient, testConfig)
 	assert.Nil(t, got)
 
 	// Remove test files and the end.
 	os.Remove(testCertPemFile)
 	os.Remove(privatekeyLocation)
 }
+
+func marshalSgxExt(t *testing.T, oid asn1.ObjectIdentifier, value interface{}) asn1.RawValue {
+	valueBytes, err := asn1.Marshal(value)
+	assert.NoError(t, err)
+	extBytes, err := asn1.Marshal(sgxExtensionValue{ID: oid, Value: asn1.RawValue{FullBytes: valueBytes}})
+	assert.NoError(t, err)
+	return asn1.RawValue{FullBytes: extBytes}
+}
+
+func TestParseSgxExtension(t *testing.T) {
+	ppid := marshalSgxExt(t, verifier.ExtSgxPPIDOid, make([]byte, models.PPIDSize))
+	pceID := marshalSgxExt(t, verifier.ExtSgxPCEIDOid, []byte{0x00, 0x01})
+	fmspc := marshalSgxExt(t, verifier.ExtSgxFMSPCOid, []byte{0x00, 0x90, 0x6e, 0xd5, 0x00, 0x00})
+	tcb := marshalSgxExt(t, verifier.ExtSgxTCBOid, []asn1.RawValue{marshalSgxExt(t, verifier.ExtSgxTcbPceSvnOid, 10)})
+	standard := marshalSgxExt(t, verifier.ExtSgxSGXTypeOid, asn1.Enumerated(0))
+	scalable := marshalSgxExt(t, verifier.ExtSgxSGXTypeOid, asn1.Enumerated(1))
+	instanceID := marshalSgxExt(t, verifier.ExtSgxPlatformInstanceIDOid, []byte{0x1a, 0x83, 0x92, 0xec, 0xa1, 0x68,
+		0x22, 0xfc, 0xf0, 0xd1, 0x30, 0xea, 0xe9, 0xe5, 0x7b, 0x21})
+	configuration := marshalSgxExt(t, verifier.ExtSgxConfigurationOid, []asn1.RawValue{
+		marshalSgxExt(t, verifier.ExtSgxDynamicPlatformOid, true),
+		marshalSgxExt(t, verifier.ExtSgxSmtEnabledOid, true),
+	})
+
+	tests := []struct {
+		name    string
+		exts    []asn1.RawValue
+		want    models.PlatformInfo
+		wantErr bool
+	}{
+		{
+			name: "processor certificate",
+			exts: []asn1.RawValue{ppid, tcb, pceID, fmspc, standard},
+			want: models.PlatformInfo{PPID: "00000000000000000000000000000000", PceID: "0001", Fmspc: "00906ed50000",
+				SgxType: models.SgxTypeStandard},
+		},
+		{
+			name: "platform certificate",
+			exts: []asn1.RawValue{ppid, tcb, pceID, fmspc, scalable, instanceID, configuration},
+			want: models.PlatformInfo{PPID: "00000000000000000000000000000000", PceID: "0001", Fmspc: "00906ed50000",
+				SgxType: models.SgxTypeScalable, PlatformInstanceID: "1a8392eca16822fcf0d130eae9e57b21",
+				Configuration: &models.PlatformConfiguration{DynamicPlatform: true, SmtEnabled: true}},
+		},
+		{
+			name:    "missing SGX Type",
+			exts:    []asn1.RawValue{ppid, tcb, pceID, fmspc},
+			wantErr: true,
+		},
+		{
+			name:    "invalid PPID length",
+			exts:    []asn1.RawValue{marshalSgxExt(t, verifier.ExtSgxPPIDOid, []byte{0x01}), tcb, pceID, fmspc, standard},
+			wantErr: true,
+		},
+		{
+			name:    "unknown SGX Type",
+			exts:    []asn1.RawValue{ppid, tcb, pceID, fmspc, marshalSgxExt(t, verifier.ExtSgxSGXTypeOid, asn1.Enumerated(7))},
+			wantErr: true,
+		},
+		{
+			name: "invalid configuration flag",
+			exts: []asn1.RawValue{ppid, tcb, pceID, fmspc, scalable, marshalSgxExt(t, verifier.ExtSgxConfigurationOid,
+				[]asn1.RawValue{marshalSgxExt(t, verifier.ExtSgxCachedKeysOid, 1)})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extValue, err := asn1.Marshal(tt.exts)
+			assert.NoError(t, err)
+			got, err := parseSgxExtension(extValue)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package parser
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
@@ -14,7 +15,7 @@ import (
 	"intel/isecl/sqvs/v5/resource/utils"
 	"io/ioutil"
 	"net/http"
-	"strings"
+	"net/url"
 
 	"github.com/pkg/errors"
 )
@@ -26,8 +27,8 @@ type QeIdentityJSON struct {
 
 type QeIdentityData struct {
 	QEJson         QeIdentityJSON
-	RootCA         map[string]*x509.Certificate
-	IntermediateCA map[string]*x509.Certificate
+	RootCA         []*x509.Certificate
+	IntermediateCA []*x509.Certificate
 	RawBlob        []byte
 }
 
@@ -56,11 +57,12 @@ type EnclaveIdentityType struct {
 	TcbLevels               []TcbLevelsInfo `json:"tcbLevels"`
 }
 
-func NewQeIdentity(conf *config.Configuration, client domain.HttpClient) (*QeIdentityData, error) {
+// NewQeIdentity fetches and parses the QE identity, the request is bound to ctx
+func NewQeIdentity(ctx context.Context, conf *config.Configuration, client domain.HttpClient) (*QeIdentityData, error) {
 	obj := new(QeIdentityData)
 
-	url := fmt.Sprintf("%s/qe/identity", conf.SCSBaseURL)
-	req, err := http.NewRequest("GET", url, nil)
+	scsURL := fmt.Sprintf("%s/qe/identity", conf.SCSBaseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", scsURL, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "NewQeIdentity: failed to get new request")
 	}
@@ -96,61 +98,68 @@ func NewQeIdentity(conf *config.Configuration, client domain.HttpClient) (*QeIde
 		return nil, errors.Wrap(err, "NewQeIdentity: no qe identity data received")
 	}
 
-	obj.RawBlob = make([]byte, len(content))
-	copy(obj.RawBlob, content)
-
-	if err := json.Unmarshal(content, &obj.QEJson); err != nil {
-		return nil, errors.Wrap(err, "NewQeIdentity: cannot unmarshal qeidentity data")
-	}
-
-	certChainList, err := utils.GetCertObjList(resp.Header.Get("Sgx-Qe-Identity-Issuer-Chain"))
+	issuerChain, err := url.QueryUnescape(resp.Header.Get("Sgx-Qe-Identity-Issuer-Chain"))
 	if err != nil {
 		return nil, errors.Wrap(err, "NewQeIdentity: failed to get QE Identity CertChain")
 	}
+	if err := obj.parse(content, issuerChain); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ParseQeIdentity parses a QE identity and its PEM encoded issuer chain
+func ParseQeIdentity(content []byte, issuerChain string) (*QeIdentityData, error) {
+	if len(content) == 0 {
+		return nil, errors.New("ParseQeIdentity: QE identity is empty")
+	}
+	obj := new(QeIdentityData)
+	if err := obj.parse(content, issuerChain); err != nil {
+		return nil, errors.Wrap(err, "ParseQeIdentity: Failed to parse QE identity")
+	}
+	return obj, nil
+}
 
-	obj.RootCA = make(map[string]*x509.Certificate)
-	obj.IntermediateCA = make(map[string]*x509.Certificate)
+func (e *QeIdentityData) parse(content []byte, issuerChain string) error {
+	e.RawBlob = make([]byte, len(content))
+	copy(e.RawBlob, content)
 
-	var intermediateCACount int
-	var rootCACount int
-	for i := 0; i < len(certChainList); i++ {
-		cert := certChainList[i]
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX Root CA") {
-			rootCACount++
-			obj.RootCA[cert.Subject.String()] = cert
-		}
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX TCB Signing") {
-			intermediateCACount++
-			obj.IntermediateCA[cert.Subject.String()] = cert
-		}
-		log.Debug("Cert[", i, "]Issuer:", cert.Issuer.String(), ", Subject:", cert.Subject.String())
+	if err := json.Unmarshal(content, &e.QEJson); err != nil {
+		return errors.Wrap(err, "NewQeIdentity: cannot unmarshal qeidentity data")
 	}
 
-	if intermediateCACount == 0 || rootCACount == 0 {
-		return nil, errors.Wrap(err, "NewQeIdentityi: Root CA/Intermediate CA Invalid count")
+	certChainList, err := utils.GetCertObjListFromPem(issuerChain)
+	if err != nil {
+		return errors.Wrap(err, "NewQeIdentity: failed to get QE Identity CertChain")
 	}
 
-	return obj, nil
+	// the issuer chain is ordered TCB signing CA, root CA, subjects are checked by the verifier
+	chain, err := utils.BuildCertChain(certChainList)
+	if err != nil {
+		return errors.Wrap(err, "NewQeIdentity: Invalid QE Identity CertChain")
+	}
+	if len(chain) < 2 {
+		return errors.New("NewQeIdentity: QE Identity CertChain has no intermediate CA")
+	}
+	for i := 0; i < len(chain); i++ {
+		log.Debug("Cert[", i, "]Issuer:", chain[i].Issuer.String(), ", Subject:", chain[i].Subject.String())
+	}
+
+	e.IntermediateCA = chain[:len(chain)-1]
+	e.RootCA = chain[len(chain)-1:]
+	return nil
 }
 
 func (e *QeIdentityData) GetQeInfoInterCaList() []*x509.Certificate {
 	interMediateCAArr := make([]*x509.Certificate, len(e.IntermediateCA))
-	var i int
-	for _, v := range e.IntermediateCA {
-		interMediateCAArr[i] = v
-		i++
-	}
+	copy(interMediateCAArr, e.IntermediateCA)
 	log.Debug("GetQeInfoInterCaList:", len(interMediateCAArr))
 	return interMediateCAArr
 }
 
 func (e *QeIdentityData) GetQeInfoRootCaList() []*x509.Certificate {
 	rootCAArr := make([]*x509.Certificate, len(e.RootCA))
-	var i int
-	for _, v := range e.RootCA {
-		rootCAArr[i] = v
-		i++
-	}
+	copy(rootCAArr, e.RootCA)
 	log.Debug("GetQeInfoRootCaList:", len(rootCAArr))
 	return rootCAArr
 }
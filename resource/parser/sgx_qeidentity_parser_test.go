@@ -5,6 +5,7 @@
 package parser
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -30,7 +31,7 @@ const (
 func ReadCertFromFile(t *testing.T, certFilePath string) *x509.Certificate {
 	_, err := os.Stat(certFilePath)
 	if os.IsNotExist(err) {
-		return errors.Wrap(err, "cert file path does not exist")
+		t.Fatalf("cert file path %s does not exist", certFilePath)
 	}
 
 	trustedSGXRootCABytes, err := ioutil.ReadFile(certFilePath)
@@ -151,16 +152,13 @@ func TestQeIdentityData_GetQeInfoInterCaList(t *testing.T) {
 	rootCert := ReadCertFromFile(t, trustedSGXRootCA)
 	intermediateCert := ReadCertFromFile(t, intermediateSGXRootCA)
 
-	rootCAMap := make(map[string]*x509.Certificate)
-	interCAMap := make(map[string]*x509.Certificate)
-
-	rootCAMap["root-ca"] = rootCert
-	interCAMap["inter-ca"] = intermediateCert
+	rootCAList := []*x509.Certificate{rootCert}
+	interCAList := []*x509.Certificate{intermediateCert}
 
 	qedata := &QeIdentityData{
 		QEJson:         getTestQeIdentityJSON(t),
-		RootCA:         rootCAMap,
-		IntermediateCA: interCAMap,
+		RootCA:         rootCAList,
+		IntermediateCA: interCAList,
 	}
 
 	qeInfoInterCaList = qedata.GetQeInfoInterCaList()
@@ -175,23 +173,23 @@ func TestNewQeIdentity(t *testing.T) {
 	scsClient := mocks.NewClientMock(http.StatusOK)
 	testConfig := config.Load(testConfigFilePath)
 
-	_, err := NewQeIdentity(testConfig, scsClient)
+	_, err := NewQeIdentity(context.Background(), testConfig, scsClient)
 	assert.Nil(t, err)
 
 	// test with negative clients
 	scsClient = mocks.NewClientMock(400)
-	_, err = NewQeIdentity(testConfig, scsClient)
+	_, err = NewQeIdentity(context.Background(), testConfig, scsClient)
 	assert.NotNil(t, err)
 
 	scsClient = mocks.NewClientMock(401)
-	_, err = NewQeIdentity(testConfig, scsClient)
+	_, err = NewQeIdentity(context.Background(), testConfig, scsClient)
 	assert.NotNil(t, err)
 
 	scsClient = mocks.NewClientMock(204)
-	_, err = NewQeIdentity(testConfig, scsClient)
+	_, err = NewQeIdentity(context.Background(), testConfig, scsClient)
 	assert.Nil(t, err)
 
 	scsClient = mocks.NewClientMock(202)
-	_, err = NewQeIdentity(testConfig, scsClient)
+	_, err = NewQeIdentity(context.Background(), testConfig, scsClient)
 	assert.NotNil(t, err)
 }
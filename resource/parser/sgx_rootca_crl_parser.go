@@ -0,0 +1,81 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package parser
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/resource/domain"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type RootCACrl struct {
+	CrlObj *pkix.CertificateList
+}
+
+// NewRootCACrl fetches the Intel SGX Root CA CRL, which revokes the Processor, Platform and TCB Signing
+// intermediate CA certificates. The request is bound to ctx.
+func NewRootCACrl(ctx context.Context, conf *config.Configuration, client domain.HttpClient) (*RootCACrl, error) {
+	if conf == nil || client == nil {
+		return nil, errors.New("NewRootCACrl: Configuration/HTTPClient pointer is null")
+	}
+
+	url := fmt.Sprintf("%s/rootcacrl", conf.SCSBaseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRootCACrl: failed to get new request")
+	}
+
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if resp != nil {
+		defer func() {
+			derr := resp.Body.Close()
+			if derr != nil {
+				log.WithError(derr).Error("Error closing root ca crl response")
+			}
+		}()
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRootCACrl: failed to get root ca crl response from scs")
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, errors.New(fmt.Sprintf("NewRootCACrl: Invalid Status code received: %d", resp.StatusCode))
+	}
+
+	crlBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRootCACrl: failed to read root ca crl response body")
+	}
+	return ParseRootCACrl(crlBody)
+}
+
+// ParseRootCACrl parses the base64 DER encoded Intel SGX Root CA CRL
+func ParseRootCACrl(crlBody []byte) (*RootCACrl, error) {
+	crlDer, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(crlBody)))
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRootCACrl: failed to base64 decode crl blob")
+	}
+
+	crlObj, err := x509.ParseDERCRL(crlDer)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewRootCACrl: failed to Parse der encoded crl")
+	}
+	return &RootCACrl{CrlObj: crlObj}, nil
+}
+
+func (e *RootCACrl) GetRootCACrlObj() *pkix.CertificateList {
+	return e.CrlObj
+}
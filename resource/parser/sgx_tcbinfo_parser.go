@@ -5,6 +5,7 @@
 package parser
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/binary"
 	"encoding/json"
@@ -16,7 +17,7 @@ import (
 	"io/ioutil"
 	"math/big"
 	"net/http"
-	"strings"
+	"net/url"
 
 	"github.com/pkg/errors"
 )
@@ -72,8 +73,8 @@ type TcbInfoJSON struct {
 
 type TcbInfoStruct struct {
 	TcbInfoData    TcbInfoJSON
-	RootCA         map[string]*x509.Certificate
-	IntermediateCA map[string]*x509.Certificate
+	RootCA         []*x509.Certificate
+	IntermediateCA []*x509.Certificate
 	RawBlob        []byte
 
 	Conf   *config.Configuration
@@ -83,7 +84,8 @@ type ECDSASignature struct {
 	R, S *big.Int
 }
 
-func NewTcbInfo(fmspc string, conf *config.Configuration, client domain.HttpClient) (*TcbInfoStruct, error) {
+// NewTcbInfo fetches and parses the TCB info of the FMSPC, the request is bound to ctx
+func NewTcbInfo(ctx context.Context, fmspc string, conf *config.Configuration, client domain.HttpClient) (*TcbInfoStruct, error) {
 	var err error
 	if len(fmspc) < constants.FmspcLen {
 		return nil, errors.New("NewTcbInfo: FMSPC value not found")
@@ -92,7 +94,7 @@ func NewTcbInfo(fmspc string, conf *config.Configuration, client domain.HttpClie
 	tcbInfoStruct := new(TcbInfoStruct)
 	tcbInfoStruct.Conf = conf
 	tcbInfoStruct.Client = client
-	err = tcbInfoStruct.getTcbInfoStruct(fmspc)
+	err = tcbInfoStruct.getTcbInfoStruct(ctx, fmspc)
 	if err != nil {
 		return nil, errors.Wrap(err, "NewTcbInfo: Failed to get Tcb Info")
 	}
@@ -101,21 +103,13 @@ func NewTcbInfo(fmspc string, conf *config.Configuration, client domain.HttpClie
 
 func (e *TcbInfoStruct) GetTcbInfoInterCaList() []*x509.Certificate {
 	interMediateCAArr := make([]*x509.Certificate, len(e.IntermediateCA))
-	var i int
-	for _, v := range e.IntermediateCA {
-		interMediateCAArr[i] = v
-		i++
-	}
+	copy(interMediateCAArr, e.IntermediateCA)
 	return interMediateCAArr
 }
 
 func (e *TcbInfoStruct) GetTcbInfoRootCaList() []*x509.Certificate {
 	rootCAArr := make([]*x509.Certificate, len(e.RootCA))
-	var i int
-	for _, v := range e.RootCA {
-		rootCAArr[i] = v
-		i++
-	}
+	copy(rootCAArr, e.RootCA)
 	return rootCAArr
 }
 
@@ -127,10 +121,10 @@ func (e *TcbInfoStruct) GetTcbInfoNextUpdate() string {
 	return e.TcbInfoData.TcbInfo.NextUpdate
 }
 
-func (e *TcbInfoStruct) getTcbInfoStruct(fmspc string) error {
+func (e *TcbInfoStruct) getTcbInfoStruct(ctx context.Context, fmspc string) error {
 
-	url := fmt.Sprintf("%s/tcb", e.Conf.SCSBaseURL)
-	req, err := http.NewRequest("GET", url, nil)
+	scsURL := fmt.Sprintf("%s/tcb", e.Conf.SCSBaseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", scsURL, nil)
 	if err != nil {
 		log.Error("getTcbInfoStruct: req object error")
 		return errors.Wrap(err, "getTcbInfoStruct: Failed to Get http NewRequest")
@@ -168,14 +162,32 @@ func (e *TcbInfoStruct) getTcbInfoStruct(fmspc string) error {
 	if len(content) == 0 {
 		return errors.Wrap(err, "getTcbInfoStruct: no tcbinfo data received")
 	}
+	log.Debug("GetTcbInfoJSON: blob[", resp.ContentLength, "]:", len(content))
 
-	e.RawBlob = make([]byte, len(content))
+	issuerChain, err := url.QueryUnescape(resp.Header.Get("SGX-TCB-Info-Issuer-Chain"))
+	if err != nil {
+		return errors.Wrap(err, "getTcbInfoStruct: failed to get cert object")
+	}
+	return e.parse(content, issuerChain)
+}
 
-	copy(e.RawBlob, content)
+// ParseTcbInfo parses TCB info and its PEM encoded issuer chain
+func ParseTcbInfo(content []byte, issuerChain string) (*TcbInfoStruct, error) {
+	if len(content) == 0 {
+		return nil, errors.New("ParseTcbInfo: TCB info is empty")
+	}
+	tcbInfoStruct := new(TcbInfoStruct)
+	if err := tcbInfoStruct.parse(content, issuerChain); err != nil {
+		return nil, errors.Wrap(err, "ParseTcbInfo: Failed to parse Tcb Info")
+	}
+	return tcbInfoStruct, nil
+}
 
-	log.Debug("GetTcbInfoJSON: blob[", resp.ContentLength, "]:", len(e.RawBlob))
+func (e *TcbInfoStruct) parse(content []byte, issuerChain string) error {
+	e.RawBlob = make([]byte, len(content))
+	copy(e.RawBlob, content)
 
-	certChainList, err := utils.GetCertObjList(resp.Header.Get("SGX-TCB-Info-Issuer-Chain"))
+	certChainList, err := utils.GetCertObjListFromPem(issuerChain)
 	if err != nil {
 		return errors.Wrap(err, "getTcbInfoStruct: failed to get cert object")
 	}
@@ -184,26 +196,20 @@ func (e *TcbInfoStruct) getTcbInfoStruct(fmspc string) error {
 		return errors.Wrap(err, "getTcbInfoStruct: TcbInfo Unmarshal Failed")
 	}
 
-	e.RootCA = make(map[string]*x509.Certificate)
-	e.IntermediateCA = make(map[string]*x509.Certificate)
-
-	var intermediateCACount int
-	var rootCACount int
-	for i := 0; i < len(certChainList); i++ {
-		cert := certChainList[i]
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX Root CA") {
-			rootCACount++
-			e.RootCA[cert.Subject.String()] = cert
-		}
-		if strings.Contains(cert.Subject.String(), "CN=Intel SGX TCB Signing") {
-			intermediateCACount++
-			e.IntermediateCA[cert.Subject.String()] = cert
-		}
-		log.Debug("Cert[", i, "]Issuer:", cert.Issuer.String(), ", Subject:", cert.Subject.String())
+	// the issuer chain is ordered TCB signing CA, root CA, subjects are checked by the verifier
+	chain, err := utils.BuildCertChain(certChainList)
+	if err != nil {
+		return errors.Wrap(err, "getTcbInfoStruct: Invalid TCBInfo CertChain")
 	}
-	if intermediateCACount == 0 || rootCACount == 0 {
-		return errors.Wrap(err, "getTcbInfoStruct: intermediate CA or Root CA is empty")
+	if len(chain) < 2 {
+		return errors.New("getTcbInfoStruct: TCBInfo CertChain has no intermediate CA")
 	}
+	for i := 0; i < len(chain); i++ {
+		log.Debug("Cert[", i, "]Issuer:", chain[i].Issuer.String(), ", Subject:", chain[i].Subject.String())
+	}
+
+	e.IntermediateCA = chain[:len(chain)-1]
+	e.RootCA = chain[len(chain)-1:]
 	return nil
 }
 
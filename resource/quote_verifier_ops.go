@@ -6,26 +6,23 @@
 package resource
 
 import (
-	"crypto/x509"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"fmt"
 	commLogMsg "intel/isecl/lib/common/v5/log/message"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/quoteverifier"
 	"intel/isecl/sqvs/v5/resource/domain"
 	"intel/isecl/sqvs/v5/resource/domain/models"
-	"intel/isecl/sqvs/v5/resource/parser"
-	"intel/isecl/sqvs/v5/resource/utils"
-	"intel/isecl/sqvs/v5/resource/verifier"
-	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/pkg/errors"
 )
 
 type SignedSGXResponse struct {
@@ -61,12 +58,56 @@ func NewSGXQuoteVerifier(conf *config.Configuration, scsClient domain.HttpClient
 	}
 }
 
+// SgxEcdsaQuoteVerifier verifies quotes with the quote verifier built by NewSGXEcdsaQuoteVerifier. A zero
+// SgxEcdsaQuoteVerifier builds a quote verifier from the SCS client and trusted SGX root CA file of each call.
 type SgxEcdsaQuoteVerifier struct {
+	verifier *quoteverifier.Verifier
 }
 
-func NewSGXEcdsaQuoteVerifier() domain.SGXQuoteVerifier {
+// NewSGXEcdsaQuoteVerifier builds the quote verifier from the configuration, the SCS client and the trusted SGX
+// root CA file once, so that the trust anchors are loaded and collateral rollback is tracked across requests.
+// opts are applied in addition to the options derived from the configuration.
+func NewSGXEcdsaQuoteVerifier(conf *config.Configuration, scsClient domain.HttpClient, trustedSGXRootCAFile string,
+	opts ...quoteverifier.Option) (domain.SGXQuoteVerifier, error) {
+	quoteVerifier, err := newQuoteVerifier(conf, scsClient, trustedSGXRootCAFile, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SgxEcdsaQuoteVerifier{verifier: quoteVerifier}, nil
+}
 
-	return &SgxEcdsaQuoteVerifier{}
+// newQuoteVerifier returns a quote verifier with the trust anchors in trustedSGXRootCAFile and the policy of
+// the configuration, fetching collateral with scsClient
+func newQuoteVerifier(conf *config.Configuration, scsClient domain.HttpClient, trustedSGXRootCAFile string,
+	opts ...quoteverifier.Option) (*quoteverifier.Verifier, error) {
+	if conf == nil {
+		conf = &config.Configuration{}
+	}
+	trustAnchors, err := quoteverifier.LoadTrustAnchors(trustedSGXRootCAFile)
+	if err != nil {
+		log.WithError(err).Error("Cannot read SGX CA Cert")
+		return nil, &resourceError{Message: "Cannot read SGX CA Cert", StatusCode: http.StatusBadRequest}
+	}
+
+	verifierOpts := []quoteverifier.Option{
+		quoteverifier.WithTrustAnchors(trustAnchors...),
+		quoteverifier.WithPolicy(quoteverifier.Policy{
+			CollateralExpiryGrace:      conf.CollateralExpiryGrace,
+			Platform:                   quoteverifier.PlatformPolicy(conf.PlatformPolicy),
+			MinTcbEvaluationDataNumber: conf.MinTcbEvaluationDataNumber,
+		}),
+	}
+	verifierOpts = append(verifierOpts, opts...)
+	if scsClient != nil {
+		verifierOpts = append(verifierOpts, quoteverifier.WithCollateralProvider(
+			quoteverifier.NewSCSCollateralProvider(scsClient, conf.SCSBaseURL)))
+	}
+	quoteVerifier, err := quoteverifier.New(verifierOpts...)
+	if err != nil {
+		log.WithError(err).Error("Could not create quote verifier")
+		return nil, &resourceError{Message: "Could not create quote verifier", StatusCode: http.StatusInternalServerError}
+	}
+	return quoteVerifier, nil
 }
 
 func QuoteVerifyCB(router *mux.Router, conf *config.Configuration, scsClient domain.HttpClient,
@@ -74,7 +115,8 @@ func QuoteVerifyCB(router *mux.Router, conf *config.Configuration, scsClient dom
 
 	quoteVerifier := NewSGXQuoteVerifier(conf, scsClient, trustedSGXRootCAFile, sgxQuoteVerifier)
 
-	router.Handle("/sgx_qv_verify_quote", handlers.ContentTypeHandler(quoteVerifier.sgxVerifyQuote(), "application/json")).Methods("POST")
+	router.Handle("/sgx_qv_verify_quote", AuditRejections(constants.EndpointV1)(
+		handlers.ContentTypeHandler(quoteVerifier.sgxVerifyQuote(), "application/json"))).Methods("POST")
 }
 
 func (sqv *SgxQuoteVerifier) sgxVerifyQuote() errorHandlerFunc {
@@ -82,7 +124,7 @@ func (sqv *SgxQuoteVerifier) sgxVerifyQuote() errorHandlerFunc {
 		log.Trace("resource/quote_verifier_ops:sgxVerifyQuote() Entering")
 		defer log.Trace("resource/quote_verifier_ops:sgxVerifyQuote() Leaving")
 
-		if sqv.config.IncludeToken {
+		if sqv.config.AuthorizationEnabled() {
 			err := AuthorizeEndpoint(r, constants.QuoteVerifierGroupName, true)
 			if err != nil {
 				slog.WithError(err).Error("resource/quote_verifier_ops: sgxVerifyQuote() Authorization Error")
@@ -104,14 +146,25 @@ func (sqv *SgxQuoteVerifier) sgxVerifyQuote() errorHandlerFunc {
 			return &resourceError{Message: "Invalid JSON input provided", StatusCode: http.StatusBadRequest}
 		}
 
+		if err := authorizeVerifyRequest(r, models.QuoteDataWithChallenge{QuoteData: data}); err != nil {
+			return err
+		}
+
 		if sqv.SGXQuoteVerifier == nil {
-			sqv.SGXQuoteVerifier = NewSGXEcdsaQuoteVerifier()
+			sqv.SGXQuoteVerifier = &SgxEcdsaQuoteVerifier{}
 		}
 
-		sgxResponse, err := sqv.SGXQuoteVerifier.SgxEcdsaQuoteVerify(models.QuoteDataWithChallenge{
+		ctx, cancel := verificationContext(r.Context(), sqv.config)
+		defer cancel()
+		sgxResponse, err := sqv.SGXQuoteVerifier.SgxEcdsaQuoteVerify(ctx, models.QuoteDataWithChallenge{
 			QuoteData: data,
 		}, sqv.scsClient, sqv.config, sqv.trustedSGXRootCAFile)
+		if err == nil {
+			// the replay status is only part of the v2 response, replays are still rejected in strict mode
+			_, err = checkReplay(sqv.config, data.QuoteBlob)
+		}
 		if err != nil {
+			recordVerification(r, constants.EndpointV1, data.QuoteBlob, sgxResponse, err, nil)
 			return err
 		}
 		quoteResponseBytes, err := json.Marshal(sgxResponse)
@@ -119,6 +172,7 @@ func (sqv *SgxQuoteVerifier) sgxVerifyQuote() errorHandlerFunc {
 			log.WithError(err).Error("Error marshalling SGX response in JSON")
 			return &resourceError{Message: "Error marshalling SGX response in JSON", StatusCode: http.StatusInternalServerError}
 		}
+		recordVerification(r, constants.EndpointV1, data.QuoteBlob, sgxResponse, nil, quoteResponseBytes)
 
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
@@ -132,244 +186,133 @@ func (sqv *SgxQuoteVerifier) sgxVerifyQuote() errorHandlerFunc {
 	}
 }
 
-func (seqv *SgxEcdsaQuoteVerifier) SgxEcdsaQuoteVerify(data models.QuoteDataWithChallenge, scsClient domain.HttpClient, config *config.Configuration,
-	trustedSGXRootCAFile string) (models.SGXResponse, error) {
-	log.Trace("resource/quote_verifier_ops:SgxEcdsaQuoteVerify() Entering")
-	log.Trace("resource/quote_verifier_ops:SgxEcdsaQuoteVerify() Leaving")
-	skcBlobParsed := parser.ParseQuoteBlob(data.QuoteBlob)
-	if skcBlobParsed == nil {
-		log.Error("Could not parse sgx ecdsa quote")
-		return models.SGXResponse{}, &resourceError{Message: "Could not parse sgx ecdsa quote",
-			StatusCode: http.StatusBadRequest}
-	}
-
-	quoteObj := parser.NewSGXQuoteParser(skcBlobParsed.GetQuoteBlob())
-
-	pckCertBytes, err := utils.GetCertPemData(quoteObj.GetQuotePckCertObj())
-	if err != nil {
-		log.WithError(err).Error("Cannot extract PCK cert data")
-		return models.SGXResponse{}, &resourceError{Message: "Cannot extract PCK cert data",
-			StatusCode: http.StatusBadRequest}
+// verificationContext bounds the verification of a quote by the verification timeout of the configuration
+func verificationContext(ctx context.Context, conf *config.Configuration) (context.Context, context.CancelFunc) {
+	if conf == nil || conf.VerificationTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, conf.VerificationTimeout)
+}
 
-	certObj := parser.NewPCKCertObj(pckCertBytes, scsClient, config)
-	if certObj == nil {
-		return models.SGXResponse{}, &resourceError{Message: "Invalid PCK Certificate Buffer", StatusCode: http.StatusBadRequest}
-	}
+func (seqv *SgxEcdsaQuoteVerifier) SgxEcdsaQuoteVerify(ctx context.Context, data models.QuoteDataWithChallenge,
+	scsClient domain.HttpClient, conf *config.Configuration, trustedSGXRootCAFile string) (models.SGXResponse, error) {
+	log.Trace("resource/quote_verifier_ops:SgxEcdsaQuoteVerify() Entering")
+	defer log.Trace("resource/quote_verifier_ops:SgxEcdsaQuoteVerify() Leaving")
 
-	sgxCaCert, err := readSGXRootCaCert(trustedSGXRootCAFile)
-	if err != nil {
-		log.WithError(err).Error("Cannot read SGX CA Cert")
-		return models.SGXResponse{}, &resourceError{Message: "Cannot read SGX CA Cert",
-			StatusCode: http.StatusBadRequest}
+	if conf == nil {
+		conf = &config.Configuration{}
 	}
 
-	err = verifier.VerifyPCKCertificate(quoteObj.GetQuotePckCertObj(), quoteObj.GetQuotePckCertInterCAList(),
-		quoteObj.GetQuotePckCertRootCAList(), certObj.GetPckCrlObj(), sgxCaCert)
-	if err != nil {
-		log.WithError(err).Error("Cannot verify pck cert")
-		return models.SGXResponse{}, &resourceError{Message: "Cannot verify pck cert",
-			StatusCode: http.StatusBadRequest}
+	var verificationTime time.Time
+	if data.VerificationTime != "" {
+		var err error
+		verificationTime, err = time.Parse(time.RFC3339, data.VerificationTime)
+		if err != nil {
+			log.WithError(err).Error("Invalid verification time")
+			return models.SGXResponse{}, &resourceError{Message: "Invalid verificationTime, expected RFC3339 format",
+				StatusCode: http.StatusBadRequest}
+		}
 	}
 
-	log.Info("PCK Certificate Chain Verified")
-	err = verifier.VerifyPckCrl(certObj.GetPckCrlURL(), certObj.GetPckCrlObj(), certObj.GetPckCrlInterCaList(),
-		certObj.GetPckCrlRootCaList(), sgxCaCert)
+	quote, err := base64.StdEncoding.DecodeString(data.QuoteBlob)
 	if err != nil {
-		log.WithError(err).Error("Cannot verify PCK crl")
-		return models.SGXResponse{}, &resourceError{Message: "Cannot verify PCK crl",
+		log.WithError(err).Error("Could not parse sgx ecdsa quote")
+		return models.SGXResponse{}, &resourceError{Message: "Could not parse sgx ecdsa quote",
 			StatusCode: http.StatusBadRequest}
 	}
 
-	log.Info("PCK Certificates checked against PCK Certificate Revocation List")
-	tcbObj, err := parser.NewTcbInfo(certObj.GetFmspcValue(), config, scsClient)
-	if err != nil {
-		log.WithError(err).Error("Get TCB Info data parsing/fetch failed")
-		return models.SGXResponse{}, &resourceError{Message: "Get TCB Info data parsing/fetch failed",
-			StatusCode: http.StatusInternalServerError}
-	}
-
-	err = verifyTcbInfo(certObj, tcbObj, sgxCaCert)
-	if err != nil {
-		log.WithError(err).Error("TCBInfo Verification failed")
-		return models.SGXResponse{}, &resourceError{Message: "TCBInfo Verification failed",
-			StatusCode: http.StatusInternalServerError}
-	}
-
-	log.Info("TCBInfo Structure Verified")
-	tcbUptoDateStatus := tcbObj.GetTcbUptoDateStatus(certObj.GetPckCertTcbLevels())
-	log.Info("Current Tcb-Upto-Date Status is : ", tcbUptoDateStatus)
-
-	qeIDObj, err := parser.NewQeIdentity(config, scsClient)
-	if err != nil {
-		log.WithError(err).Error("QEIdentity Parsing failed")
-		return models.SGXResponse{}, &resourceError{Message: "QEIdentity Parsing failed",
-			StatusCode: http.StatusInternalServerError}
-	}
-
-	err = verifyQeIdentity(qeIDObj, quoteObj, sgxCaCert)
-	if err != nil {
-		log.WithError(err).Error("verifyQeIdentity failed")
-		return models.SGXResponse{}, &resourceError{Message: "Verification of QeIdentity failed",
-			StatusCode: http.StatusInternalServerError}
+	opts := quoteverifier.VerifyOptions{
+		ReportDataBinding: data.ReportDataBinding,
+		StrictReportData:  data.StrictReportData,
+		VerificationTime:  verificationTime,
+		Collateral:        data.Collateral,
 	}
-	log.Info("QEIdentity Structure Verified")
-	hashMatched := false
-
+	// user data which can not be decoded does not match the report data in the quote
+	userDataDecoded := true
 	if data.UserData != "" {
-		data, err := base64.StdEncoding.DecodeString(data.UserData)
-		if err != nil {
-			log.Error("Failed to Base64 Decode User Data")
+		opts.UserData, err = base64.StdEncoding.DecodeString(data.UserData)
+		if err == nil && data.ReportDataBinding == constants.ReportDataBindingNoncePubKey && data.Nonce != "" {
+			opts.Nonce, err = base64.StdEncoding.DecodeString(data.Nonce)
 		}
-		err = verifier.VerifySHA256Hash(quoteObj.GetSHA256Hash(), data)
 		if err != nil {
-			log.Error(err.Error())
-		} else {
-			hashMatched = true
-			log.Info("User Data Hash matches with the one in quote")
+			log.WithError(err).Error("User Data does not match the report data in quote")
+			if data.StrictReportData {
+				return models.SGXResponse{}, &resourceError{Message: "User Data does not match the report data in quote",
+					StatusCode: http.StatusBadRequest}
+			}
+			userDataDecoded = false
+			opts.UserData, opts.Nonce = nil, nil
 		}
 	}
 
-	repBlob, err := quoteObj.GetHeaderAndEnclaveReportBlob()
-	if err != nil {
-		log.WithError(err).Error("Invalid Header and Enclave Report Blob in SGX ECDSA Quote")
-		return models.SGXResponse{}, &resourceError{Message: "Invalid Header and Enclave Report Blob in SGX ECDSA Quote",
-			StatusCode: http.StatusInternalServerError}
-	}
-
-	err = verifier.VerifyEnclaveReportSignature(quoteObj.GetEnclaveReportSignature(), repBlob, quoteObj.GetAttestationPublicKey())
-	if err != nil {
-		log.WithError(err).Error("Enclave Report Signature Verification failed")
-		return models.SGXResponse{}, &resourceError{Message: "Enclave Report Signature Verification failed",
-			StatusCode: http.StatusInternalServerError}
+	quoteVerifier := seqv.verifier
+	if quoteVerifier == nil {
+		quoteVerifier, err = newQuoteVerifier(conf, scsClient, trustedSGXRootCAFile)
+		if err != nil {
+			return models.SGXResponse{}, err
+		}
 	}
 
-	log.Info("Enclave Report Signature Verified")
-	qeBlob, err := quoteObj.GetQeReportBlob()
+	result, err := quoteVerifier.Verify(ctx, quote, opts)
 	if err != nil {
-		log.Error(err.Error())
-		return models.SGXResponse{}, &resourceError{Message: "Invalid QE Report Blob in SGX ECDSA Quote",
-			StatusCode: http.StatusInternalServerError}
+		return models.SGXResponse{}, newVerifyError(err)
 	}
-	err = verifier.VerifyQeReportSignature(quoteObj.GetQeReportSignature(), qeBlob, certObj.GetPCKPublicKey())
-	if err != nil {
-		log.WithError(err).Error("QE Report Signature Verification failed")
-		return models.SGXResponse{}, &resourceError{Message: "QE Report Signature Verification failed",
-			StatusCode: http.StatusInternalServerError}
-	}
-	log.Info("QE Report Signature Verified")
 
 	var resp models.SGXResponse
 	resp.Message = "SGX_QL_QV_RESULT_OK"
 	if data.UserData != "" {
-		resp.UserDataHashMatch = strconv.FormatBool(hashMatched)
-	}
-	resp.ReportData = fmt.Sprintf("%02x", quoteObj.GetSHA256Hash())
-	resp.EnclaveIssuer = fmt.Sprintf("%02x", quoteObj.GetEnclaveMrSigner())
-	resp.EnclaveIssuerProdID = fmt.Sprintf("%02x", quoteObj.GetEnclaveReportProdID())
-	resp.EnclaveMeasurement = fmt.Sprintf("%02x", quoteObj.GetEnclaveReportMrEnclave())
-	resp.IsvSvn = fmt.Sprintf("%02x", quoteObj.GetEnclaveReportIsvSvn())
-	resp.TcbLevel = tcbUptoDateStatus
-
-	log.Info("Sgx Ecdsa Quote Verification completed")
+		resp.UserDataHashMatch = strconv.FormatBool(userDataDecoded && result.UserDataMatch)
+	}
+	resp.ReportData = result.EnclaveReport.ReportData[:2*sha256.Size]
+	resp.EnclaveIssuer = result.EnclaveReport.MrSigner
+	resp.EnclaveIssuerProdID = fmt.Sprintf("%02x", result.EnclaveReport.IsvProdID)
+	resp.EnclaveMeasurement = result.EnclaveReport.MrEnclave
+	resp.IsvSvn = fmt.Sprintf("%02x", result.EnclaveReport.IsvSvn)
+	resp.TcbLevel = result.TcbStatus
+	resp.Fmspc = result.Platform.Fmspc
+	resp.EnclaveReport = &result.EnclaveReport
+	resp.Platform = &result.Platform
+	if !conf.IncludePPID {
+		resp.Platform.PPID = ""
+	}
+	resp.PlatformPolicy = result.PlatformPolicy
+	if data.VerificationTime != "" {
+		resp.VerificationTime = result.VerificationTime.UTC().Format(time.RFC3339)
+	}
+	resp.CollateralExpired = result.CollateralExpired
+	resp.CollateralExpiry = result.CollateralExpiry.UTC().Format(time.RFC3339)
+	resp.TcbEvaluationDataNumber = result.Collateral.TcbEvaluationDataNumber
+	resp.QeIdentityTcbEvaluationDataNumber = result.Collateral.QeIdentityEvaluationNumber
+	resp.Collateral = &result.Collateral
 
 	return resp, nil
 }
 
-func verifyQeIdentityReport(qeIdObj *parser.QeIdentityData, quoteObj domain.SGXQuoteParser) error {
-	log.Trace("resource/quote_verifier_ops:verifyQeIdentityReport() Entering")
-	log.Trace("resource/quote_verifier_ops:verifyQeIdentityReport() Leaving")
-
-	err := verifier.VerifyMiscSelect(quoteObj.GetQeReportMiscSelect(), qeIdObj.GetQeIDMiscSelect(),
-		qeIdObj.GetQeIDMiscSelectMask())
-	if err != nil {
-		return errors.Wrap(err, "verifyQeIdentityReport: ")
-	}
-
-	err = verifier.VerifyAttributes(quoteObj.GetQeReportAttributes(), qeIdObj.GetQeIDAttributes(),
-		qeIdObj.GetQeIDAttributesMask())
-	if err != nil {
-		return errors.Wrap(err, "verifyQeIdentityReport:")
-	}
-
-	err = verifier.VerifyReportAttrSize(quoteObj.GetQeReportMrSigner(), "MrSigner", qeIdObj.GetQeIDMrSigner())
-	if err != nil {
-		return errors.Wrap(err, "verifyQeIdentityReport")
-	}
-
-	if quoteObj.GetQeReportProdID() < qeIdObj.GetQeIDIsvProdID() {
-		log.Info("Qe Prod Id in ecdsa quote is below the minimum prod id expected for QE")
-	}
-
-	if quoteObj.GetQeReportIsvSvn() < qeIdObj.GetQeIDIsvSvn() {
-		log.Info("IsvSvn in ecdsa quote is below the minimum IsvSvn expected for QE")
-	}
-	return nil
-}
-
-func verifyQeIdentity(qeIDObj *parser.QeIdentityData, quoteObj domain.SGXQuoteParser,
-	trustedRootCA *x509.Certificate) error {
-	log.Trace("resource/quote_verifier_ops:verifyQeIdentity() Entering")
-	log.Trace("resource/quote_verifier_ops:verifyQeIdentity() Leaving")
-
-	if qeIDObj == nil || quoteObj == nil {
-		return errors.New("verifyQeIdentity: QEIdentity/Quote Object is empty")
-	}
-	err := verifier.VerifyQeIDCertChain(qeIDObj.GetQeInfoInterCaList(), qeIDObj.GetQeInfoRootCaList(),
-		trustedRootCA)
-	if err != nil {
-		return errors.Wrap(err, "verifyQeIdentity: VerifyQeIDCertChain")
-	}
-
-	status := qeIDObj.GetQeIdentityStatus()
-	if !status {
-		return errors.New("verifyQeIdentity: GetQeIdentityStatus is invalid")
-	}
-
-	if !utils.CheckDate(qeIDObj.GetQeIDIssueDate(), qeIDObj.GetQeIDNextUpdate()) {
-		return errors.New("verifyQeIdentity: Date Check validation failed")
-	}
-
-	return verifyQeIdentityReport(qeIDObj, quoteObj)
-}
-
-func verifyTcbInfo(certObj domain.PCKCertParser, tcbObj *parser.TcbInfoStruct, trustedRootCA *x509.Certificate) error {
-	log.Trace("resource/quote_verifier_ops:verifyTcbInfo() Entering")
-	log.Trace("resource/quote_verifier_ops:verifyTcbInfo() Leaving")
-
-	if tcbObj.GetTcbInfoFmspc() != certObj.GetFmspcValue() {
-		return errors.New("verifyTcbInfo: FMSPC in TCBInfoStruct does not match with PCK Cert FMSPC")
-	}
-
-	err := verifier.VerifyTcbInfoCertChain(tcbObj.GetTcbInfoInterCaList(), tcbObj.GetTcbInfoRootCaList(),
-		trustedRootCA)
-	if err != nil {
-		return errors.Wrap(err, "verifyTcbInfo: failed to verify Tcbinfo Certchain")
-	}
-
-	if !utils.CheckDate(tcbObj.GetTcbInfoIssueDate(), tcbObj.GetTcbInfoNextUpdate()) {
-		return errors.New("verifyTcbInfo: Date Check validation failed")
-	}
-
-	return nil
+// verifyErrorStatus maps the kinds of verification errors to the HTTP status codes returned for them
+var verifyErrorStatus = map[quoteverifier.ErrorKind]int{
+	quoteverifier.ErrInvalidRequest:        http.StatusBadRequest,
+	quoteverifier.ErrInvalidQuote:          http.StatusBadRequest,
+	quoteverifier.ErrCollateralUnavailable: http.StatusInternalServerError,
+	quoteverifier.ErrCollateralInvalid:     http.StatusInternalServerError,
+	quoteverifier.ErrUntrusted:             http.StatusBadRequest,
+	quoteverifier.ErrRevoked:               http.StatusBadRequest,
+	quoteverifier.ErrPolicy:                http.StatusBadRequest,
+	quoteverifier.ErrSignature:             http.StatusInternalServerError,
+	quoteverifier.ErrCanceled:              http.StatusServiceUnavailable,
 }
 
-func readSGXRootCaCert(trustedSGXRootCAFile string) (*x509.Certificate, error) {
-	log.Trace("resource/quote_verifier_ops:readSGXRootCaCert() Entering")
-	log.Trace("resource/quote_verifier_ops:readSGXRootCaCert() Leaving")
-
-	certBytes, err := ioutil.ReadFile(trustedSGXRootCAFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "readSGXRootCaCert: error reading SGX CA certificate")
+// newVerifyError converts an error returned by the quote verifier into a resourceError
+func newVerifyError(err error) error {
+	verifyErr, ok := err.(*quoteverifier.Error)
+	if !ok {
+		return &resourceError{Message: "Quote verification failed", StatusCode: http.StatusInternalServerError}
 	}
-	pemBlock, _ := pem.Decode(certBytes)
-	if pemBlock == nil {
-		return nil, errors.New("readSGXRootCaCert: Pem Decode error")
+	statusCode, ok := verifyErrorStatus[verifyErr.Kind]
+	if !ok {
+		statusCode = http.StatusInternalServerError
 	}
-	x509Cert, err := x509.ParseCertificate(pemBlock.Bytes)
-	if err != nil {
-		return nil, errors.Wrap(err, "readSGXRootCaCert: error parsing SGX CA certificate")
+	if verifyErr.Kind == quoteverifier.ErrCanceled && verifyErr.Err == context.DeadlineExceeded {
+		statusCode = http.StatusGatewayTimeout
 	}
-
-	return x509Cert, nil
+	return &resourceError{Message: verifyErr.Message, StatusCode: statusCode}
 }
@@ -10,8 +10,10 @@ import (
 	commLogMsg "intel/isecl/lib/common/v5/log/message"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/replay"
 	"intel/isecl/sqvs/v5/resource/domain"
 	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/parser"
 	"intel/isecl/sqvs/v5/resource/utils"
 	"io/ioutil"
 	"net/http"
@@ -54,7 +56,7 @@ func (sqvcs *SgxQuoteVerifierCBAndSign) sgxVerifyQuoteAndSign() errorHandlerFunc
 		log.Trace("resource/quote_verifier_ops:sgxVerifyQuoteAndSign() Entering")
 		defer log.Trace("resource/quote_verifier_ops:sgxVerifyQuoteAndSign() Leaving")
 
-		if sqvcs.config.IncludeToken {
+		if sqvcs.config.AuthorizationEnabled() {
 			err := AuthorizeEndpoint(r, constants.QuoteVerifierGroupName, true)
 			if err != nil {
 				slog.WithError(err).Error("resource/quote_verifier_ops: sgxVerifyQuoteAndSign() Authorization Error")
@@ -76,72 +78,159 @@ func (sqvcs *SgxQuoteVerifierCBAndSign) sgxVerifyQuoteAndSign() errorHandlerFunc
 			return &resourceError{Message: "Invalid JSON input provided", StatusCode: http.StatusInternalServerError}
 		}
 
-		if sqvcs.SGXQuoteVerifier == nil {
-			slog.Error("resource/quote_verifier_ops: sgxVerifyQuoteAndSign() SGX quote verifier was not provided")
-			return &resourceError{Message: "Invalid quote verifier", StatusCode: http.StatusBadRequest}
+		resp, err := sqvcs.verifyQuoteAndSign(r, constants.EndpointV2, data)
+		if err != nil {
+			return err
 		}
 
-		sgxResponse, err := sqvcs.SGXQuoteVerifier.SgxEcdsaQuoteVerify(data, sqvcs.scsClient, sqvcs.config, sqvcs.trustedSGXRootCAFile)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		w.WriteHeader(http.StatusOK)
 
-		var quoteResponseBytes []byte
-		if strings.TrimSpace(data.Challenge) != "" && sqvcs.config.SignQuoteResponse {
-			if err != nil {
-				sgxResponse.Message = err.Error()
-			}
-			log.Info("SgxEcdsaQuoteVerify: Signing the quote response")
-			sgxResponse.Quote = data.QuoteBlob
-			sgxResponse.Challenge = data.Challenge
+		_, err = w.Write(resp.Body)
+		if err != nil {
+			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+		}
 
-			dataBytes, err := json.Marshal(QuoteInfo(sgxResponse))
-			if err != nil {
-				return &resourceError{Message: "Failed to marshal hostPlatformData to get trustReport" +
-					err.Error(), StatusCode: http.StatusInternalServerError}
-			}
+		return nil
+	}
+}
 
-			signature, err := utils.GenerateSignature([]byte(base64.StdEncoding.EncodeToString(dataBytes)), sqvcs.PrivateKeyLocation, sqvcs.config.UsePSSPadding)
-			if err != nil {
-				return &resourceError{Message: "Failed to get signature for QVL response: " + err.Error(),
-					StatusCode: http.StatusInternalServerError}
-			}
+// v2QuoteResponse is the response of a v2 quote verification. QuoteData is the JSON encoded quote info,
+// Signature and CertificateChain are only set for signed responses. Body is the REST response body.
+type v2QuoteResponse struct {
+	QuoteData        []byte
+	Signature        string
+	CertificateChain string
+	Body             []byte
+}
 
-			certChain, err := ioutil.ReadFile(sqvcs.PublicKeyLocation)
-			if err != nil {
-				log.WithError(err).Error("Error reading signing public key from file")
-				return &resourceError{Message: "Error reading signing public key from file",
-					StatusCode: http.StatusInternalServerError}
-			}
+func (resp v2QuoteResponse) marshal() ([]byte, error) {
+	if resp.Signature != "" {
+		return json.Marshal(SignedSGXResponse{
+			QuoteData:        base64.StdEncoding.EncodeToString(resp.QuoteData),
+			Signature:        resp.Signature,
+			CertificateChain: resp.CertificateChain,
+		})
+	}
+	return json.Marshal(struct {
+		QuoteData json.RawMessage `json:"quoteData"`
+	}{QuoteData: resp.QuoteData})
+}
 
-			quoteResponseBytes, err = json.Marshal(SignedSGXResponse{
-				QuoteData:        base64.StdEncoding.EncodeToString(dataBytes),
-				Signature:        signature,
-				CertificateChain: string(certChain),
-			})
-			if err != nil {
-				log.WithError(err).Error("Error marshalling signed SGX response in JSON")
-				return &resourceError{Message: "Error marshalling signed SGX response in JSON", StatusCode: http.StatusInternalServerError}
-			}
-		} else {
-			if err != nil {
-				return err
-			}
-			quoteResponseBytes, err = json.Marshal(UnsignedSGXResponse{
-				QuoteData: QuoteInfo(sgxResponse),
-			})
-			if err != nil {
-				log.WithError(err).Error("Error marshalling SGX response in JSON")
-				return &resourceError{Message: "Error marshalling SGX response in JSON", StatusCode: http.StatusInternalServerError}
-			}
+// verifyQuoteAndSign verifies the quote and signs the response when a challenge is given and response signing
+// is enabled. The verification decision is recorded in the audit log for the endpoint.
+func (sqvcs *SgxQuoteVerifierCBAndSign) verifyQuoteAndSign(r *http.Request, endpoint string,
+	data models.QuoteDataWithChallenge) (v2QuoteResponse, error) {
+	var resp v2QuoteResponse
+	if sqvcs.SGXQuoteVerifier == nil {
+		slog.Error("resource/quote_verifier_ops: verifyQuoteAndSign() SGX quote verifier was not provided")
+		return resp, &resourceError{Message: "Invalid quote verifier", StatusCode: http.StatusBadRequest}
+	}
+	if err := authorizeVerifyRequest(r, data); err != nil {
+		return resp, err
+	}
+
+	// the quote verifier uses the collateral bundle in place of SCS, it is validated here to reject
+	// incomplete bundles before verification
+	collateralSource := constants.CollateralSourceSCS
+	if data.Collateral != nil {
+		if _, err := domain.NewCollateralBundleClient(data.Collateral); err != nil {
+			slog.WithError(err).Errorf("resource/quote_verifier_ops: verifyQuoteAndSign() %s: Invalid collateral "+
+				"bundle", commLogMsg.InvalidInputBadParam)
+			return resp, &resourceError{Message: "Invalid collateral bundle: " + err.Error(), StatusCode: http.StatusBadRequest}
 		}
+		collateralSource = constants.CollateralSourceRequest
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
-		w.WriteHeader(http.StatusOK)
+	ctx, cancel := verificationContext(r.Context(), sqvcs.config)
+	sgxResponse, verifyErr := sqvcs.SGXQuoteVerifier.SgxEcdsaQuoteVerify(ctx, data, sqvcs.scsClient, sqvcs.config,
+		sqvcs.trustedSGXRootCAFile)
+	cancel()
+	sgxResponse.CollateralSource = collateralSource
+	if verifyErr == nil {
+		sgxResponse.ReplayStatus, verifyErr = checkReplay(sqvcs.config, data.QuoteBlob)
+	}
+
+	var err error
+	if strings.TrimSpace(data.Challenge) != "" && sqvcs.config.SignQuoteResponse {
+		if verifyErr != nil {
+			sgxResponse.Message = verifyErr.Error()
+		}
+		log.Info("SgxEcdsaQuoteVerify: Signing the quote response")
+		sgxResponse.Quote = data.QuoteBlob
+		sgxResponse.Challenge = data.Challenge
 
-		_, err = w.Write(quoteResponseBytes)
+		resp.QuoteData, err = json.Marshal(QuoteInfo(sgxResponse))
 		if err != nil {
-			return &resourceError{Message: err.Error(), StatusCode: http.StatusInternalServerError}
+			return resp, &resourceError{Message: "Failed to marshal hostPlatformData to get trustReport" +
+				err.Error(), StatusCode: http.StatusInternalServerError}
 		}
 
-		return nil
+		resp.Signature, err = utils.GenerateSignature([]byte(base64.StdEncoding.EncodeToString(resp.QuoteData)), sqvcs.PrivateKeyLocation, sqvcs.config.UsePSSPadding)
+		if err != nil {
+			return resp, &resourceError{Message: "Failed to get signature for QVL response: " + err.Error(),
+				StatusCode: http.StatusInternalServerError}
+		}
+
+		certChain, err := ioutil.ReadFile(sqvcs.PublicKeyLocation)
+		if err != nil {
+			log.WithError(err).Error("Error reading signing public key from file")
+			return resp, &resourceError{Message: "Error reading signing public key from file",
+				StatusCode: http.StatusInternalServerError}
+		}
+		resp.CertificateChain = string(certChain)
+	} else {
+		if verifyErr != nil {
+			recordVerification(r, endpoint, data.QuoteBlob, sgxResponse, verifyErr, nil)
+			return resp, verifyErr
+		}
+		resp.QuoteData, err = json.Marshal(QuoteInfo(sgxResponse))
+		if err != nil {
+			log.WithError(err).Error("Error marshalling SGX response in JSON")
+			return resp, &resourceError{Message: "Error marshalling SGX response in JSON", StatusCode: http.StatusInternalServerError}
+		}
+	}
+
+	resp.Body, err = resp.marshal()
+	if err != nil {
+		log.WithError(err).Error("Error marshalling SGX response in JSON")
+		return resp, &resourceError{Message: "Error marshalling SGX response in JSON", StatusCode: http.StatusInternalServerError}
+	}
+	recordVerification(r, endpoint, data.QuoteBlob, sgxResponse, verifyErr, resp.Body)
+	return resp, nil
+}
+
+// checkReplay returns whether the verified quote has been verified before, its replay status, when replay
+// detection is enabled. Replayed quotes are rejected in strict mode.
+func checkReplay(conf *config.Configuration, quoteBlob string) (string, error) {
+	cache := replay.Default()
+	if cache == nil {
+		return "", nil
+	}
+	quote, err := base64.StdEncoding.DecodeString(quoteBlob)
+	if err != nil {
+		return "", &resourceError{Message: "Invalid SGX ECDSA Quote", StatusCode: http.StatusBadRequest}
+	}
+	// the report data is taken from the verified quote itself, quotes which do not parse are only identified
+	// by the quote
+	var reportData []byte
+	if quoteObj := parser.NewSGXQuoteParser(quote); quoteObj != nil {
+		reportData = quoteObj.GetReportData()
+	}
+
+	replayed, err := cache.Check(quote, reportData)
+	if err != nil {
+		log.WithError(err).Error("Could not check the quote for replay")
+		return "", &resourceError{Message: "Could not check the quote for replay", StatusCode: http.StatusInternalServerError}
+	}
+	if !replayed {
+		return constants.ReplayStatusFirstSeen, nil
+	}
+	slog.Warn("resource/quote_verifier_ops: checkReplay() Quote has been verified before")
+	if conf != nil && conf.Replay.Strict {
+		return constants.ReplayStatusReplayed, &resourceError{Message: "Quote has been verified before",
+			StatusCode: http.StatusConflict}
 	}
+	return constants.ReplayStatusReplayed, nil
 }
@@ -6,19 +6,26 @@ package resource
 
 import (
 	"bytes"
+	"encoding/json"
 	"intel/isecl/lib/common/v5/context"
 	"intel/isecl/lib/common/v5/types/aas"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/replay"
 	"intel/isecl/sqvs/v5/resource/domain/mocks"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	consts "github.com/intel-secl/intel-secl/v5/pkg/lib/common/constants"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -258,6 +265,157 @@ var _ = Describe("SgxQuoteVerifierCBAndSign Resource Validation", func() {
 				Expect(w.Code).To(Equal(http.StatusInternalServerError))
 			})
 
+			It("Should report caller supplied collateral as collateral source", func() {
+
+				testConfig.SignQuoteResponse = false
+				QuoteVerifyCBAndSign(router, testConfig, scsClient, trustedSGXRootCA, sgxQuoteVerifier, privateKeyLocation, pubKeyLocation)
+				testQuote := []byte(`{
+						"quote": "cXVvdGU=",
+						"collateral": {
+							"pckCrl": "Y3Js",
+							"pckCrlIssuerChain": "chain",
+							"rootCaCrl": "Y3Js",
+							"tcbInfo": "{}",
+							"tcbInfoIssuerChain": "chain",
+							"qeIdentity": "{}",
+							"qeIdentityIssuerChain": "chain"
+						}
+					}`)
+				req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", bytes.NewReader(testQuote))
+				Expect(err).NotTo(HaveOccurred())
+
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName, Context: "type=SQVS"}}
+				req = context.SetUserRoles(req, roleInfo)
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(ContainSubstring(`"CollateralSource":"REQUEST"`))
+			})
+
+			It("Should verify with caller supplied collateral without calling SCS", func() {
+
+				// the bundle holds the collateral the SCS mock serves, scs fails the test if it is ever called
+				bundle := &models.Collateral{}
+				for _, path := range []string{"/pckcrl?ca=platform", "/tcb?fmspc=00906ED50000", "/qe/identity"} {
+					req, err := http.NewRequest(http.MethodGet, testConfig.SCSBaseURL+path, nil)
+					Expect(err).NotTo(HaveOccurred())
+					resp, err := scsClient.Do(req)
+					Expect(err).NotTo(HaveOccurred())
+					body, err := ioutil.ReadAll(resp.Body)
+					Expect(err).NotTo(HaveOccurred())
+					for header, value := range resp.Header {
+						chain, err := url.QueryUnescape(value[0])
+						Expect(err).NotTo(HaveOccurred())
+						switch header {
+						case "Sgx-Pck-Crl-Issuer-Chain":
+							bundle.PckCrl, bundle.RootCaCrl, bundle.PckCrlIssuerChain = string(body), string(body), chain
+						case "Sgx-Tcb-Info-Issuer-Chain":
+							bundle.TcbInfo, bundle.TcbInfoIssuerChain = string(body), chain
+						case "Sgx-Qe-Identity-Issuer-Chain":
+							bundle.QeIdentity, bundle.QeIdentityIssuerChain = string(body), chain
+						}
+					}
+				}
+
+				scs := &failingSCSClient{}
+				testConfig.SignQuoteResponse = false
+				sgxEcdsaQuoteVerifier, err := NewSGXEcdsaQuoteVerifier(testConfig, scs, trustedSGXRootCA)
+				Expect(err).NotTo(HaveOccurred())
+				QuoteVerifyCBAndSign(router, testConfig, scs, trustedSGXRootCA, sgxEcdsaQuoteVerifier, privateKeyLocation, pubKeyLocation)
+				verify := func(collateral *models.Collateral) *httptest.ResponseRecorder {
+					body, err := json.Marshal(models.QuoteDataWithChallenge{
+						QuoteData:  models.QuoteData{QuoteBlob: "AwACAAAAAAAFAAoAk5pyM/ecTKmUCg2zlX8GB1ePHvTyaJq7KWtZvEB5i5QAAAAAAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAABwAAAAAAAADnAAAAAAAAAK1GdJ7UHrqiMnJSBB7nRtN5Gp8kMYMP7giD95k8rzFqAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACD1xnnferKFHD2uvYqTXdDA8iZ22kCD5xw7h38CMfOngAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAU850rHdoyZhtjHHze/xDF6e/hNwogmoRd40iZZB/v+AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA1BAAAGp1IMlI7P+lVMltAJ3xTyeLmrqsZgK/0WBajiIPqCrhxAagIIu0l+QPoAuYmEmHm4oBrgjHhUspUmzqguHHofFM5sfwb/QU4hRFUhtwVAno0GAfyGz8nHVy64xAtRNnv7Vvk/GjislKD73UamghpdNaH5pz0/u5JhOp37YoDNVfAgIAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAFQAAAAAAAADnAAAAAAAAAGDYWvKL6NHECgjZiwCdX4rME4Sjhc9GCADkeHkdGpecAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAACMT1d115ZQPpYTf3fGioKaAFasje1wFAsIGwlEkMV7/wAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEABQAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAADNWDh6dvJehw5sQSZBtNlOVBGafQaMeOQkvnxUAIAuYgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAhguSX/JsCRh+Rjbg+dTLhT3/rzHPoMboaUH2fSWNyk7h+hUPh2QloKd8slEi8ZPnXYzzhcYXqTUXwlGHkr3nkiAAAAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8FAGwOAAAtLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJRTlEQ0NCSnFnQXdJQkFnSVVkK3p1Yi94WlhaSVZtd0d6MXFDUzBVcG9sNlF3Q2dZSUtvWkl6ajBFQXdJd2NERWlNQ0FHQTFVRQpBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2dRMjl5Y0c5eVlYUnBiMjR4CkZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTE1Ba0dBMVVFQmhNQ1ZWTXdIaGNOTWpFd016QTUKTURZek5USTJXaGNOTWpnd016QTVNRFl6TlRJMldqQndNU0l3SUFZRFZRUUREQmxKYm5SbGJDQlRSMWdnVUVOTElFTmxjblJwWm1sagpZWFJsTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JEYjNKd2IzSmhkR2x2YmpFVU1CSUdBMVVFQnd3TFUyRnVkR0VnUTJ4aGNtRXhDekFKCkJnTlZCQWdNQWtOQk1Rc3dDUVlEVlFRR0V3SlZVekJaTUJNR0J5cUdTTTQ5QWdFR0NDcUdTTTQ5QXdFSEEwSUFCTXhuYWJ0c0VxRlUKblNvVE50Y0kraG1xQlA3eXcvR2FldlllS3UzTVNsc21ZQVloc0RuNWNTczRObFNabkJWQ1F4NU9XaWpHNTUrZUd3QTJzWHRCZ2VhagpnZ01RTUlJREREQWZCZ05WSFNNRUdEQVdnQlJaSTlPblNxaGpWQzQ1Y0szZ0R3Y3JWeVFxdHpCdkJnTlZIUjhFYURCbU1HU2dZcUJnCmhsNW9kSFJ3Y3pvdkwzTmllQzVoY0drdWRISjFjM1JsWkhObGNuWnBZMlZ6TG1sdWRHVnNMbU52YlM5elozZ3ZZMlZ5ZEdsbWFXTmgKZEdsdmJpOTJNeTl3WTJ0amNtdy9ZMkU5Y0d4aGRHWnZjbTBtWlc1amIyUnBibWM5WkdWeU1CMEdBMVVkRGdRV0JCU2lMS2JLVHFNSgpvSHd2K01iRjQ2NmNsUGNQWXpBT0JnTlZIUThCQWY4RUJBTUNCc0F3REFZRFZSMFRBUUgvQkFJd0FEQ0NBamtHQ1NxR1NJYjRUUUVOCkFRU0NBaW93Z2dJbU1CNEdDaXFHU0liNFRRRU5BUUVFRUNDdm84ait5MGZBb2pFZVRMeExiZGd3Z2dGakJnb3Foa2lHK0UwQkRRRUMKTUlJQlV6QVFCZ3NxaGtpRytFMEJEUUVDQVFJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQWdJQkFqQVFCZ3NxaGtpRytFMEJEUUVDQXdJQgpBREFRQmdzcWhraUcrRTBCRFFFQ0JBSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0JnSUJBREFRCkJnc3Foa2lHK0UwQkRRRUNCd0lCQURBUUJnc3Foa2lHK0UwQkRRRUNDQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNDUUlCQURBUUJnc3EKaGtpRytFMEJEUUVDQ2dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDQ3dJQkFEQVFCZ3NxaGtpRytFMEJEUUVDREFJQkFEQVFCZ3NxaGtpRworRTBCRFFFQ0RRSUJBREFRQmdzcWhraUcrRTBCRFFFQ0RnSUJBREFRQmdzcWhraUcrRTBCRFFFQ0R3SUJBREFRQmdzcWhraUcrRTBCCkRRRUNFQUlCQURBUUJnc3Foa2lHK0UwQkRRRUNFUUlCQ2pBZkJnc3Foa2lHK0UwQkRRRUNFZ1FRQWdJQUFBQUFBQUFBQUFBQUFBQUEKQURBUUJnb3Foa2lHK0UwQkRRRURCQUlBQURBVUJnb3Foa2lHK0UwQkRRRUVCQVlRWUdvQUFBQXdEd1lLS29aSWh2aE5BUTBCQlFvQgpBVEFlQmdvcWhraUcrRTBCRFFFR0JCQWFnNUxzb1dnaS9QRFJNT3JwNVhzaE1FUUdDaXFHU0liNFRRRU5BUWN3TmpBUUJnc3Foa2lHCitFMEJEUUVIQVFFQi96QVFCZ3NxaGtpRytFMEJEUUVIQWdFQkFEQVFCZ3NxaGtpRytFMEJEUUVIQXdFQi96QUtCZ2dxaGtqT1BRUUQKQWdOSUFEQkZBaUVBcTVzK2hhWHlaRisxVE5CUVVhRExNaTBlN204V2JOTGhRNm54MHphY3NvUUNJQS9aRjIxVk9EMTdCdHcwcHBHTwp3REF5VC9LOEJiMTZ3SjhDTU1FWVljcUEKLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLS0tLS0tQkVHSU4gQ0VSVElGSUNBVEUtLS0tLQpNSUlDbWpDQ0FrQ2dBd0lCQWdJVVdTUFRwMHFvWTFRdU9YQ3Q0QThISzFja0tyY3dDZ1lJS29aSXpqMEVBd0l3CmFERWFNQmdHQTFVRUF3d1JTVzUwWld3Z1UwZFlJRkp2YjNRZ1EwRXhHakFZQmdOVkJBb01FVWx1ZEdWc0lFTnYKY25CdmNtRjBhVzl1TVJRd0VnWURWUVFIREF0VFlXNTBZU0JEYkdGeVlURUxNQWtHQTFVRUNBd0NRMEV4Q3pBSgpCZ05WQkFZVEFsVlRNQjRYRFRFNU1UQXpNVEV5TXpNME4xb1hEVE0wTVRBek1URXlNek0wTjFvd2NERWlNQ0FHCkExVUVBd3daU1c1MFpXd2dVMGRZSUZCRFN5QlFiR0YwWm05eWJTQkRRVEVhTUJnR0ExVUVDZ3dSU1c1MFpXd2cKUTI5eWNHOXlZWFJwYjI0eEZEQVNCZ05WQkFjTUMxTmhiblJoSUVOc1lYSmhNUXN3Q1FZRFZRUUlEQUpEUVRFTApNQWtHQTFVRUJoTUNWVk13V1RBVEJnY3Foa2pPUFFJQkJnZ3Foa2pPUFFNQkJ3TkNBQVF3cCtMYytUVUJ0ZzFICitVOEpJc01zYmpIakNrVHRYYjhqUE02cjJkaHU5eklibGhEWjdJTmZxdDNJeDhYY0ZLRDhrME5FWHJrWjY2cUoKWGExS3pMSUtvNEcvTUlHOE1COEdBMVVkSXdRWU1CYUFGT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUZZRwpBMVVkSHdSUE1FMHdTNkJKb0VlR1JXaDBkSEJ6T2k4dmMySjRMV05sY25ScFptbGpZWFJsY3k1MGNuVnpkR1ZrCmMyVnlkbWxqWlhNdWFXNTBaV3d1WTI5dEwwbHVkR1ZzVTBkWVVtOXZkRU5CTG1SbGNqQWRCZ05WSFE0RUZnUVUKV1NQVHAwcW9ZMVF1T1hDdDRBOEhLMWNrS3Jjd0RnWURWUjBQQVFIL0JBUURBZ0VHTUJJR0ExVWRFd0VCL3dRSQpNQVlCQWY4Q0FRQXdDZ1lJS29aSXpqMEVBd0lEU0FBd1JRSWhBSjFxK0ZUeitnVXVWZkJRdUNnSnNGckwyVFRTCmUxYUJaNTNPNTJUakZpZTZBaUFyaVBhUmFoVVg5T2E5a0dMbEFjaFdYS1Q2ajRSV1NSNTBCcWhyTjNVVDRBPT0KLS0tLS1FTkQgQ0VSVElGSUNBVEUtLS0tLQotLS0tLUJFR0lOIENFUlRJRklDQVRFLS0tLS0KTUlJQ2xEQ0NBam1nQXdJQkFnSVZBT25vUkZKVE5seExHSm9SL0VNWUxLWGNJSUJJTUFvR0NDcUdTTTQ5QkFNQwpNR2d4R2pBWUJnTlZCQU1NRVVsdWRHVnNJRk5IV0NCU2IyOTBJRU5CTVJvd0dBWURWUVFLREJGSmJuUmxiQ0JECmIzSndiM0poZEdsdmJqRVVNQklHQTFVRUJ3d0xVMkZ1ZEdFZ1EyeGhjbUV4Q3pBSkJnTlZCQWdNQWtOQk1Rc3cKQ1FZRFZRUUdFd0pWVXpBZUZ3MHhPVEV3TXpFd09UUTVNakZhRncwME9URXlNekV5TXpVNU5UbGFNR2d4R2pBWQpCZ05WQkFNTUVVbHVkR1ZzSUZOSFdDQlNiMjkwSUVOQk1Sb3dHQVlEVlFRS0RCRkpiblJsYkNCRGIzSndiM0poCmRHbHZiakVVTUJJR0ExVUVCd3dMVTJGdWRHRWdRMnhoY21FeEN6QUpCZ05WQkFnTUFrTkJNUXN3Q1FZRFZRUUcKRXdKVlV6QlpNQk1HQnlxR1NNNDlBZ0VHQ0NxR1NNNDlBd0VIQTBJQUJFLzZELzFXSE5yV3dQbU5NSXlCS01XNQpKNkp6TXNqbzZ4UDJ2a0sxY2RaR2IxUEdSUC9DLzhFQ2dpRGtta2xtendMekxpKzAwMG03TExydEtKQTNvQzJqCmdiOHdnYnd3SHdZRFZSMGpCQmd3Rm9BVTZlaEVVbE0yWEVzWW1oSDhReGdzcGR3Z2dFZ3dWZ1lEVlIwZkJFOHcKVFRCTG9FbWdSNFpGYUhSMGNITTZMeTl6WW5ndFkyVnlkR2xtYVdOaGRHVnpMblJ5ZFhOMFpXUnpaWEoyYVdObApjeTVwYm5SbGJDNWpiMjB2U1c1MFpXeFRSMWhTYjI5MFEwRXVaR1Z5TUIwR0ExVWREZ1FXQkJUcDZFUlNVelpjClN4aWFFZnhER0N5bDNDQ0FTREFPQmdOVkhROEJBZjhFQkFNQ0FRWXdFZ1lEVlIwVEFRSC9CQWd3QmdFQi93SUIKQVRBS0JnZ3Foa2pPUFFRREFnTkpBREJHQWlFQXp3OXpkVWlVSFBNVWQwQzRteDQxamxGWmtyTTN5NWYxbGduVgpPN0Ziak9vQ0lRQ29HdFVtVDRjWHQ3Vit5U0hiSjhIb2I5QWFucHZYTkgxRVIrL2daRitvcFE9PQotLS0tLUVORCBDRVJUSUZJQ0FURS0tLS0tCg=="},
+						Collateral: collateral,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", bytes.NewReader(body))
+					Expect(err).NotTo(HaveOccurred())
+					roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName, Context: "type=SQVS"}}
+					req = context.SetUserRoles(req, roleInfo)
+					req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					return w
+				}
+
+				w = verify(bundle)
+				Expect(scs.calls).To(Equal(0))
+				Expect(w.Body.String()).NotTo(ContainSubstring(errSCSCalled.Error()))
+				// the mock collateral is not signed by the test trusted root, so verification stops at the root CA CRL
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+				Expect(w.Body.String()).To(ContainSubstring("Cannot verify Root CA crl"))
+
+				w = verify(nil)
+				Expect(scs.calls).NotTo(Equal(0))
+			})
+
+			It("Should return StatusBadRequest - Incomplete collateral bundle given", func() {
+
+				testConfig.SignQuoteResponse = false
+				QuoteVerifyCBAndSign(router, testConfig, scsClient, trustedSGXRootCA, sgxQuoteVerifier, privateKeyLocation, pubKeyLocation)
+				testQuote := []byte(`{"quote": "cXVvdGU=", "collateral": {"tcbInfo": "{}"}}`)
+				req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", bytes.NewReader(testQuote))
+				Expect(err).NotTo(HaveOccurred())
+
+				roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName, Context: "type=SQVS"}}
+				req = context.SetUserRoles(req, roleInfo)
+
+				req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				Expect(w.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Should report replayed quotes and reject them in strict mode", func() {
+
+				backend, err := replay.NewMemoryBackend(10)
+				Expect(err).NotTo(HaveOccurred())
+				replayCache, err := replay.NewCache(backend, constants.ReplayKeyQuote, time.Hour)
+				Expect(err).NotTo(HaveOccurred())
+				replay.SetDefault(replayCache)
+				defer replay.SetDefault(nil)
+				defer func() {
+					testConfig.Replay.Strict = false
+				}()
+
+				testConfig.SignQuoteResponse = false
+				QuoteVerifyCBAndSign(router, testConfig, scsClient, trustedSGXRootCA, sgxQuoteVerifier, privateKeyLocation, pubKeyLocation)
+				verify := func() *httptest.ResponseRecorder {
+					req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", strings.NewReader(`{"quote": "cXVvdGU="}`))
+					Expect(err).NotTo(HaveOccurred())
+					roleInfo := []aas.RoleInfo{{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName, Context: "type=SQVS"}}
+					req = context.SetUserRoles(req, roleInfo)
+					req.Header.Set("Content-Type", consts.HTTPMediaTypeJson)
+					w := httptest.NewRecorder()
+					router.ServeHTTP(w, req)
+					return w
+				}
+
+				w = verify()
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(ContainSubstring(`"ReplayStatus":"FIRST_SEEN"`))
+				w = verify()
+				Expect(w.Code).To(Equal(http.StatusOK))
+				Expect(w.Body.String()).To(ContainSubstring(`"ReplayStatus":"REPLAYED"`))
+
+				testConfig.Replay.Strict = true
+				w = verify()
+				Expect(w.Code).To(Equal(http.StatusConflict))
+			})
 		})
 	})
 })
+
+var errSCSCalled = errors.New("SCS must not be called")
+
+// failingSCSClient counts the requests reaching SCS and fails all of them
+type failingSCSClient struct {
+	calls int
+}
+
+func (f *failingSCSClient) Do(req *http.Request) (*http.Response, error) {
+	f.calls++
+	return nil, errSCSCalled
+}
@@ -0,0 +1,275 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenBucket is a simple thread safe token bucket limiter. Tokens are refilled at Rate tokens per second
+// up to Burst tokens.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket creates a token bucket allowing rate requests per second with the given burst size.
+// A burst smaller than one is treated as one.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	tb := &TokenBucket{
+		rate:  rate,
+		burst: float64(burst),
+		now:   time.Now,
+	}
+	tb.tokens = tb.burst
+	tb.last = tb.now()
+	return tb
+}
+
+func (tb *TokenBucket) refill() {
+	now := tb.now()
+	elapsed := now.Sub(tb.last).Seconds()
+	if elapsed > 0 {
+		tb.tokens = math.Min(tb.burst, tb.tokens+elapsed*tb.rate)
+	}
+	tb.last = now
+}
+
+// Allow consumes a token if one is available
+func (tb *TokenBucket) Allow() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	if tb.tokens < 1 {
+		return false
+	}
+	tb.tokens--
+	return true
+}
+
+// RetryAfter returns the time until the next token becomes available
+func (tb *TokenBucket) RetryAfter() time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	if tb.tokens >= 1 || tb.rate <= 0 {
+		return 0
+	}
+	return time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+}
+
+// DailyQuota counts requests per UTC calendar day and rejects requests once the limit is reached
+type DailyQuota struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+	day   time.Time
+	now   func() time.Time
+}
+
+// NewDailyQuota creates a quota allowing limit requests per UTC day
+func NewDailyQuota(limit int) *DailyQuota {
+	return &DailyQuota{
+		limit: limit,
+		now:   time.Now,
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Take consumes one unit of the quota for the current day
+func (dq *DailyQuota) Take() bool {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	today := startOfDay(dq.now())
+	if !today.Equal(dq.day) {
+		dq.day = today
+		dq.used = 0
+	}
+	if dq.used >= dq.limit {
+		return false
+	}
+	dq.used++
+	return true
+}
+
+// Remaining returns the number of requests left for the current day
+func (dq *DailyQuota) Remaining() int {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if !startOfDay(dq.now()).Equal(dq.day) {
+		return dq.limit
+	}
+	return dq.limit - dq.used
+}
+
+// ResetIn returns the time left until the quota is replenished
+func (dq *DailyQuota) ResetIn() time.Duration {
+	now := dq.now()
+	return startOfDay(now).Add(24 * time.Hour).Sub(now)
+}
+
+// KeyedLimiter maintains one token bucket per key, e.g. per client address or token subject.
+// Buckets idle for longer than the prune interval are discarded.
+type KeyedLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	buckets   map[string]*keyedBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type keyedBucket struct {
+	*TokenBucket
+	lastUsed time.Time
+}
+
+const keyedLimiterPruneInterval = 10 * time.Minute
+
+// NewKeyedLimiter creates a limiter allowing rate requests per second with the given burst size for every key
+func NewKeyedLimiter(rate float64, burst int) *KeyedLimiter {
+	kl := &KeyedLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*keyedBucket),
+		now:     time.Now,
+	}
+	kl.lastPrune = kl.now()
+	return kl
+}
+
+func (kl *KeyedLimiter) bucket(key string) *TokenBucket {
+	kl.mu.Lock()
+	defer kl.mu.Unlock()
+
+	now := kl.now()
+	if now.Sub(kl.lastPrune) > keyedLimiterPruneInterval {
+		for k, b := range kl.buckets {
+			if now.Sub(b.lastUsed) > keyedLimiterPruneInterval {
+				delete(kl.buckets, k)
+			}
+		}
+		kl.lastPrune = now
+	}
+
+	b, ok := kl.buckets[key]
+	if !ok {
+		tb := NewTokenBucket(kl.rate, kl.burst)
+		tb.now = kl.now
+		tb.last = now
+		b = &keyedBucket{TokenBucket: tb}
+		kl.buckets[key] = b
+	}
+	b.lastUsed = now
+	return b.TokenBucket
+}
+
+// Allow consumes a token from the bucket of the given key. When the request is rejected, the time
+// until the next token becomes available is returned.
+func (kl *KeyedLimiter) Allow(key string) (bool, time.Duration) {
+	tb := kl.bucket(key)
+	if tb.Allow() {
+		return true, 0
+	}
+	return false, tb.RetryAfter()
+}
+
+var (
+	// ErrQueueFull is returned by Semaphore.Acquire when the wait queue has no room left
+	ErrQueueFull = errors.New("semaphore wait queue is full")
+	// ErrQueueTimeout is returned by Semaphore.Acquire when no slot became available in time
+	ErrQueueTimeout = errors.New("timed out waiting for semaphore")
+)
+
+// Semaphore bounds the number of concurrently held slots. Callers that cannot get a slot immediately
+// wait in a bounded queue.
+type Semaphore struct {
+	slots   chan struct{}
+	mu      sync.Mutex
+	queued  int
+	maxWait int
+}
+
+// NewSemaphore creates a semaphore with maxInFlight slots and room for maxQueued waiting callers
+func NewSemaphore(maxInFlight, maxQueued int) *Semaphore {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &Semaphore{
+		slots:   make(chan struct{}, maxInFlight),
+		maxWait: maxQueued,
+	}
+}
+
+// Acquire takes a slot, waiting in the queue until one is released or ctx is done.
+// Every successful Acquire must be paired with a Release.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	s.mu.Lock()
+	if s.queued >= s.maxWait {
+		s.mu.Unlock()
+		return ErrQueueFull
+	}
+	s.queued++
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.queued--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ErrQueueTimeout
+	}
+}
+
+// Release returns a slot taken by Acquire
+func (s *Semaphore) Release() {
+	<-s.slots
+}
+
+// InFlight returns the number of slots currently held
+func (s *Semaphore) InFlight() int {
+	return len(s.slots)
+}
+
+// Queued returns the number of callers waiting for a slot
+func (s *Semaphore) Queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queued
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tb := NewTokenBucket(2, 2)
+	tb.now = func() time.Time { return now }
+	tb.last = now
+
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+	assert.Equal(t, 500*time.Millisecond, tb.RetryAfter())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+
+	// tokens never exceed the burst size
+	now = now.Add(time.Hour)
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+}
+
+func TestDailyQuota(t *testing.T) {
+	now := time.Date(2022, 1, 1, 23, 0, 0, 0, time.UTC)
+	dq := NewDailyQuota(2)
+	dq.now = func() time.Time { return now }
+
+	assert.Equal(t, 2, dq.Remaining())
+	assert.True(t, dq.Take())
+	assert.True(t, dq.Take())
+	assert.False(t, dq.Take())
+	assert.Equal(t, 0, dq.Remaining())
+	assert.Equal(t, time.Hour, dq.ResetIn())
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, 2, dq.Remaining())
+	assert.True(t, dq.Take())
+}
+
+func TestKeyedLimiter(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	kl := NewKeyedLimiter(1, 1)
+	kl.now = func() time.Time { return now }
+	kl.lastPrune = now
+
+	ok, _ := kl.Allow("client-a")
+	assert.True(t, ok)
+	ok, retryAfter := kl.Allow("client-a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	// keys are limited independently
+	ok, _ = kl.Allow("client-b")
+	assert.True(t, ok)
+
+	// idle buckets are pruned
+	now = now.Add(2 * keyedLimiterPruneInterval)
+	ok, _ = kl.Allow("client-a")
+	assert.True(t, ok)
+	assert.Len(t, kl.buckets, 1)
+}
+
+func TestSemaphore(t *testing.T) {
+	sem := NewSemaphore(1, 1)
+	assert.NoError(t, sem.Acquire(context.Background()))
+	assert.Equal(t, 1, sem.InFlight())
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- sem.Acquire(context.Background())
+	}()
+	for sem.Queued() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// queue is full
+	assert.Equal(t, ErrQueueFull, sem.Acquire(context.Background()))
+
+	sem.Release()
+	assert.NoError(t, <-acquired)
+	assert.Equal(t, 0, sem.Queued())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, ErrQueueTimeout, sem.Acquire(ctx))
+	sem.Release()
+	assert.Equal(t, 0, sem.InFlight())
+}
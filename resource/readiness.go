@@ -0,0 +1,133 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// HealthCheck reports the health of a background component of the service along with its metrics
+type HealthCheck func() (metrics interface{}, err error)
+
+// Readiness reports whether the service accepts new verifications. It is flipped to not ready when the
+// service starts draining, so that load balancers stop routing requests to it before it stops serving.
+// The health checks of background components are reported along with the readiness, a failing check
+// degrades the service without making it unready.
+type Readiness struct {
+	ready int32
+
+	mu     sync.Mutex
+	checks map[string]HealthCheck
+}
+
+type healthCheckResult struct {
+	Status  string      `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Metrics interface{} `json:"metrics,omitempty"`
+}
+
+type healthResponse struct {
+	Status string                       `json:"status"`
+	Checks map[string]healthCheckResult `json:"checks,omitempty"`
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+func (rd *Readiness) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&rd.ready, v)
+}
+
+// Ready returns true when the service is ready, a nil Readiness is always ready
+func (rd *Readiness) Ready() bool {
+	return rd == nil || atomic.LoadInt32(&rd.ready) == 1
+}
+
+// AddHealthCheck reports the health check under name in the health endpoint
+func (rd *Readiness) AddHealthCheck(name string, check HealthCheck) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.checks == nil {
+		rd.checks = make(map[string]HealthCheck)
+	}
+	rd.checks[name] = check
+}
+
+// SetRoutes registers the readiness and health endpoints, which are not authorized like the version endpoint
+func (rd *Readiness) SetRoutes(router *mux.Router) {
+	router.Handle("/ready", rd.getReadiness()).Methods("GET")
+	router.Handle("/health", rd.getHealth()).Methods("GET")
+}
+
+func (rd *Readiness) getReadiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		if !rd.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		_, err := w.Write([]byte("ready"))
+		if err != nil {
+			log.WithError(err).Error("Could not write readiness to response")
+		}
+	}
+}
+
+// health runs the health checks, the status is "ok", "degraded" when a check fails or "not ready"
+func (rd *Readiness) health() healthResponse {
+	rd.mu.Lock()
+	checks := make(map[string]HealthCheck, len(rd.checks))
+	names := make([]string, 0, len(rd.checks))
+	for name, check := range rd.checks {
+		checks[name] = check
+		names = append(names, name)
+	}
+	rd.mu.Unlock()
+	sort.Strings(names)
+
+	resp := healthResponse{Status: "ok"}
+	for _, name := range names {
+		metrics, err := checks[name]()
+		result := healthCheckResult{Status: "ok", Metrics: metrics}
+		if err != nil {
+			result.Status = "failing"
+			result.Error = err.Error()
+			resp.Status = "degraded"
+		}
+		if resp.Checks == nil {
+			resp.Checks = make(map[string]healthCheckResult)
+		}
+		resp.Checks[name] = result
+	}
+	if !rd.Ready() {
+		resp.Status = "not ready"
+	}
+	return resp
+}
+
+func (rd *Readiness) getHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := rd.health()
+		w.Header().Add("Strict-Transport-Security", "max-age=63072000; includeSubDomains")
+		w.Header().Set("Content-Type", "application/json")
+		if !rd.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.WithError(err).Error("Could not write health to response")
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+)
+
+var _ = Describe("Readiness", func() {
+	var router *mux.Router
+	var readiness *Readiness
+
+	BeforeEach(func() {
+		router = mux.NewRouter()
+		readiness = NewReadiness()
+		readiness.SetRoutes(router)
+	})
+
+	getReady := func() int {
+		req, err := http.NewRequest(http.MethodGet, "/ready", nil)
+		Expect(err).NotTo(HaveOccurred())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	It("Should not be ready until set ready", func() {
+		Expect(getReady()).To(Equal(http.StatusServiceUnavailable))
+		readiness.SetReady(true)
+		Expect(getReady()).To(Equal(http.StatusOK))
+	})
+
+	It("Should fail the readiness check once draining", func() {
+		readiness.SetReady(true)
+		readiness.SetReady(false)
+		Expect(readiness.Ready()).To(BeFalse())
+		Expect(getReady()).To(Equal(http.StatusServiceUnavailable))
+	})
+
+	It("Should report failing health checks as degraded", func() {
+		readiness.SetReady(true)
+		var checkErr error
+		readiness.AddHealthCheck("collateralRefresh", func() (interface{}, error) {
+			return map[string]int{"failures": 1}, checkErr
+		})
+
+		getHealth := func() (int, healthResponse) {
+			req, err := http.NewRequest(http.MethodGet, "/health", nil)
+			Expect(err).NotTo(HaveOccurred())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			var resp healthResponse
+			Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+			return w.Code, resp
+		}
+
+		code, resp := getHealth()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(resp.Status).To(Equal("ok"))
+		Expect(resp.Checks["collateralRefresh"].Status).To(Equal("ok"))
+
+		checkErr = errors.New("SCS is down")
+		code, resp = getHealth()
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(resp.Status).To(Equal("degraded"))
+		Expect(resp.Checks["collateralRefresh"].Error).To(Equal("SCS is down"))
+		Expect(resp.Checks["collateralRefresh"].Metrics).NotTo(BeNil())
+
+		readiness.SetReady(false)
+		code, resp = getHealth()
+		Expect(code).To(Equal(http.StatusServiceUnavailable))
+		Expect(resp.Status).To(Equal("not ready"))
+	})
+
+	It("Should treat a nil readiness as ready", func() {
+		var nilReadiness *Readiness
+		Expect(nilReadiness.Ready()).To(BeTrue())
+	})
+})
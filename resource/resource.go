@@ -24,6 +24,7 @@ type errorHandlerFunc func(w http.ResponseWriter, r *http.Request) error
 func (ehf errorHandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err := ehf(w, r); err != nil {
 		slog.WithError(err).Error("HTTP Error")
+		noteRejection(r, err)
 		switch t := err.(type) {
 		case *resourceError:
 			http.Error(w, t.Message, t.StatusCode)
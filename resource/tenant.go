@@ -0,0 +1,241 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	"fmt"
+	commContext "intel/isecl/lib/common/v5/context"
+	commLogMsg "intel/isecl/lib/common/v5/log/message"
+	ct "intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"intel/isecl/sqvs/v5/resource/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type tenantContextKey struct{}
+
+// TenantContext describes the tenant on whose behalf a request is being served
+type TenantContext struct {
+	ID     string
+	Config config.TenantConfig
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (tc *TenantContext) AllowsEndpoint(endpoint string) bool {
+	return len(tc.Config.Endpoints) == 0 || contains(tc.Config.Endpoints, endpoint)
+}
+
+func (tc *TenantContext) AllowsPolicy(policy string) bool {
+	return len(tc.Config.Policies) == 0 || contains(tc.Config.Policies, policy)
+}
+
+func (tc *TenantContext) AllowsTrustProfile(profile string) bool {
+	return len(tc.Config.TrustProfiles) == 0 || contains(tc.Config.TrustProfiles, profile)
+}
+
+// GetTenantContext returns the tenant the request was authorized for, nil if the caller is not bound to a tenant
+func GetTenantContext(r *http.Request) *TenantContext {
+	if tc, ok := r.Context().Value(tenantContextKey{}).(*TenantContext); ok {
+		return tc
+	}
+	return nil
+}
+
+func setTenantContext(r *http.Request, tc *TenantContext) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tc))
+}
+
+// TenantAuthorizer enforces per-tenant endpoint access, rate limits and daily quotas. Rate limiter and quota
+// state is shared across all the routes the authorizer is installed on.
+type TenantAuthorizer struct {
+	tenants  map[string]config.TenantConfig
+	mu       sync.Mutex
+	limiters map[string]*ratelimit.TokenBucket
+	quotas   map[string]*ratelimit.DailyQuota
+}
+
+func NewTenantAuthorizer(tenants map[string]config.TenantConfig) *TenantAuthorizer {
+	return &TenantAuthorizer{
+		tenants:  tenants,
+		limiters: make(map[string]*ratelimit.TokenBucket),
+		quotas:   make(map[string]*ratelimit.DailyQuota),
+	}
+}
+
+// tenantFromRoles extracts the tenant id from the context of the QuoteVerifier roles held by the caller.
+// Role context is a comma separated list of key=value pairs, e.g. "type=SQVS,tenant=team-a"
+func tenantFromRoles(roles []ct.RoleInfo) (string, error) {
+	var tenantID string
+	for _, role := range roles {
+		if role.Service != constants.ServiceName || role.Name != constants.QuoteVerifierGroupName {
+			continue
+		}
+		for _, pair := range strings.Split(role.Context, ",") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || strings.TrimSpace(kv[0]) != constants.TenantRoleContextKey {
+				continue
+			}
+			id := strings.TrimSpace(kv[1])
+			if id == "" {
+				continue
+			}
+			if tenantID != "" && tenantID != id {
+				return "", errors.Errorf("token is bound to multiple tenants: %s, %s", tenantID, id)
+			}
+			tenantID = id
+		}
+	}
+	return tenantID, nil
+}
+
+func (ta *TenantAuthorizer) limiter(id string, tc config.TenantConfig) *ratelimit.TokenBucket {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	if _, ok := ta.limiters[id]; !ok {
+		ta.limiters[id] = ratelimit.NewTokenBucket(tc.RateLimit, tc.RateBurst)
+	}
+	return ta.limiters[id]
+}
+
+func (ta *TenantAuthorizer) quota(id string, tc config.TenantConfig) *ratelimit.DailyQuota {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	if _, ok := ta.quotas[id]; !ok {
+		ta.quotas[id] = ratelimit.NewDailyQuota(tc.DailyQuota)
+	}
+	return ta.quotas[id]
+}
+
+func retryAfterSeconds(seconds float64) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(seconds))))
+}
+
+// Authorize checks the tenant bound to the request against its configuration for the given endpoint.
+// Requests without a tenant in the role context are passed through unchanged.
+func (ta *TenantAuthorizer) Authorize(w http.ResponseWriter, r *http.Request, endpoint string) (*http.Request, error) {
+	log.Trace("resource/tenant:Authorize() Entering")
+	defer log.Trace("resource/tenant:Authorize() Leaving")
+
+	roles, err := commContext.GetUserRoles(r)
+	if err != nil {
+		// no roles in context, endpoint authorization is left to AuthorizeEndpoint
+		return r, nil
+	}
+
+	tenantID, err := tenantFromRoles(roles)
+	if err != nil {
+		slog.WithError(err).Warnf("resource/tenant:Authorize() %s: ambiguous tenant context", commLogMsg.UnauthorizedAccess)
+		return r, &privilegeError{Message: "Ambiguous tenant context", StatusCode: http.StatusForbidden}
+	}
+	if tenantID == "" {
+		return r, nil
+	}
+
+	tc, ok := ta.tenants[tenantID]
+	if !ok {
+		slog.Warnf("resource/tenant:Authorize() %s: tenant %s is not configured", commLogMsg.UnauthorizedAccess, tenantID)
+		return r, &privilegeError{Message: "Tenant access unauthorized", StatusCode: http.StatusForbidden}
+	}
+	tenant := &TenantContext{ID: tenantID, Config: tc}
+
+	if !tenant.AllowsEndpoint(endpoint) {
+		slog.Warnf("resource/tenant:Authorize() %s: tenant %s is not allowed to access %s endpoint",
+			commLogMsg.UnauthorizedAccess, tenantID, endpoint)
+		return r, &privilegeError{Message: "Endpoint access unauthorized for tenant", StatusCode: http.StatusForbidden}
+	}
+
+	if tc.RateLimit > 0 {
+		limiter := ta.limiter(tenantID, tc)
+		if !limiter.Allow() {
+			slog.Warnf("resource/tenant:Authorize() tenant %s exceeded rate limit of %v requests/sec on %s endpoint",
+				tenantID, tc.RateLimit, endpoint)
+			w.Header().Set("Retry-After", retryAfterSeconds(limiter.RetryAfter().Seconds()))
+			return r, &resourceError{Message: "Tenant rate limit exceeded", StatusCode: http.StatusTooManyRequests}
+		}
+	}
+
+	if tc.DailyQuota > 0 {
+		quota := ta.quota(tenantID, tc)
+		if !quota.Take() {
+			slog.Warnf("resource/tenant:Authorize() tenant %s exhausted daily quota of %d requests on %s endpoint",
+				tenantID, tc.DailyQuota, endpoint)
+			w.Header().Set("Retry-After", retryAfterSeconds(quota.ResetIn().Seconds()))
+			return r, &resourceError{Message: "Tenant daily quota exhausted", StatusCode: http.StatusTooManyRequests}
+		}
+	}
+
+	slog.Infof("resource/tenant:Authorize() %s - tenant %s, endpoint %s", commLogMsg.AuthorizedAccess, tenantID, endpoint)
+	return setTenantContext(r, tenant), nil
+}
+
+// Middleware returns a router middleware enforcing tenant restrictions for the given endpoint.
+// It must be installed after the token authentication middleware.
+func (ta *TenantAuthorizer) Middleware(endpoint string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return errorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			r, err := ta.Authorize(w, r, endpoint)
+			if err != nil {
+				return err
+			}
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
+
+// AuthorizePolicy checks whether the tenant bound to the request may use the given policy and trust profile.
+// Empty names are not checked.
+func AuthorizePolicy(r *http.Request, policy, trustProfile string) error {
+	tenant := GetTenantContext(r)
+	if tenant == nil {
+		return nil
+	}
+	if policy != "" && !tenant.AllowsPolicy(policy) {
+		slog.Warnf("resource/tenant:AuthorizePolicy() %s: tenant %s is not allowed to use policy %s",
+			commLogMsg.UnauthorizedAccess, tenant.ID, policy)
+		return &privilegeError{Message: fmt.Sprintf("Policy %s not allowed for tenant", policy), StatusCode: http.StatusForbidden}
+	}
+	if trustProfile != "" && !tenant.AllowsTrustProfile(trustProfile) {
+		slog.Warnf("resource/tenant:AuthorizePolicy() %s: tenant %s is not allowed to use trust profile %s",
+			commLogMsg.UnauthorizedAccess, tenant.ID, trustProfile)
+		return &privilegeError{Message: fmt.Sprintf("Trust profile %s not allowed for tenant", trustProfile),
+			StatusCode: http.StatusForbidden}
+	}
+	return nil
+}
+
+// authorizeVerifyRequest checks a verification request against the tenant bound to it. The policy of a request
+// is its report data binding and its trust profile the source of its collateral, SCS or the request itself.
+func authorizeVerifyRequest(r *http.Request, data models.QuoteDataWithChallenge) error {
+	policy := data.ReportDataBinding
+	if policy == "" {
+		policy = constants.ReportDataBindingSHA256
+	}
+	trustProfile := constants.CollateralSourceSCS
+	if data.Collateral != nil {
+		trustProfile = constants.CollateralSourceRequest
+	}
+	return AuthorizePolicy(r, policy, trustProfile)
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/lib/common/v5/types/aas"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TenantAuthorizer", func() {
+	var router *mux.Router
+	var w *httptest.ResponseRecorder
+	var servedTenant *TenantContext
+
+	tenants := map[string]config.TenantConfig{
+		"team-a": {Endpoints: []string{constants.EndpointV2}, Policies: []string{"default"}},
+		"team-b": {RateLimit: 0.001, RateBurst: 1},
+		"team-c": {DailyQuota: 1},
+	}
+
+	newRequest := func(roleContext ...string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		var roles []aas.RoleInfo
+		for _, c := range roleContext {
+			roles = append(roles, aas.RoleInfo{Service: constants.ServiceName, Name: constants.QuoteVerifierGroupName, Context: c})
+		}
+		return context.SetUserRoles(req, roles)
+	}
+
+	BeforeEach(func() {
+		servedTenant = nil
+		router = mux.NewRouter()
+		router.Use(NewTenantAuthorizer(tenants).Middleware(constants.EndpointV2))
+		router.HandleFunc("/sgx_qv_verify_quote", func(w http.ResponseWriter, r *http.Request) {
+			servedTenant = GetTenantContext(r)
+			w.WriteHeader(http.StatusOK)
+		})
+		w = httptest.NewRecorder()
+	})
+
+	It("Should pass through requests without tenant context", func() {
+		router.ServeHTTP(w, newRequest("type=SQVS"))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(servedTenant).To(BeNil())
+	})
+
+	It("Should set tenant context for an allowed endpoint", func() {
+		req := newRequest("type=SQVS,tenant=team-a")
+		router.ServeHTTP(w, req)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(servedTenant).NotTo(BeNil())
+		Expect(servedTenant.ID).To(Equal("team-a"))
+		Expect(servedTenant.AllowsPolicy("default")).To(BeTrue())
+		Expect(servedTenant.AllowsPolicy("strict")).To(BeFalse())
+		Expect(servedTenant.AllowsTrustProfile("any")).To(BeTrue())
+	})
+
+	It("Should reject tenants not allowed on the endpoint", func() {
+		router = mux.NewRouter()
+		router.Use(NewTenantAuthorizer(tenants).Middleware(constants.EndpointV1))
+		router.HandleFunc("/sgx_qv_verify_quote", func(w http.ResponseWriter, r *http.Request) {})
+		router.ServeHTTP(w, newRequest("tenant=team-a"))
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("Should reject unknown and ambiguous tenants", func() {
+		router.ServeHTTP(w, newRequest("tenant=team-x"))
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("tenant=team-a", "tenant=team-b"))
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("Should enforce tenant rate limit", func() {
+		router.ServeHTTP(w, newRequest("tenant=team-b"))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("tenant=team-b"))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(w.Header().Get("Retry-After")).NotTo(BeEmpty())
+	})
+
+	It("Should enforce tenant daily quota", func() {
+		router.ServeHTTP(w, newRequest("tenant=team-c"))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("tenant=team-c"))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+	})
+
+	It("Should authorize policies against the tenant context", func() {
+		req := setTenantContext(newRequest(), &TenantContext{ID: "team-a", Config: tenants["team-a"]})
+		Expect(AuthorizePolicy(req, "default", "")).To(Succeed())
+		Expect(AuthorizePolicy(req, "strict", "")).NotTo(Succeed())
+		Expect(AuthorizePolicy(newRequest(), "strict", "")).To(Succeed())
+	})
+
+	It("Should authorize the report data binding and collateral source of verification requests", func() {
+		req := setTenantContext(newRequest(), &TenantContext{ID: "team-d", Config: config.TenantConfig{
+			Policies:      []string{constants.ReportDataBindingSHA256},
+			TrustProfiles: []string{constants.CollateralSourceSCS},
+		}})
+		Expect(authorizeVerifyRequest(req, models.QuoteDataWithChallenge{})).To(Succeed())
+		Expect(authorizeVerifyRequest(req, models.QuoteDataWithChallenge{
+			ReportDataBinding: constants.ReportDataBindingRaw,
+		})).NotTo(Succeed())
+		Expect(authorizeVerifyRequest(req, models.QuoteDataWithChallenge{
+			Collateral: &models.Collateral{},
+		})).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,106 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"context"
+	commContext "intel/isecl/lib/common/v5/context"
+	"intel/isecl/sqvs/v5/config"
+	"intel/isecl/sqvs/v5/resource/ratelimit"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Throttler enforces the global and per-client request rate limits and bounds the number of
+// verifications in flight. Its state is shared across all the routes it is installed on.
+type Throttler struct {
+	conf    config.RateLimitConfig
+	global  *ratelimit.TokenBucket
+	clients *ratelimit.KeyedLimiter
+	sem     *ratelimit.Semaphore
+}
+
+func NewThrottler(conf config.RateLimitConfig) *Throttler {
+	t := &Throttler{conf: conf}
+	if conf.GlobalRate > 0 {
+		t.global = ratelimit.NewTokenBucket(conf.GlobalRate, conf.GlobalBurst)
+	}
+	if conf.ClientRate > 0 {
+		t.clients = ratelimit.NewKeyedLimiter(conf.ClientRate, conf.ClientBurst)
+	}
+	if conf.MaxConcurrent > 0 {
+		t.sem = ratelimit.NewSemaphore(conf.MaxConcurrent, conf.MaxQueued)
+	}
+	return t
+}
+
+// clientKey identifies the caller by its token (or client certificate) subject, falling back to the remote address
+func clientKey(r *http.Request) string {
+	if subject, err := commContext.GetTokenSubject(r); err == nil && subject != "" {
+		return "sub:" + subject
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// acquire applies the rate limits and takes a verification slot. The returned release function
+// must be called once the request has been served.
+func (t *Throttler) acquire(w http.ResponseWriter, r *http.Request) (func(), error) {
+	// the client limit is checked first so that requests of a client over its limit do not use up the global limit
+	if t.clients != nil {
+		key := clientKey(r)
+		if ok, retryAfter := t.clients.Allow(key); !ok {
+			slog.Warnf("resource/throttle:acquire() client %s exceeded rate limit of %v requests/sec",
+				key, t.conf.ClientRate)
+			w.Header().Set("Retry-After", retryAfterSeconds(retryAfter.Seconds()))
+			return nil, &resourceError{Message: "Client rate limit exceeded", StatusCode: http.StatusTooManyRequests}
+		}
+	}
+
+	if t.global != nil && !t.global.Allow() {
+		slog.Warnf("resource/throttle:acquire() global rate limit of %v requests/sec exceeded, requested from %s",
+			t.conf.GlobalRate, r.RemoteAddr)
+		w.Header().Set("Retry-After", retryAfterSeconds(t.global.RetryAfter().Seconds()))
+		return nil, &resourceError{Message: "Rate limit exceeded", StatusCode: http.StatusTooManyRequests}
+	}
+
+	if t.sem == nil {
+		return func() {}, nil
+	}
+	ctx := r.Context()
+	if t.conf.QueueTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.conf.QueueTimeout)
+		defer cancel()
+	}
+	if err := t.sem.Acquire(ctx); err != nil {
+		slog.WithError(err).Warnf("resource/throttle:acquire() %d verifications in flight, %d queued, rejecting request from %s",
+			t.sem.InFlight(), t.sem.Queued(), r.RemoteAddr)
+		w.Header().Set("Retry-After", retryAfterSeconds(t.conf.QueueTimeout.Seconds()))
+		return nil, &resourceError{Message: "Too many verifications in progress", StatusCode: http.StatusTooManyRequests}
+	}
+	return t.sem.Release, nil
+}
+
+// Middleware returns a router middleware applying the throttling limits. It should be installed after the
+// authentication middleware so that authenticated callers are limited by subject rather than address.
+func (t *Throttler) Middleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return errorHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			release, err := t.acquire(w, r)
+			if err != nil {
+				return err
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
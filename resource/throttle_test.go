@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package resource
+
+import (
+	"intel/isecl/lib/common/v5/context"
+	"intel/isecl/sqvs/v5/config"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Throttler", func() {
+	var w *httptest.ResponseRecorder
+
+	newRouter := func(conf config.RateLimitConfig, handler http.HandlerFunc) *mux.Router {
+		router := mux.NewRouter()
+		router.Use(NewThrottler(conf).Middleware())
+		router.HandleFunc("/sgx_qv_verify_quote", handler)
+		return router
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	newRequest := func(remoteAddr, subject string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/sgx_qv_verify_quote", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.RemoteAddr = remoteAddr
+		if subject != "" {
+			req = context.SetTokenSubject(req, subject)
+		}
+		return req
+	}
+
+	BeforeEach(func() {
+		w = httptest.NewRecorder()
+	})
+
+	It("Should pass requests through when no limits are configured", func() {
+		router := newRouter(config.RateLimitConfig{}, ok)
+		for i := 0; i < 5; i++ {
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, newRequest("10.0.0.1:1234", ""))
+			Expect(w.Code).To(Equal(http.StatusOK))
+		}
+	})
+
+	It("Should enforce the global rate limit", func() {
+		router := newRouter(config.RateLimitConfig{GlobalRate: 0.001, GlobalBurst: 1}, ok)
+		router.ServeHTTP(w, newRequest("10.0.0.1:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("10.0.0.2:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(w.Header().Get("Retry-After")).NotTo(BeEmpty())
+	})
+
+	It("Should not use up the global rate limit for requests over the client rate limit", func() {
+		router := newRouter(config.RateLimitConfig{GlobalRate: 0.001, GlobalBurst: 2, ClientRate: 0.001,
+			ClientBurst: 1}, ok)
+		router.ServeHTTP(w, newRequest("10.0.0.1:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		for i := 0; i < 3; i++ {
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, newRequest("10.0.0.1:1234", ""))
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		}
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("10.0.0.2:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	It("Should enforce the rate limit per client address and subject", func() {
+		router := newRouter(config.RateLimitConfig{ClientRate: 0.001, ClientBurst: 1}, ok)
+		router.ServeHTTP(w, newRequest("10.0.0.1:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("10.0.0.1:5678", ""))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("10.0.0.1:1234", "client-a"))
+		Expect(w.Code).To(Equal(http.StatusOK))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newRequest("10.0.0.2:1234", "client-a"))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+	})
+
+	It("Should reject verifications when no slot becomes available in time", func() {
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		router := newRouter(config.RateLimitConfig{MaxConcurrent: 1, MaxQueued: 1, QueueTimeout: 10 * time.Millisecond},
+			func(w http.ResponseWriter, r *http.Request) {
+				started <- struct{}{}
+				<-unblock
+				w.WriteHeader(http.StatusOK)
+			})
+
+		done := make(chan int)
+		go func() {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, newRequest("10.0.0.1:1234", ""))
+			done <- rec.Code
+		}()
+		<-started
+
+		router.ServeHTTP(w, newRequest("10.0.0.2:1234", ""))
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+
+		close(unblock)
+		Expect(<-done).To(Equal(http.StatusOK))
+	})
+})
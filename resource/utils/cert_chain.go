@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// issuedBy reports whether cert names parent as its issuer, by issuer name and, when both certificates carry
+// them, by authority and subject key identifier
+func issuedBy(cert, parent *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, parent.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) != 0 && len(parent.SubjectKeyId) != 0 {
+		return bytes.Equal(cert.AuthorityKeyId, parent.SubjectKeyId)
+	}
+	return true
+}
+
+// BuildCertChain orders a certificate chain from the leaf to the self-issued root by following the issuer
+// linkage of the certificates, regardless of the order in which they were given. Duplicate certificates,
+// certificates which are not part of the chain, branches and chains without a single root are rejected.
+// Only the structure of the chain is checked here, certificate subjects and signatures are left to the verifier.
+func BuildCertChain(certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	numCerts := len(certs)
+	if numCerts == 0 {
+		return nil, errors.New("BuildCertChain: no certificates given")
+	}
+
+	for i := 0; i < numCerts; i++ {
+		if certs[i] == nil {
+			return nil, errors.New(fmt.Sprintf("BuildCertChain: certificate[%d] is empty", i))
+		}
+		for j := 0; j < i; j++ {
+			if bytes.Equal(certs[i].Raw, certs[j].Raw) {
+				return nil, errors.New(fmt.Sprintf("BuildCertChain: certificate[%d] %s is a duplicate of "+
+					"certificate[%d]", i, certs[i].Subject.String(), j))
+			}
+		}
+	}
+
+	root := -1
+	parent := make([]int, numCerts)
+	children := make([]int, numCerts)
+	for i := 0; i < numCerts; i++ {
+		parent[i] = -1
+		if issuedBy(certs[i], certs[i]) {
+			if root != -1 {
+				return nil, errors.New(fmt.Sprintf("BuildCertChain: multiple root certificates %s and %s",
+					certs[root].Subject.String(), certs[i].Subject.String()))
+			}
+			root = i
+			continue
+		}
+
+		for j := 0; j < numCerts; j++ {
+			if i == j || !issuedBy(certs[i], certs[j]) {
+				continue
+			}
+			if parent[i] != -1 {
+				return nil, errors.New(fmt.Sprintf("BuildCertChain: certificate[%d] %s has multiple issuers in "+
+					"the chain", i, certs[i].Subject.String()))
+			}
+			parent[i] = j
+		}
+		if parent[i] == -1 {
+			return nil, errors.New(fmt.Sprintf("BuildCertChain: issuer %s of certificate[%d] %s not found in "+
+				"the chain", certs[i].Issuer.String(), i, certs[i].Subject.String()))
+		}
+		children[parent[i]]++
+	}
+
+	if root == -1 {
+		return nil, errors.New("BuildCertChain: no self issued root certificate found in the chain")
+	}
+
+	for i := 0; i < numCerts; i++ {
+		if children[i] > 1 {
+			return nil, errors.New(fmt.Sprintf("BuildCertChain: certificate[%d] %s issued several certificates "+
+				"in the chain", i, certs[i].Subject.String()))
+		}
+	}
+
+	leaf := -1
+	for i := 0; i < numCerts; i++ {
+		if children[i] == 0 {
+			if leaf != -1 {
+				return nil, errors.New(fmt.Sprintf("BuildCertChain: certificate[%d] %s is not part of the chain "+
+					"of %s", i, certs[i].Subject.String(), certs[leaf].Subject.String()))
+			}
+			leaf = i
+		}
+	}
+	if leaf == -1 {
+		return nil, errors.New("BuildCertChain: certificate chain contains a loop")
+	}
+
+	chain := make([]*x509.Certificate, 0, numCerts)
+	for i := leaf; i != -1 && len(chain) < numCerts; i = parent[i] {
+		chain = append(chain, certs[i])
+	}
+	if len(chain) != numCerts || chain[len(chain)-1] != certs[root] {
+		return nil, errors.New("BuildCertChain: certificate chain does not end in the root certificate")
+	}
+	return chain, nil
+}
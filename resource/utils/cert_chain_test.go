@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testChainCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var testChainSerial int64
+
+func newTestChainCert(t *testing.T, cn string, ski []byte, parent *testChainCert) *testChainCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	testChainSerial++
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(testChainSerial),
+		Subject:               pkix.Name{CommonName: cn, Organization: []string{"Intel Corporation"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          ski,
+	}
+	issuer, issuerKey := template, key
+	if parent != nil {
+		issuer, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testChainCert{cert: cert, key: key}
+}
+
+func TestBuildCertChain(t *testing.T) {
+	root := newTestChainCert(t, "Intel SGX Root CA", []byte{1}, nil)
+	inter := newTestChainCert(t, "Intel SGX PCK Processor CA", []byte{2}, root)
+	leaf := newTestChainCert(t, "Intel SGX PCK Certificate", []byte{3}, inter)
+	otherInter := newTestChainCert(t, "Intel SGX PCK Platform CA", []byte{4}, root)
+	// same issuer name as the intermediate CA, different key
+	sameNameInter := newTestChainCert(t, "Intel SGX PCK Processor CA", []byte{5}, root)
+	otherRoot := newTestChainCert(t, "Intel SGX Root CA", []byte{6}, nil)
+	orphan := newTestChainCert(t, "Intel SGX PCK Certificate", []byte{7},
+		newTestChainCert(t, "Intel SGX PCK Platform CA", []byte{8}, otherRoot))
+
+	tests := []struct {
+		name    string
+		certs   []*x509.Certificate
+		want    []*x509.Certificate
+		wantErr string
+	}{
+		{
+			name:  "ordered chain",
+			certs: []*x509.Certificate{leaf.cert, inter.cert, root.cert},
+			want:  []*x509.Certificate{leaf.cert, inter.cert, root.cert},
+		},
+		{
+			name:  "unordered chain",
+			certs: []*x509.Certificate{root.cert, leaf.cert, inter.cert},
+			want:  []*x509.Certificate{leaf.cert, inter.cert, root.cert},
+		},
+		{
+			name:  "issuer chain without leaf",
+			certs: []*x509.Certificate{root.cert, inter.cert},
+			want:  []*x509.Certificate{inter.cert, root.cert},
+		},
+		{
+			name:    "issuer name matches, key identifier does not",
+			certs:   []*x509.Certificate{leaf.cert, sameNameInter.cert, root.cert},
+			wantErr: "not found in the chain",
+		},
+		{
+			name:    "no certificates",
+			wantErr: "no certificates given",
+		},
+		{
+			name:    "empty certificate",
+			certs:   []*x509.Certificate{leaf.cert, nil, root.cert},
+			wantErr: "is empty",
+		},
+		{
+			name:    "duplicate certificate",
+			certs:   []*x509.Certificate{leaf.cert, inter.cert, inter.cert, root.cert},
+			wantErr: "is a duplicate",
+		},
+		{
+			name:    "missing intermediate CA",
+			certs:   []*x509.Certificate{leaf.cert, root.cert},
+			wantErr: "not found in the chain",
+		},
+		{
+			name:    "missing root CA",
+			certs:   []*x509.Certificate{leaf.cert, inter.cert},
+			wantErr: "not found in the chain",
+		},
+		{
+			name:    "multiple roots",
+			certs:   []*x509.Certificate{leaf.cert, inter.cert, root.cert, otherRoot.cert},
+			wantErr: "multiple root certificates",
+		},
+		{
+			name:    "extra intermediate CA",
+			certs:   []*x509.Certificate{leaf.cert, inter.cert, otherInter.cert, root.cert},
+			wantErr: "issued several certificates",
+		},
+		{
+			name:    "unrelated certificate",
+			certs:   []*x509.Certificate{leaf.cert, inter.cert, root.cert, orphan.cert},
+			wantErr: "not found in the chain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := BuildCertChain(tt.certs)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, chain)
+		})
+	}
+}
@@ -43,27 +43,33 @@ func GetCertObjList(certChainStr string) ([]*x509.Certificate, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "GetCertObjList: Error parsing Cert Chain QueryUnescape")
 	}
+	return GetCertObjListFromPem(certChainEscapedStr)
+}
 
-	certCount := strings.Count(certChainEscapedStr, "-----END CERTIFICATE-----")
+// GetCertObjListFromPem parses a PEM encoded certificate chain, GetCertObjList parses the URL encoded chains
+// of SCS response headers
+func GetCertObjListFromPem(certChainPem string) ([]*x509.Certificate, error) {
+	certCount := strings.Count(certChainPem, "-----END CERTIFICATE-----")
 	if certCount == 0 {
-		return nil, errors.Wrap(err, "GetCertObjList: no certificates were found")
+		return nil, errors.New("GetCertObjList: no certificates were found")
 	}
 
-	certs := strings.SplitAfterN(certChainEscapedStr, "-----END CERTIFICATE-----", certCount)
+	certs := strings.SplitAfterN(certChainPem, "-----END CERTIFICATE-----", certCount)
 	certChainObjList := make([]*x509.Certificate, certCount)
 
+	var err error
 	for i := 0; i < len(certs); i++ {
 		log.Debug("Certificate[", i, "]:", certs[i])
 		block, _ := pem.Decode([]byte(certs[i]))
 		if block == nil {
-			return nil, errors.Wrap(err, "GetCertObjList: Pem Decode error")
+			return nil, errors.New("GetCertObjList: Pem Decode error")
 		}
 		certChainObjList[i], err = x509.ParseCertificate(block.Bytes)
 		if err != nil {
 			return nil, errors.Wrap(err, "GetCertObjList: Parse Certificate error")
 		}
 	}
-	log.Debug("GetCertObjList parsed: ", len(certChainObjList), " certificates from string: ", certChainEscapedStr)
+	log.Debug("GetCertObjList parsed: ", len(certChainObjList), " certificates from string: ", certChainPem)
 	return certChainObjList, nil
 }
 
@@ -75,7 +81,9 @@ func IntToBool(i int) bool {
 	}
 }
 
-func CheckDate(issueDate, nextUpdate string) bool {
+// CheckDate checks that verificationTime lies between issueDate and nextUpdate, collateral past nextUpdate is
+// accepted for expiryGrace
+func CheckDate(issueDate, nextUpdate string, verificationTime time.Time, expiryGrace time.Duration) bool {
 	iDate, err := time.Parse(time.RFC3339, issueDate)
 	if err != nil {
 		log.Error("CheckData: IssueDate parse:" + err.Error())
@@ -88,10 +96,10 @@ func CheckDate(issueDate, nextUpdate string) bool {
 		return false
 	}
 
-	universalTime := time.Now().UTC()
+	universalTime := verificationTime.UTC()
 
 	curTimeAfterIssDate := universalTime.After(iDate)
-	curTimeBeforeNextUpdate := universalTime.Before(nUpdate)
+	curTimeBeforeNextUpdate := universalTime.Add(-expiryGrace).Before(nUpdate)
 
 	if !curTimeAfterIssDate || !curTimeBeforeNextUpdate {
 		log.Error(fmt.Sprintf("CheckDate: CheckDate Validataion Failed, Time After IssueDate : %v, Time Before NextUpdate : %v",
@@ -69,27 +69,41 @@ func TestCheckDate(t *testing.T) {
 	// valid dates given.
 	issueDate := time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
 	nextUpdate := time.Now().Add(10 * time.Minute).Format(time.RFC3339)
-	got := CheckDate(issueDate, nextUpdate)
+	got := CheckDate(issueDate, nextUpdate, time.Now(), 0)
 
 	assert.Equal(t, true, got)
 
 	// Different time format.
 	issueDate = time.Now().Add(-10 * time.Minute).Format(time.RFC1123)
 	nextUpdate = time.Now().Add(10 * time.Hour).Format(time.RFC1123)
-	got = CheckDate(issueDate, nextUpdate)
+	got = CheckDate(issueDate, nextUpdate, time.Now(), 0)
 	assert.Equal(t, false, got)
 
 	issueDate = time.Now().Add(-10 * time.Minute).Format(time.RFC3339)
 	nextUpdate = time.Now().Add(10 * time.Hour).Format(time.RFC1123)
-	got = CheckDate(issueDate, nextUpdate)
+	got = CheckDate(issueDate, nextUpdate, time.Now(), 0)
 	assert.Equal(t, false, got)
 
 	// Invalid issue date and next update date.
 	issueDate = time.Now().Add(10 * time.Hour).Format(time.RFC3339)
 	nextUpdate = time.Now().Add(-10 * time.Hour).Format(time.RFC3339)
-	got = CheckDate(issueDate, nextUpdate)
+	got = CheckDate(issueDate, nextUpdate, time.Now(), 0)
 
 	assert.Equal(t, false, got)
+
+	// dates checked at a given point in time
+	issueDate = "2021-06-01T00:00:00Z"
+	nextUpdate = "2021-07-01T00:00:00Z"
+	got = CheckDate(issueDate, nextUpdate, time.Date(2021, 6, 15, 0, 0, 0, 0, time.UTC), 0)
+	assert.Equal(t, true, got)
+	got = CheckDate(issueDate, nextUpdate, time.Date(2021, 7, 15, 0, 0, 0, 0, time.UTC), 0)
+	assert.Equal(t, false, got)
+
+	// expired collateral accepted within the grace period
+	got = CheckDate(issueDate, nextUpdate, time.Date(2021, 7, 15, 0, 0, 0, 0, time.UTC), 30*24*time.Hour)
+	assert.Equal(t, true, got)
+	got = CheckDate(issueDate, nextUpdate, time.Date(2021, 7, 15, 0, 0, 0, 0, time.UTC), 7*24*time.Hour)
+	assert.Equal(t, false, got)
 }
 
 func TestGenerateSignature(t *testing.T) {
@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GetCollateralExpiry returns the earliest of the collateral expiry dates and whether the collateral has expired
+// at verificationTime. Collateral expired beyond the grace period is rejected by the individual collateral checks,
+// expired collateral reported here has been accepted within that grace period.
+func GetCollateralExpiry(expiryDates []time.Time, verificationTime time.Time) (time.Time, bool, error) {
+	if len(expiryDates) == 0 {
+		return time.Time{}, false, errors.New("GetCollateralExpiry: no collateral expiry dates given")
+	}
+
+	var earliest time.Time
+	for _, date := range expiryDates {
+		if date.IsZero() {
+			return time.Time{}, false, errors.New("GetCollateralExpiry: collateral expiry date is empty")
+		}
+		if earliest.IsZero() || date.Before(earliest) {
+			earliest = date
+		}
+	}
+
+	expired := !verificationTime.Before(earliest)
+	if expired {
+		log.Warnf("Collateral expired at %s, accepted within the grace period", earliest.UTC().Format(time.RFC3339))
+	}
+	return earliest, expired, nil
+}
@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCollateralExpiry(t *testing.T) {
+	now := time.Now()
+
+	_, _, err := GetCollateralExpiry(nil, now)
+	assert.Error(t, err)
+
+	_, _, err = GetCollateralExpiry([]time.Time{now.Add(time.Hour), {}}, now)
+	assert.Error(t, err)
+
+	expiry, expired, err := GetCollateralExpiry([]time.Time{now.Add(2 * time.Hour), now.Add(time.Hour),
+		now.Add(3 * time.Hour)}, now)
+	assert.NoError(t, err)
+	assert.False(t, expired)
+	assert.True(t, expiry.Equal(now.Add(time.Hour)))
+
+	expiry, expired, err = GetCollateralExpiry([]time.Time{now.Add(time.Hour), now.Add(-time.Minute)}, now)
+	assert.NoError(t, err)
+	assert.True(t, expired)
+	assert.True(t, expiry.Equal(now.Add(-time.Minute)))
+
+	// collateral expiring at the verification time is expired
+	_, expired, err = GetCollateralExpiry([]time.Time{now}, now)
+	assert.NoError(t, err)
+	assert.True(t, expired)
+}
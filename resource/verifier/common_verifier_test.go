@@ -143,10 +143,10 @@ func TestVerifyRootCaCert(t *testing.T) {
 	rootCA.Extensions = append(rootCA.Extensions, pkix.Extension{Id: ExtKeyUsageOid, Critical: true})
 	rootCA.Extensions = append(rootCA.Extensions, pkix.Extension{Id: ExtBasicConstrainsOid, Critical: true})
 
-	err := verifyRootCaCert(rootCA, "CN=TEST COMMON NAME,O=Intel Corporation,L=Santa Clara,ST=CA,C=US")
+	err := verifyRootCaCert(rootCA, "CN=TEST COMMON NAME,O=Intel Corporation,L=Santa Clara,ST=CA,C=US", time.Now())
 	// "CN=Intel SGX PCK Certificate,O=Intel Corporation,L=Santa Clara,ST=CA,C=US"
 	assert.NotNil(t, err)
 
-	err = verifyRootCaCert(rootCA, "TEST COMMON NAME")
+	err = verifyRootCaCert(rootCA, "TEST COMMON NAME", time.Now())
 	assert.NotNil(t, err)
 }
@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"crypto/x509"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+
+	"github.com/pkg/errors"
+)
+
+// PlatformPolicy selects the Scalable SGX platform configurations rejected by VerifyPlatformPolicy
+type PlatformPolicy struct {
+	RejectSmtEnabled      bool
+	RejectDynamicPlatform bool
+}
+
+// VerifyPlatformPolicy evaluates the platform configuration of a PCK certificate issued by the PCK Platform CA
+// against the platform policy. Certificates issued by the PCK Processor CA carry no platform configuration and
+// are not evaluated, nil is returned for them.
+func VerifyPlatformPolicy(pckCert *x509.Certificate, platformInfo models.PlatformInfo,
+	policy PlatformPolicy) (*models.PlatformPolicy, error) {
+	if pckCert == nil {
+		return nil, errors.New("VerifyPlatformPolicy: PCK Certificate is empty")
+	}
+	if pckCert.Issuer.String() != constants.SGXPlatformCACertSubjectStr {
+		return nil, nil
+	}
+
+	result := &models.PlatformPolicy{
+		SmtEnabledAllowed:      !policy.RejectSmtEnabled,
+		DynamicPlatformAllowed: !policy.RejectDynamicPlatform,
+	}
+	if platformInfo.Configuration == nil {
+		// the flags can not be evaluated, which is only acceptable if the policy does not depend on them
+		if policy.RejectSmtEnabled || policy.RejectDynamicPlatform {
+			return nil, errors.New("VerifyPlatformPolicy: Platform Configuration not found in PCK Certificate")
+		}
+		return result, nil
+	}
+
+	result.SmtEnabled = platformInfo.Configuration.SmtEnabled
+	result.DynamicPlatform = platformInfo.Configuration.DynamicPlatform
+	if result.SmtEnabled && !result.SmtEnabledAllowed {
+		return nil, errors.New("VerifyPlatformPolicy: platforms with SMT enabled are rejected by the platform policy")
+	}
+	if result.DynamicPlatform && !result.DynamicPlatformAllowed {
+		return nil, errors.New("VerifyPlatformPolicy: dynamic platforms are rejected by the platform policy")
+	}
+	return result, nil
+}
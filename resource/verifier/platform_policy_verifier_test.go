@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPlatformPolicy(t *testing.T) {
+	issuer := func(cn string) *x509.Certificate {
+		return &x509.Certificate{Issuer: pkix.Name{CommonName: cn, Organization: []string{"Intel Corporation"},
+			Locality: []string{"Santa Clara"}, Province: []string{"CA"}, Country: []string{"US"}}}
+	}
+	processorPck := issuer("Intel SGX PCK Processor CA")
+	platformPck := issuer("Intel SGX PCK Platform CA")
+	smtEnabled := models.PlatformInfo{SgxType: models.SgxTypeScalable,
+		Configuration: &models.PlatformConfiguration{SmtEnabled: true}}
+	dynamicPlatform := models.PlatformInfo{SgxType: models.SgxTypeScalable,
+		Configuration: &models.PlatformConfiguration{DynamicPlatform: true, CachedKeys: true}}
+	noConfiguration := models.PlatformInfo{SgxType: models.SgxTypeScalable}
+
+	tests := []struct {
+		name         string
+		pckCert      *x509.Certificate
+		platformInfo models.PlatformInfo
+		policy       PlatformPolicy
+		want         *models.PlatformPolicy
+		wantErr      bool
+	}{
+		{
+			name:    "missing PCK certificate",
+			wantErr: true,
+		},
+		{
+			name:         "processor CA certificate is not evaluated",
+			pckCert:      processorPck,
+			platformInfo: models.PlatformInfo{SgxType: models.SgxTypeStandard},
+			policy:       PlatformPolicy{RejectSmtEnabled: true, RejectDynamicPlatform: true},
+		},
+		{
+			name:         "SMT enabled accepted by default",
+			pckCert:      platformPck,
+			platformInfo: smtEnabled,
+			want: &models.PlatformPolicy{SmtEnabled: true, SmtEnabledAllowed: true,
+				DynamicPlatformAllowed: true},
+		},
+		{
+			name:         "SMT enabled rejected",
+			pckCert:      platformPck,
+			platformInfo: smtEnabled,
+			policy:       PlatformPolicy{RejectSmtEnabled: true},
+			wantErr:      true,
+		},
+		{
+			name:         "dynamic platform accepted when only SMT is rejected",
+			pckCert:      platformPck,
+			platformInfo: dynamicPlatform,
+			policy:       PlatformPolicy{RejectSmtEnabled: true},
+			want:         &models.PlatformPolicy{DynamicPlatform: true, DynamicPlatformAllowed: true},
+		},
+		{
+			name:         "dynamic platform rejected",
+			pckCert:      platformPck,
+			platformInfo: dynamicPlatform,
+			policy:       PlatformPolicy{RejectDynamicPlatform: true},
+			wantErr:      true,
+		},
+		{
+			name:         "missing configuration accepted without restrictions",
+			pckCert:      platformPck,
+			platformInfo: noConfiguration,
+			want:         &models.PlatformPolicy{SmtEnabledAllowed: true, DynamicPlatformAllowed: true},
+		},
+		{
+			name:         "missing configuration rejected with restrictions",
+			pckCert:      platformPck,
+			platformInfo: noConfiguration,
+			policy:       PlatformPolicy{RejectDynamicPlatform: true},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VerifyPlatformPolicy(tt.pckCert, tt.platformInfo, tt.policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
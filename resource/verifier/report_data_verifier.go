@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/domain/models"
+
+	"github.com/pkg/errors"
+)
+
+// IsValidReportDataBinding reports whether binding is a supported report data binding, empty selects sha256
+func IsValidReportDataBinding(binding string) bool {
+	switch binding {
+	case "", constants.ReportDataBindingSHA256, constants.ReportDataBindingSHA384, constants.ReportDataBindingSHA512,
+		constants.ReportDataBindingNoncePubKey, constants.ReportDataBindingRaw:
+		return true
+	}
+	return false
+}
+
+// VerifyReportData checks that the enclave report data binds userData as selected by binding. With sha256, sha384
+// and sha512 the digest of userData fills the first 32, 48 or 64 bytes of the report data, with nonce-pubkey the
+// SHA-256 digest of the nonce followed by userData, the enclave public key, fills the first 32 bytes and with raw
+// the report data is userData padded with zeros.
+func VerifyReportData(reportData []byte, binding string, userData, nonce []byte) error {
+	if len(reportData) != models.ReportDataSize {
+		return errors.New("VerifyReportData: Invalid report data size")
+	}
+
+	if binding == "" {
+		binding = constants.ReportDataBindingSHA256
+	}
+
+	var expected []byte
+	switch binding {
+	case constants.ReportDataBindingSHA256:
+		sum := sha256.Sum256(userData)
+		expected = sum[:]
+	case constants.ReportDataBindingSHA384:
+		sum := sha512.Sum384(userData)
+		expected = sum[:]
+	case constants.ReportDataBindingSHA512:
+		sum := sha512.Sum512(userData)
+		expected = sum[:]
+	case constants.ReportDataBindingNoncePubKey:
+		if len(nonce) == 0 {
+			return errors.New("VerifyReportData: nonce is required for " + binding + " binding")
+		}
+		h := sha256.New()
+		h.Write(nonce)
+		h.Write(userData)
+		expected = h.Sum(nil)
+	case constants.ReportDataBindingRaw:
+		if len(userData) > models.ReportDataSize {
+			return errors.New("VerifyReportData: user data exceeds the report data size")
+		}
+		expected = make([]byte, models.ReportDataSize)
+		copy(expected, userData)
+	default:
+		return errors.New("VerifyReportData: unsupported report data binding " + binding)
+	}
+
+	if !bytes.Equal(expected, reportData[:len(expected)]) {
+		return errors.New("VerifyReportData: report data does not match " + binding + " binding of user data")
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"intel/isecl/sqvs/v5/constants"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testReportData(prefix []byte) []byte {
+	reportData := make([]byte, 64)
+	copy(reportData, prefix)
+	return reportData
+}
+
+func TestVerifyReportData(t *testing.T) {
+	userData := []byte("enclave public key")
+	nonce := []byte("nonce")
+	sha256Sum := sha256.Sum256(userData)
+	sha384Sum := sha512.Sum384(userData)
+	sha512Sum := sha512.Sum512(userData)
+	nonceSum := sha256.Sum256(append(append([]byte{}, nonce...), userData...))
+
+	tests := []struct {
+		name       string
+		reportData []byte
+		binding    string
+		nonce      []byte
+		wantErr    bool
+	}{
+		{name: "default binding", reportData: testReportData(sha256Sum[:]), binding: ""},
+		{name: "sha256", reportData: testReportData(sha256Sum[:]), binding: constants.ReportDataBindingSHA256},
+		{name: "sha384", reportData: testReportData(sha384Sum[:]), binding: constants.ReportDataBindingSHA384},
+		{name: "sha512", reportData: sha512Sum[:], binding: constants.ReportDataBindingSHA512},
+		{name: "nonce and public key", reportData: testReportData(nonceSum[:]),
+			binding: constants.ReportDataBindingNoncePubKey, nonce: nonce},
+		{name: "raw", reportData: testReportData(userData), binding: constants.ReportDataBindingRaw},
+		{name: "sha256 digest checked as sha384", reportData: testReportData(sha256Sum[:]),
+			binding: constants.ReportDataBindingSHA384, wantErr: true},
+		{name: "sha384 digest checked as sha512", reportData: testReportData(sha384Sum[:]),
+			binding: constants.ReportDataBindingSHA512, wantErr: true},
+		{name: "nonce missing", reportData: testReportData(nonceSum[:]),
+			binding: constants.ReportDataBindingNoncePubKey, wantErr: true},
+		{name: "wrong nonce", reportData: testReportData(nonceSum[:]),
+			binding: constants.ReportDataBindingNoncePubKey, nonce: []byte("other"), wantErr: true},
+		{name: "raw with trailing data", reportData: testReportData(append(userData, 1)),
+			binding: constants.ReportDataBindingRaw, wantErr: true},
+		{name: "unsupported binding", reportData: testReportData(sha256Sum[:]), binding: "md5", wantErr: true},
+		{name: "short report data", reportData: sha256Sum[:], binding: constants.ReportDataBindingSHA256,
+			wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyReportData(tt.reportData, tt.binding, userData, tt.nonce)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// raw user data larger than the report data
+	err := VerifyReportData(testReportData(nil), constants.ReportDataBindingRaw, make([]byte, 65), nil)
+	assert.Error(t, err)
+}
+
+func TestIsValidReportDataBinding(t *testing.T) {
+	assert.True(t, IsValidReportDataBinding(""))
+	assert.True(t, IsValidReportDataBinding(constants.ReportDataBindingNoncePubKey))
+	assert.False(t, IsValidReportDataBinding("SHA256"))
+}
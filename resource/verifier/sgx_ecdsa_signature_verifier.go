@@ -8,6 +8,9 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"math/big"
 
 	"github.com/pkg/errors"
@@ -58,3 +61,45 @@ func VerifyEnclaveReportSignature(sigBlob, blob, attestPubKeyBlob []byte) error
 	}
 	return nil
 }
+
+// VerifyCollateralSignature verifies the signature of a TCB info or QE identity body with the public key of
+// the signing certificate. The signature is the hex encoded concatenation of r and s.
+func VerifyCollateralSignature(body []byte, signature string, signingCert *x509.Certificate) error {
+	sigBlob, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.Wrap(err, "Collateral Signature is not hex encoded")
+	}
+	if len(sigBlob) != 64 {
+		return errors.New("Collateral Signature has an invalid length")
+	}
+	pubKey, ok := signingCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("Collateral Signing Certificate does not carry an ECDSA key")
+	}
+	if !verifyECDSA256Signature(body, pubKey, sigBlob) {
+		return errors.New("Collateral Signature Verification Failed")
+	}
+	return nil
+}
+
+// VerifyCollateralDocumentSignature verifies the signature of a TCB info or QE identity document as served by SCS
+// with the signing certificate. The signature covers the JSON of the object named object, tcbInfo or
+// enclaveIdentity, as served.
+func VerifyCollateralDocumentSignature(document []byte, object string, signingCert *x509.Certificate) error {
+	if signingCert == nil {
+		return errors.New("Collateral Signing Certificate is empty")
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return errors.Wrap(err, "Could not parse collateral document")
+	}
+	body, ok := doc[object]
+	if !ok {
+		return errors.Errorf("Collateral document has no %s", object)
+	}
+	var signature string
+	if err := json.Unmarshal(doc["signature"], &signature); err != nil {
+		return errors.Wrap(err, "Collateral document has no valid signature")
+	}
+	return VerifyCollateralSignature(body, signature, signingCert)
+}
@@ -10,7 +10,9 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -83,3 +85,67 @@ func TestVerifyEnclaveReportSignature(t *testing.T) {
 	err = VerifyEnclaveReportSignature(signatureBytes, msg, pem.EncodeToMemory(publickeyPem))
 	assert.NotNil(t, err)
 }
+
+func TestVerifyCollateralSignature(t *testing.T) {
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	cert := &x509.Certificate{PublicKey: &privateKey.PublicKey}
+
+	body := []byte(`{"version":2}`)
+	hash := sha256.Sum256(body)
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	assert.Nil(t, err)
+	sigBlob := make([]byte, 64)
+	r.FillBytes(sigBlob[:32])
+	s.FillBytes(sigBlob[32:])
+	signature := hex.EncodeToString(sigBlob)
+
+	err = VerifyCollateralSignature(body, signature, cert)
+	assert.Nil(t, err)
+
+	err = VerifyCollateralSignature([]byte(`{"version":3}`), signature, cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralSignature(body, signature[:64], cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralSignature(body, "not hex", cert)
+	assert.NotNil(t, err)
+}
+
+func TestVerifyCollateralDocumentSignature(t *testing.T) {
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	cert := &x509.Certificate{PublicKey: &privateKey.PublicKey}
+
+	body := `{"version":2,"tcbLevels":[{"tcbStatus":"OutOfDate"}]}`
+	hash := sha256.Sum256([]byte(body))
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
+	assert.Nil(t, err)
+	sigBlob := make([]byte, 64)
+	r.FillBytes(sigBlob[:32])
+	s.FillBytes(sigBlob[32:])
+	document := `{"tcbInfo":` + body + `,"signature":"` + hex.EncodeToString(sigBlob) + `"}`
+
+	err = VerifyCollateralDocumentSignature([]byte(document), "tcbInfo", cert)
+	assert.Nil(t, err)
+
+	// the object is checked as served, a changed status fails
+	tampered := strings.Replace(document, "OutOfDate", "UpToDate", 1)
+	err = VerifyCollateralDocumentSignature([]byte(tampered), "tcbInfo", cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralDocumentSignature([]byte(document), "enclaveIdentity", cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralDocumentSignature([]byte(`{"tcbInfo":`+body+`}`), "tcbInfo", cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralDocumentSignature([]byte("not json"), "tcbInfo", cert)
+	assert.NotNil(t, err)
+
+	err = VerifyCollateralDocumentSignature([]byte(document), "tcbInfo", nil)
+	assert.NotNil(t, err)
+}
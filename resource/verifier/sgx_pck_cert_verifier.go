@@ -9,12 +9,13 @@ import (
 	"crypto/x509/pkix"
 	"intel/isecl/sqvs/v5/constants"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 func VerifyPCKCertificate(pckCert *x509.Certificate, interCA, rootCA []*x509.Certificate,
-	crl []*pkix.CertificateList, trustedRootCA *x509.Certificate) error {
+	crl []*pkix.CertificateList, trustedRootCA *x509.Certificate, verificationTime time.Time) error {
 	numInterCA := len(interCA)
 	numRootCA := len(rootCA)
 	numCrl := len(crl)
@@ -36,9 +37,10 @@ func VerifyPCKCertificate(pckCert *x509.Certificate, interCA, rootCA []*x509.Cer
 	}
 
 	var opts x509.VerifyOptions
+	opts.CurrentTime = verificationTime
 	opts.Intermediates = x509.NewCertPool()
 	for i := 0; i < numInterCA; i++ {
-		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXInterCACertSubjectStr)
+		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXInterCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "Invalid Intermediate CA Certificate")
 		}
@@ -46,7 +48,7 @@ func VerifyPCKCertificate(pckCert *x509.Certificate, interCA, rootCA []*x509.Cer
 	}
 	opts.Roots = x509.NewCertPool()
 	for i := 0; i < numRootCA; i++ {
-		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr)
+		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "Invalid Root CA Certificate")
 		}
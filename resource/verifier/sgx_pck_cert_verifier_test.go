@@ -69,7 +69,7 @@ func createTestCert(commonName string, IsCA bool, rootCA *x509.Certificate) *x50
 
 func TestVerifyPCKCertificate(t *testing.T) {
 
-	err := VerifyPCKCertificate(nil, nil, nil, nil, nil)
+	err := VerifyPCKCertificate(nil, nil, nil, nil, nil, time.Now())
 	assert.NotNil(t, err)
 
 	rootCA := createTestCert("Intel SGX Root CA", true, nil)
@@ -82,10 +82,10 @@ func TestVerifyPCKCertificate(t *testing.T) {
 		},
 	}
 
-	err = VerifyPCKCertificate(pckCert, []*x509.Certificate{rootCA}, []*x509.Certificate{intermediateCA}, []*pkix.CertificateList{crl}, rootCA)
+	err = VerifyPCKCertificate(pckCert, []*x509.Certificate{rootCA}, []*x509.Certificate{intermediateCA}, []*pkix.CertificateList{crl}, rootCA, time.Now())
 	assert.NotNil(t, err)
 
 	pckCert = createTestCert("Intel SGX PCK Certificate Test", false, intermediateCA)
-	err = VerifyPCKCertificate(pckCert, []*x509.Certificate{rootCA}, []*x509.Certificate{intermediateCA}, []*pkix.CertificateList{crl}, rootCA)
+	err = VerifyPCKCertificate(pckCert, []*x509.Certificate{rootCA}, []*x509.Certificate{intermediateCA}, []*pkix.CertificateList{crl}, rootCA, time.Now())
 	assert.NotNil(t, err)
 }
@@ -5,35 +5,159 @@
 package verifier
 
 import (
+	"bytes"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
 	"intel/isecl/sqvs/v5/constants"
+	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-func checkExpiry(crl *pkix.CertificateList) bool {
-	if crl.HasExpired(time.Now()) {
+var ExtCRLNumberOid = asn1.ObjectIdentifier{2, 5, 29, 20}
+
+type authorityKeyIdentifier struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+// checkExpiry rejects CRLs whose nextUpdate lies more than expiryGrace before verificationTime
+func checkExpiry(crl *pkix.CertificateList, verificationTime time.Time, expiryGrace time.Duration) bool {
+	if crl.HasExpired(verificationTime.Add(-expiryGrace)) {
 		log.Error("Certificate Revocation List Has Expired")
 		return false
 	}
 	return true
 }
 
+// checkThisUpdate rejects CRLs which are not yet valid or whose validity period is empty
+func checkThisUpdate(crl *pkix.CertificateList, verificationTime time.Time) bool {
+	thisUpdate := crl.TBSCertList.ThisUpdate
+	if thisUpdate.IsZero() || thisUpdate.After(verificationTime) {
+		log.Error("Certificate Revocation List is not yet valid")
+		return false
+	}
+	if !crl.TBSCertList.NextUpdate.After(thisUpdate) {
+		log.Error("Certificate Revocation List nextUpdate is not after thisUpdate")
+		return false
+	}
+	return true
+}
+
 func verifyPckCrlIssuer(crl *pkix.CertificateList) bool {
 	issuer := crl.TBSCertList.Issuer.String()
 	return verifyCaSubject(issuer, constants.SGXCRLIssuerStr)
 }
 
+func getCrlExtension(crl *pkix.CertificateList, oid asn1.ObjectIdentifier) *pkix.Extension {
+	for i := range crl.TBSCertList.Extensions {
+		if crl.TBSCertList.Extensions[i].Id.Equal(oid) {
+			return &crl.TBSCertList.Extensions[i]
+		}
+	}
+	return nil
+}
+
+// getCrlNumber returns the mandatory CRL number extension of the CRL
+func getCrlNumber(crl *pkix.CertificateList) (*big.Int, error) {
+	ext := getCrlExtension(crl, ExtCRLNumberOid)
+	if ext == nil {
+		return nil, errors.New("getCrlNumber: CRL Number extension not found")
+	}
+	number := new(big.Int)
+	rest, err := asn1.Unmarshal(ext.Value, &number)
+	if err != nil || len(rest) != 0 {
+		return nil, errors.New("getCrlNumber: invalid CRL Number extension")
+	}
+	if number.Sign() < 0 {
+		return nil, errors.New("getCrlNumber: CRL Number is negative")
+	}
+	return number, nil
+}
+
+// getCrlAuthorityKeyID returns the key identifier of the authority key identifier extension of the CRL
+func getCrlAuthorityKeyID(crl *pkix.CertificateList) ([]byte, error) {
+	ext := getCrlExtension(crl, ExtAuthorityKeyIdentifierOid)
+	if ext == nil {
+		return nil, errors.New("getCrlAuthorityKeyID: Authority Key Identifier extension not found")
+	}
+	var aki authorityKeyIdentifier
+	rest, err := asn1.Unmarshal(ext.Value, &aki)
+	if err != nil || len(rest) != 0 || len(aki.ID) == 0 {
+		return nil, errors.New("getCrlAuthorityKeyID: invalid Authority Key Identifier extension")
+	}
+	return aki.ID, nil
+}
+
+// findCrlIssuer returns the CA certificate whose subject matches the CRL issuer name and whose subject key
+// identifier matches the CRL authority key identifier
+func findCrlIssuer(crl *pkix.CertificateList, caList []*x509.Certificate) (*x509.Certificate, error) {
+	var issuer pkix.Name
+	issuer.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	aki, err := getCrlAuthorityKeyID(crl)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ca := range caList {
+		if ca.Subject.String() == issuer.String() && bytes.Equal(ca.SubjectKeyId, aki) {
+			return ca, nil
+		}
+	}
+	return nil, errors.New("findCrlIssuer: no CA certificate matches CRL issuer " + issuer.String())
+}
+
+// CrlNumberTracker tracks the highest CRL number seen in verified PCK CRLs per issuing CA. A CRL with a lower
+// number than seen before is rejected, which keeps a stale or malicious collateral provider from serving a CRL
+// issued before a PCK certificate was revoked. A CrlNumberTracker is safe for concurrent use.
+type CrlNumberTracker struct {
+	mu      sync.Mutex
+	highest map[string]*big.Int
+}
+
+// NewCrlNumberTracker returns an empty CrlNumberTracker
+func NewCrlNumberTracker() *CrlNumberTracker {
+	return &CrlNumberTracker{highest: make(map[string]*big.Int)}
+}
+
+// crlNumberKey identifies the issuer of a CRL by the subject and key identifier of the issuing CA
+func crlNumberKey(issuerCA *x509.Certificate) string {
+	return issuerCA.Subject.String() + ":" + hex.EncodeToString(issuerCA.SubjectKeyId)
+}
+
+// Observe records the CRL number of a verified CRL issued by issuerCA. It fails for a number below the highest
+// number recorded for issuerCA.
+func (t *CrlNumberTracker) Observe(issuerCA *x509.Certificate, number *big.Int) error {
+	key := crlNumberKey(issuerCA)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	highest, ok := t.highest[key]
+	if ok && number.Cmp(highest) < 0 {
+		return errors.Errorf("CRL Number %s of %s is below %s seen before", number.String(),
+			issuerCA.Subject.String(), highest.String())
+	}
+	if !ok || number.Cmp(highest) > 0 {
+		t.highest[key] = new(big.Int).Set(number)
+	}
+	return nil
+}
+
+// VerifyPckCrl verifies the PCK CRLs against their issuing intermediate CAs at verificationTime, allowing for
+// expiryGrace past their nextUpdate. The CRL numbers are checked against and recorded in crlNumbers unless it
+// is nil.
 func VerifyPckCrl(crlURL []string, crlList []*pkix.CertificateList, interCA,
-	rootCA []*x509.Certificate, trustedRootCA *x509.Certificate) error {
+	rootCA []*x509.Certificate, trustedRootCA *x509.Certificate, verificationTime time.Time,
+	expiryGrace time.Duration, crlNumbers *CrlNumberTracker) error {
 	numInterCA := len(interCA)
 	numRootCA := len(rootCA)
 	numCrlList := len(crlList)
 
-	if numCrlList == 0 || numInterCA == 0 || numRootCA == 0 {
+	if numCrlList == 0 || numInterCA == 0 || numRootCA == 0 || trustedRootCA == nil {
 		return errors.New("VerifyPckCrl: CRL List/InterCA/RootCA is empty")
 	}
 
@@ -42,35 +166,59 @@ func VerifyPckCrl(crlURL []string, crlList []*pkix.CertificateList, interCA,
 	}
 
 	for i := 0; i < numInterCA; i++ {
-		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXInterCACertSubjectStr)
+		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXInterCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyPckCrl: verifyInterCaCert failed")
 		}
 	}
 
 	for i := 0; i < numRootCA; i++ {
-		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr)
+		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyPckCrl: verifyRootCaCert failed ")
 		}
 	}
 
-	for i := 0; i < numCrlList; i++ {
-		ret := checkExpiry(crlList[i])
-		if !ret {
-			return errors.New("VerifyPckCrl: Revocation List has Expired" + crlURL[i])
+	for i, crl := range crlList {
+		url := ""
+		if i < len(crlURL) {
+			url = crlURL[i]
+		}
+		if crl == nil {
+			return errors.New("VerifyPckCrl: Revocation List is empty: " + url)
+		}
+		if !checkExpiry(crl, verificationTime, expiryGrace) {
+			return errors.New("VerifyPckCrl: Revocation List has Expired: " + url)
 		}
-		ret = verifyPckCrlIssuer(crlList[i])
-		if !ret {
-			return errors.New("VerifyPckCrl: CRL Issuer info is Invalid: " + crlURL[i])
+		if !checkThisUpdate(crl, verificationTime) {
+			return errors.New("VerifyPckCrl: Revocation List thisUpdate is Invalid: " + url)
+		}
+		if !verifyPckCrlIssuer(crl) {
+			return errors.New("VerifyPckCrl: CRL Issuer info is Invalid: " + url)
+		}
+
+		number, err := getCrlNumber(crl)
+		if err != nil {
+			return errors.Wrap(err, "VerifyPckCrl: CRL Number is Invalid: "+url)
+		}
+
+		issuerCA, err := findCrlIssuer(crl, interCA)
+		if err != nil {
+			return errors.Wrap(err, "VerifyPckCrl: CRL Issuer not found: "+url)
+		}
+
+		if err = issuerCA.CheckCRLSignature(crl); err != nil {
+			return errors.Wrap(err, "VerifyPckCrl: Signature Verification failed: "+url)
 		}
 
-		for j := 0; j < numInterCA; j++ {
-			err := interCA[i].CheckCRLSignature(crlList[i])
-			if err != nil {
-				return errors.New("VerifyPckCrl: Signature Verification failed")
+		// only CRLs with a verified signature may raise the highest CRL number
+		if crlNumbers != nil {
+			if err = crlNumbers.Observe(issuerCA, number); err != nil {
+				return errors.Wrap(err, "VerifyPckCrl: CRL Number rolled back: "+url)
 			}
 		}
+		log.Debugf("VerifyPckCrl: CRL %s number %s verified against %s", url, number.String(),
+			issuerCA.Subject.String())
 	}
 	return nil
 }
@@ -5,8 +5,14 @@
 package verifier
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
@@ -20,7 +26,7 @@ func TestCheckExpiry(t *testing.T) {
 			NextUpdate: time.Now().Add(1 * time.Hour),
 		},
 	}
-	got := checkExpiry(crl)
+	got := checkExpiry(crl, time.Now(), 0)
 	assert.Equal(t, true, got)
 
 	// expired nextupdate time
@@ -29,8 +35,12 @@ func TestCheckExpiry(t *testing.T) {
 			NextUpdate: time.Now().Add(-1 * time.Hour),
 		},
 	}
-	got = checkExpiry(crl)
+	got = checkExpiry(crl, time.Now(), 0)
 	assert.Equal(t, false, got)
+
+	// expired nextupdate time within the grace period
+	got = checkExpiry(crl, time.Now(), 2*time.Hour)
+	assert.Equal(t, true, got)
 }
 
 func TestVerifyPckCrlIssuer(t *testing.T) {
@@ -51,7 +61,7 @@ func TestVerifyPckCrlIssuer(t *testing.T) {
 func TestVerifyPckCrl(t *testing.T) {
 
 	crlURL := []string{"http://test.com/"}
-	err := VerifyPckCrl(nil, nil, nil, nil, nil)
+	err := VerifyPckCrl(nil, nil, nil, nil, nil, time.Now(), 0, nil)
 	assert.NotNil(t, err)
 
 	rootCA := createTestCert("Intel SGX Root CA", true, nil)
@@ -63,9 +73,241 @@ func TestVerifyPckCrl(t *testing.T) {
 		},
 	}
 
-	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, []*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA)
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, []*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA, time.Now(), 0, nil)
 	assert.NotNil(t, err)
 
-	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, []*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA)
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, []*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA, time.Now(), 0, nil)
 	assert.NotNil(t, err)
 }
+
+type testSGXCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// newTestSGXCA creates a CA certificate carrying the extensions of the Intel SGX CAs, encoding the subject
+// common name first as in the Intel issued CRLs. The root CA is created when parent is nil.
+func newTestSGXCA(t *testing.T, commonName string, ski []byte, parent *testSGXCA) *testSGXCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	rawSubject, err := asn1.Marshal(pkix.RDNSequence{
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 3}, Value: commonName}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 10}, Value: "Intel Corporation"}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 7}, Value: "Santa Clara"}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 8}, Value: "CA"}},
+		{{Type: asn1.ObjectIdentifier{2, 5, 4, 6}, Value: "US"}},
+	})
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(int64(ski[0])),
+		RawSubject:            rawSubject,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          ski,
+		AuthorityKeyId:        ski,
+		CRLDistributionPoints: []string{"https://certificates.trustedservices.intel.com/IntelSGXRootCA.der"},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testSGXCA{cert: cert, key: key}
+}
+
+func (ca *testSGXCA) crl(t *testing.T, number int64, thisUpdate, nextUpdate time.Time) *pkix.CertificateList {
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}, ca.cert, ca.key)
+	assert.NoError(t, err)
+	crl, err := x509.ParseDERCRL(der)
+	assert.NoError(t, err)
+	return crl
+}
+
+func withoutCrlExtension(crl *pkix.CertificateList, oid asn1.ObjectIdentifier) *pkix.CertificateList {
+	var exts []pkix.Extension
+	for _, ext := range crl.TBSCertList.Extensions {
+		if !ext.Id.Equal(oid) {
+			exts = append(exts, ext)
+		}
+	}
+	crl.TBSCertList.Extensions = exts
+	return crl
+}
+
+func TestVerifyPckCrlMatchesIssuer(t *testing.T) {
+	rootCA := newTestSGXCA(t, "Intel SGX Root CA", []byte{1}, nil)
+	processorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{2}, rootCA)
+	platformCA := newTestSGXCA(t, "Intel SGX PCK Platform CA", []byte{3}, rootCA)
+	// same name as the Processor CA, different key and key identifier
+	otherProcessorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{4}, rootCA)
+	// same name and key identifier as the Processor CA, different key
+	forgedProcessorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{2}, rootCA)
+
+	now := time.Now()
+	valid := func(ca *testSGXCA) *pkix.CertificateList {
+		return ca.crl(t, 1, now.Add(-time.Hour), now.Add(time.Hour))
+	}
+
+	tests := []struct {
+		name    string
+		crls    []*pkix.CertificateList
+		interCA []*x509.Certificate
+		wantErr string
+	}{
+		{
+			name:    "single CRL",
+			crls:    []*pkix.CertificateList{valid(processorCA)},
+			interCA: []*x509.Certificate{processorCA.cert},
+		},
+		{
+			name:    "multiple CRLs from different issuers",
+			crls:    []*pkix.CertificateList{valid(processorCA), valid(platformCA)},
+			interCA: []*x509.Certificate{processorCA.cert, platformCA.cert},
+		},
+		{
+			name:    "issuers listed in a different order than the CRLs",
+			crls:    []*pkix.CertificateList{valid(processorCA), valid(platformCA)},
+			interCA: []*x509.Certificate{platformCA.cert, processorCA.cert},
+		},
+		{
+			name:    "more CRLs than intermediate CAs",
+			crls:    []*pkix.CertificateList{valid(processorCA), valid(processorCA)},
+			interCA: []*x509.Certificate{processorCA.cert},
+		},
+		{
+			name:    "issuer of one CRL missing",
+			crls:    []*pkix.CertificateList{valid(processorCA), valid(platformCA)},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "CRL Issuer not found",
+		},
+		{
+			name:    "issuer name matches, authority key identifier does not",
+			crls:    []*pkix.CertificateList{valid(otherProcessorCA)},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "CRL Issuer not found",
+		},
+		{
+			name:    "authority key identifier missing",
+			crls:    []*pkix.CertificateList{withoutCrlExtension(valid(processorCA), ExtAuthorityKeyIdentifierOid)},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "CRL Issuer not found",
+		},
+		{
+			name:    "signed by a different key",
+			crls:    []*pkix.CertificateList{valid(forgedProcessorCA)},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "Signature Verification failed",
+		},
+		{
+			name:    "CRL number missing",
+			crls:    []*pkix.CertificateList{withoutCrlExtension(valid(processorCA), ExtCRLNumberOid)},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "CRL Number is Invalid",
+		},
+		{
+			name:    "thisUpdate in the future",
+			crls:    []*pkix.CertificateList{processorCA.crl(t, 1, now.Add(time.Hour), now.Add(2*time.Hour))},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "thisUpdate is Invalid",
+		},
+		{
+			name:    "expired",
+			crls:    []*pkix.CertificateList{processorCA.crl(t, 1, now.Add(-2*time.Hour), now.Add(-time.Hour))},
+			interCA: []*x509.Certificate{processorCA.cert},
+			wantErr: "has Expired",
+		},
+		{
+			name:    "one of several CRLs expired",
+			crls:    []*pkix.CertificateList{valid(processorCA), platformCA.crl(t, 1, now.Add(-2*time.Hour), now.Add(-time.Hour))},
+			interCA: []*x509.Certificate{processorCA.cert, platformCA.cert},
+			wantErr: "has Expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crlURL := make([]string, len(tt.crls))
+			for i := range crlURL {
+				crlURL[i] = fmt.Sprintf("https://scs/pckcrl/%d", i)
+			}
+			err := VerifyPckCrl(crlURL, tt.crls, tt.interCA, []*x509.Certificate{rootCA.cert}, rootCA.cert, time.Now(), 0, nil)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyPckCrlAtVerificationTime(t *testing.T) {
+	rootCA := newTestSGXCA(t, "Intel SGX Root CA", []byte{1}, nil)
+	processorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{2}, rootCA)
+
+	now := time.Now()
+	crl := processorCA.crl(t, 1, now.Add(-50*time.Minute), now.Add(-30*time.Minute))
+	crlURL := []string{"https://scs/pckcrl"}
+	interCA := []*x509.Certificate{processorCA.cert}
+	rootCAList := []*x509.Certificate{rootCA.cert}
+
+	err := VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now.Add(-40*time.Minute), 0, nil)
+	assert.NoError(t, err)
+
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now, 0, nil)
+	assert.Error(t, err)
+
+	// expired CRL accepted within the grace period
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now, time.Hour, nil)
+	assert.NoError(t, err)
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now, 10*time.Minute, nil)
+	assert.Error(t, err)
+
+	// before the CRL was issued
+	err = VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now.Add(-55*time.Minute), 0, nil)
+	assert.Error(t, err)
+}
+
+func TestVerifyPckCrlNumberRollback(t *testing.T) {
+	rootCA := newTestSGXCA(t, "Intel SGX Root CA", []byte{1}, nil)
+	processorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{2}, rootCA)
+	platformCA := newTestSGXCA(t, "Intel SGX PCK Platform CA", []byte{3}, rootCA)
+	forgedProcessorCA := newTestSGXCA(t, "Intel SGX PCK Processor CA", []byte{2}, rootCA)
+
+	now := time.Now()
+	crlURL := []string{"https://scs/pckcrl"}
+	interCA := []*x509.Certificate{processorCA.cert, platformCA.cert}
+	rootCAList := []*x509.Certificate{rootCA.cert}
+	verify := func(crlNumbers *CrlNumberTracker, crl *pkix.CertificateList) error {
+		return VerifyPckCrl(crlURL, []*pkix.CertificateList{crl}, interCA, rootCAList, rootCA.cert, now, 0, crlNumbers)
+	}
+
+	crlNumbers := NewCrlNumberTracker()
+	assert.NoError(t, verify(crlNumbers, processorCA.crl(t, 5, now.Add(-time.Hour), now.Add(time.Hour))))
+	assert.NoError(t, verify(crlNumbers, processorCA.crl(t, 5, now.Add(-time.Hour), now.Add(time.Hour))))
+	err := verify(crlNumbers, processorCA.crl(t, 4, now.Add(-time.Hour), now.Add(time.Hour)))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "CRL Number rolled back")
+	}
+
+	// CRL numbers are tracked per issuer
+	assert.NoError(t, verify(crlNumbers, platformCA.crl(t, 1, now.Add(-time.Hour), now.Add(time.Hour))))
+
+	// CRLs failing signature verification do not raise the highest CRL number
+	assert.Error(t, verify(crlNumbers, forgedProcessorCA.crl(t, 9, now.Add(-time.Hour), now.Add(time.Hour))))
+	assert.NoError(t, verify(crlNumbers, processorCA.crl(t, 6, now.Add(-time.Hour), now.Add(time.Hour))))
+
+	// without a tracker CRL numbers are not compared
+	assert.NoError(t, verify(nil, processorCA.crl(t, 1, now.Add(-time.Hour), now.Add(time.Hour))))
+}
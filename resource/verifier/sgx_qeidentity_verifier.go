@@ -11,6 +11,7 @@ import (
 	"encoding/hex"
 	"intel/isecl/sqvs/v5/constants"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -20,7 +21,8 @@ const (
 	HashSize      = 32
 )
 
-func VerifyQeIDCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *x509.Certificate) error {
+func VerifyQeIDCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *x509.Certificate,
+	verificationTime time.Time) error {
 	numInterCA := len(interCA)
 	numRootCA := len(rootCA)
 
@@ -33,13 +35,13 @@ func VerifyQeIDCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *x50
 	}
 
 	for i := 0; i < numInterCA; i++ {
-		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXQEInfoSubjectStr)
+		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXQEInfoSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyQeIDCertChain: verifyInterCaCert failed")
 		}
 	}
 	for i := 0; i < numRootCA; i++ {
-		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr)
+		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyQeIDCertChain: verifyRootCaCert failed")
 		}
@@ -9,6 +9,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/hex"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -24,10 +25,10 @@ func TestVerifyQeIDCertChain(t *testing.T) {
 	intermediateCA.Extensions = append(intermediateCA.Extensions, pkix.Extension{Id: ExtKeyUsageOid, Critical: true})
 	intermediateCA.Extensions = append(intermediateCA.Extensions, pkix.Extension{Id: ExtBasicConstrainsOid, Critical: true})
 
-	err := VerifyQeIDCertChain(nil, nil, rootCA)
+	err := VerifyQeIDCertChain(nil, nil, rootCA, time.Now())
 	assert.NotNil(t, err)
 
-	err = VerifyQeIDCertChain([]*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA)
+	err = VerifyQeIDCertChain([]*x509.Certificate{intermediateCA}, []*x509.Certificate{rootCA}, rootCA, time.Now())
 	assert.NotNil(t, err)
 }
 
@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"intel/isecl/sqvs/v5/constants"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func verifyRootCaCrlIssuer(crl *pkix.CertificateList, trustedRootCA *x509.Certificate) bool {
+	var issuer pkix.Name
+	issuer.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	return verifyCaSubject(issuer.String(), constants.SGXRootCACertSubjectStr) &&
+		issuer.String() == trustedRootCA.Subject.String()
+}
+
+// VerifyRootCaCrl verifies that the Root CA CRL has been issued and signed by the trusted SGX Root CA
+// and is valid at verificationTime, allowing for expiryGrace past its nextUpdate
+func VerifyRootCaCrl(crl *pkix.CertificateList, trustedRootCA *x509.Certificate, verificationTime time.Time,
+	expiryGrace time.Duration) error {
+	if crl == nil || trustedRootCA == nil {
+		return errors.New("VerifyRootCaCrl: Root CA CRL/Trusted Root CA is empty")
+	}
+
+	if !verifyRootCaCrlIssuer(crl, trustedRootCA) {
+		return errors.New("VerifyRootCaCrl: CRL Issuer info is Invalid")
+	}
+
+	if !checkExpiry(crl, verificationTime, expiryGrace) {
+		return errors.New("VerifyRootCaCrl: Revocation List has Expired")
+	}
+
+	if !checkThisUpdate(crl, verificationTime) {
+		return errors.New("VerifyRootCaCrl: Revocation List thisUpdate is Invalid")
+	}
+
+	if err := trustedRootCA.CheckCRLSignature(crl); err != nil {
+		return errors.Wrap(err, "VerifyRootCaCrl: Signature Verification failed")
+	}
+	return nil
+}
+
+// CheckInterCaRevocation checks the intermediate CA certificates issued by the SGX Root CA against the
+// Root CA CRL. Serial numbers are only unique per issuer, so a certificate is revoked only when it has been
+// issued by the CRL issuer as well.
+func CheckInterCaRevocation(crl *pkix.CertificateList, interCA []*x509.Certificate) error {
+	if crl == nil {
+		return errors.New("CheckInterCaRevocation: Root CA CRL is empty")
+	}
+
+	var issuer pkix.Name
+	issuer.FillFromRDNSequence(&crl.TBSCertList.Issuer)
+	for _, cert := range interCA {
+		if cert.Issuer.String() != issuer.String() {
+			continue
+		}
+		for _, revoked := range crl.TBSCertList.RevokedCertificates {
+			if cert.SerialNumber.Cmp(revoked.SerialNumber) == 0 {
+				log.Errorf("Intermediate CA Certificate %s is Revoked", cert.Subject.String())
+				return errors.New("CheckInterCaRevocation: Intermediate CA Certificate is Revoked: " +
+					cert.Subject.String())
+			}
+		}
+	}
+	return nil
+}
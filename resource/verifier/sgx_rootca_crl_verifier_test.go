@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package verifier
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createSignedTestRootCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := createTestCert("Intel SGX Root CA", true, nil)
+	template.KeyUsage |= x509.KeyUsageCRLSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func createTestRootCaCrl(t *testing.T, rootCA *x509.Certificate, key *ecdsa.PrivateKey, nextUpdate time.Time,
+	revoked ...*big.Int) *pkix.CertificateList {
+	var revokedCerts []pkix.RevokedCertificate
+	for _, serial := range revoked {
+		revokedCerts = append(revokedCerts, pkix.RevokedCertificate{SerialNumber: serial, RevocationTime: time.Now()})
+	}
+	der, err := rootCA.CreateCRL(rand.Reader, key, revokedCerts, time.Now(), nextUpdate)
+	assert.NoError(t, err)
+	crl, err := x509.ParseDERCRL(der)
+	assert.NoError(t, err)
+	return crl
+}
+
+func TestVerifyRootCaCrl(t *testing.T) {
+	err := VerifyRootCaCrl(nil, nil, time.Now(), 0)
+	assert.NotNil(t, err)
+
+	rootCA, key := createSignedTestRootCA(t)
+	crl := createTestRootCaCrl(t, rootCA, key, time.Now().Add(time.Hour))
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 0)
+	assert.Nil(t, err)
+
+	// expired CRL
+	crl = createTestRootCaCrl(t, rootCA, key, time.Now().Add(-time.Hour))
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 0)
+	assert.NotNil(t, err)
+
+	// expired CRL accepted within the grace period
+	der, err := rootCA.CreateCRL(rand.Reader, key, nil, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	crl, err = x509.ParseDERCRL(der)
+	assert.NoError(t, err)
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 2*time.Hour)
+	assert.Nil(t, err)
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 30*time.Minute)
+	assert.NotNil(t, err)
+
+	// CRL signed by a different key claiming to be the Root CA
+	otherRootCA, otherKey := createSignedTestRootCA(t)
+	crl = createTestRootCaCrl(t, otherRootCA, otherKey, time.Now().Add(time.Hour))
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 0)
+	assert.NotNil(t, err)
+
+	// CRL issued by an intermediate CA
+	interCA := createTestCert("Intel SGX PCK Processor CA", true, nil)
+	interCA.KeyUsage |= x509.KeyUsageCRLSign
+	der, err = x509.CreateCertificate(rand.Reader, interCA, rootCA, &key.PublicKey, key)
+	assert.NoError(t, err)
+	interCA, err = x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	crl = createTestRootCaCrl(t, interCA, key, time.Now().Add(time.Hour))
+	err = VerifyRootCaCrl(crl, rootCA, time.Now(), 0)
+	assert.NotNil(t, err)
+}
+
+func TestCheckInterCaRevocation(t *testing.T) {
+	err := CheckInterCaRevocation(nil, nil)
+	assert.NotNil(t, err)
+
+	rootCA, key := createSignedTestRootCA(t)
+	interCA := createTestCert("Intel SGX PCK Processor CA", true, nil)
+	interCA.Issuer = rootCA.Subject
+	tcbSigningCA := createTestCert("Intel SGX TCB Signing", true, nil)
+	tcbSigningCA.Issuer = rootCA.Subject
+	tcbSigningCA.SerialNumber = big.NewInt(2020)
+
+	crl := createTestRootCaCrl(t, rootCA, key, time.Now().Add(time.Hour), big.NewInt(1))
+	err = CheckInterCaRevocation(crl, []*x509.Certificate{interCA, tcbSigningCA})
+	assert.Nil(t, err)
+
+	crl = createTestRootCaCrl(t, rootCA, key, time.Now().Add(time.Hour), big.NewInt(2020))
+	err = CheckInterCaRevocation(crl, []*x509.Certificate{interCA})
+	assert.Nil(t, err)
+	err = CheckInterCaRevocation(crl, []*x509.Certificate{interCA, tcbSigningCA})
+	assert.NotNil(t, err)
+
+	// the same serial number issued by another CA is not revoked by the Root CA CRL
+	pckCert := createTestCert("Intel SGX PCK Certificate", false, nil)
+	pckCert.SerialNumber = big.NewInt(2020)
+	err = CheckInterCaRevocation(crl, []*x509.Certificate{interCA, pckCert})
+	assert.Nil(t, err)
+}
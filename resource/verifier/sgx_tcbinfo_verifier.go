@@ -8,11 +8,13 @@ import (
 	"crypto/x509"
 	"intel/isecl/sqvs/v5/constants"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
-func VerifyTcbInfoCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *x509.Certificate) error {
+func VerifyTcbInfoCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *x509.Certificate,
+	verificationTime time.Time) error {
 	numInterCA := len(interCA)
 	numRootCA := len(rootCA)
 
@@ -25,13 +27,13 @@ func VerifyTcbInfoCertChain(interCA, rootCA []*x509.Certificate, trustedRootCA *
 	}
 
 	for i := 0; i < numInterCA; i++ {
-		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXTCBInfoSubjectStr)
+		err := verifyInterCaCert(interCA[i], rootCA, constants.SGXTCBInfoSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyTcbInfo: verifyInterCaCert failed")
 		}
 	}
 	for i := 0; i < numRootCA; i++ {
-		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr)
+		err := verifyRootCaCert(rootCA[i], constants.SGXRootCACertSubjectStr, verificationTime)
 		if err != nil {
 			return errors.Wrap(err, "VerifyTcbInfo: verifyRootCaCert failed")
 		}
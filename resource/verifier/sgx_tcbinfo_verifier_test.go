@@ -12,6 +12,7 @@ import (
 	"intel/isecl/sqvs/v5/test/utils"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -44,13 +45,13 @@ func TestVerifyTcbInfoCertChain(t *testing.T) {
 	trustedRootCA = thisRootCA
 
 	// NIL certificate info given.
-	err = VerifyTcbInfoCertChain(nil, nil, nil)
+	err = VerifyTcbInfoCertChain(nil, nil, nil, time.Now())
 	assert.NotNil(t, err)
 
-	err = VerifyTcbInfoCertChain(rootCA, rootCA, trustedRootCA)
+	err = VerifyTcbInfoCertChain(rootCA, rootCA, trustedRootCA, time.Now())
 	assert.NotNil(t, err)
 
-	err = VerifyTcbInfoCertChain(interCA, rootCA, trustedRootCA)
+	err = VerifyTcbInfoCertChain(interCA, rootCA, trustedRootCA, time.Now())
 	assert.NotNil(t, err)
 
 	// remove test files at the end.
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2022 Intel Corporation
+ * SPDX-License-Identifier: BSD-3-Clause
+ */
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// shutdownStep stops a part of the service, returning once its in-flight work has finished or ctx is done
+type shutdownStep struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// drain runs the shutdown steps concurrently and waits for all of them. An error is returned when a step
+// failed or did not finish before ctx was done.
+func drain(ctx context.Context, steps []shutdownStep) error {
+	errs := make([]error, len(steps))
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func(i int, step shutdownStep) {
+			defer wg.Done()
+			log.Infof("app:drain() Waiting for %s to finish in-flight work", step.name)
+			errs[i] = step.stop(ctx)
+			if errs[i] != nil {
+				log.WithError(errs[i]).Errorf("app:drain() Failed to gracefully stop %s", step.name)
+				return
+			}
+			log.Infof("app:drain() Stopped %s", step.name)
+		}(i, step)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return errors.Wrapf(err, "Failed to gracefully stop %s", steps[i].name)
+		}
+	}
+	return nil
+}
+
+// stopGRPCServer waits for the in-flight calls of the gRPC server, the remaining calls are cancelled when
+// ctx is done
+func stopGRPCServer(grpcServer *grpc.Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// stopHTTPServer waits for the in-flight requests of the HTTP server, the remaining requests are cancelled
+// through the base context of the server when ctx is done
+func stopHTTPServer(h *http.Server, cancelRequests context.CancelFunc) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		err := h.Shutdown(ctx)
+		if err != nil {
+			cancelRequests()
+		}
+		return err
+	}
+}
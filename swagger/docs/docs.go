@@ -172,7 +172,50 @@ type UnsignedSGXResponseInfo struct {
 //      "ConfigId": "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
 //      "TcbLevel": "OutofDate",
 //      "Quote": "<quote in request>",
-//      "Challenge": "DJ4m0A9eBwTUuuiJOwi5ALgyMP5X99KH+afqF6qjn0ImiA2ej8LnNgV377sdsS17JRkHJWzJbucmHufcuRtpfA=="
+//      "Challenge": "DJ4m0A9eBwTUuuiJOwi5ALgyMP5X99KH+afqF6qjn0ImiA2ej8LnNgV377sdsS17JRkHJWzJbucmHufcuRtpfA==",
+//      "enclaveReport": {
+//        "cpuSvn": "05050c0cffff00000000000000000000",
+//        "miscSelect": 0,
+//        "attributes": {
+//          "flags": 7,
+//          "xfrm": 231,
+//          "init": true,
+//          "debug": true,
+//          "mode64Bit": true,
+//          "provisionKey": false,
+//          "einitTokenKey": false,
+//          "kss": false
+//        },
+//        "mrEnclave": "9270442d1bd1961fa39dbe1f2cdf4f87950a54fcaf9a2e5013875c3346542dca",
+//        "mrSigner": "d412a4f07ef83892a5915fb2ab584be31e186e5a4f95ab5f6950fd4eb8694d7b",
+//        "isvProdId": 0,
+//        "isvSvn": 1,
+//        "isvExtProdId": "00000000000000000000000000000000",
+//        "isvFamilyId": "00000000000000000000000000000000",
+//        "configId": "00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+//        "configSvn": 0,
+//        "reportData": "0000000000000000000000000000000000000000000000000000000000000000"
+//      },
+//      "platform": {
+//        "fmspc": "10606a000000",
+//        "pceId": "0000",
+//        "qeSvn": 5,
+//        "pceSvn": 10,
+//        "ppid": "20afa3c8fecb47c0a2311e4cbc4b6dd8",
+//        "sgxType": "Scalable",
+//        "platformInstanceId": "1a8392eca16822fcf0d130eae9e57b21",
+//        "configuration": {
+//          "dynamicPlatform": true,
+//          "cachedKeys": false,
+//          "smtEnabled": true
+//        }
+//      },
+//      "platformPolicy": {
+//        "smtEnabled": true,
+//        "smtEnabledAllowed": true,
+//        "dynamicPlatform": true,
+//        "dynamicPlatformAllowed": true
+//      }
 //    }
 //   }
 
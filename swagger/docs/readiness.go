@@ -0,0 +1,120 @@
+/*
+ *  Copyright (C) 2022 Intel Corporation
+ *  SPDX-License-Identifier: BSD-3-Clause
+ */
+
+package docs
+
+//
+// swagger:operation GET /v1/ready Readiness GetReadinessV1
+// ---
+// description: |
+//   GetReadiness reports whether the service accepts quote verifications. It fails once the service
+//   starts draining in-flight verifications on shutdown.
+//
+// produces:
+//   - text/plain
+// responses:
+//   '200':
+//     description: The service is ready.
+//     content: text/plain
+//   '503':
+//     description: The service is starting or shutting down.
+//
+// x-sample-call-endpoint: https://svs.com:12000/svs/v1/ready
+// x-sample-call-output: ready
+// ---
+
+// swagger:operation GET /v2/ready Readiness GetReadinessV2
+// ---
+// description: |
+//   GetReadiness reports whether the service accepts quote verifications. It fails once the service
+//   starts draining in-flight verifications on shutdown.
+//
+// produces:
+//   - text/plain
+// responses:
+//   '200':
+//     description: The service is ready.
+//     content: text/plain
+//   '503':
+//     description: The service is starting or shutting down.
+//
+// x-sample-call-endpoint: https://svs.com:12000/svs/v2/ready
+// x-sample-call-output: ready
+// ---
+
+// swagger:operation GET /v1/health Readiness GetHealthV1
+// ---
+// description: |
+//   GetHealth reports the readiness of the service along with the health and metrics of its background
+//   components, such as the collateral refresher. A failing component degrades the service without making
+//   it unready.
+//
+// produces:
+//   - application/json
+// responses:
+//   '200':
+//     description: The service is ready, its status is ok or degraded.
+//   '503':
+//     description: The service is starting or shutting down.
+//
+// x-sample-call-endpoint: https://svs.com:12000/svs/v1/health
+// x-sample-call-output: |
+//   {
+//     "status": "degraded",
+//     "checks": {
+//       "collateralRefresh": {
+//         "status": "failing",
+//         "error": "1 collateral refreshes failed, last error: Could not fetch /qe/identity, status code 503",
+//         "metrics": {
+//           "refreshes": 12,
+//           "failures": 1,
+//           "consecutiveFailures": 1,
+//           "lastRefresh": "2022-03-01T10:00:00Z",
+//           "lastFailure": "2022-03-01T10:01:00Z",
+//           "lastError": "Could not fetch /qe/identity, status code 503",
+//           "cachedEntries": 5,
+//           "scheduledEntries": 5
+//         }
+//       }
+//     }
+//   }
+// ---
+
+// swagger:operation GET /v2/health Readiness GetHealthV2
+// ---
+// description: |
+//   GetHealth reports the readiness of the service along with the health and metrics of its background
+//   components, such as the collateral refresher. A failing component degrades the service without making
+//   it unready.
+//
+// produces:
+//   - application/json
+// responses:
+//   '200':
+//     description: The service is ready, its status is ok or degraded.
+//   '503':
+//     description: The service is starting or shutting down.
+//
+// x-sample-call-endpoint: https://svs.com:12000/svs/v2/health
+// x-sample-call-output: |
+//   {
+//     "status": "degraded",
+//     "checks": {
+//       "collateralRefresh": {
+//         "status": "failing",
+//         "error": "1 collateral refreshes failed, last error: Could not fetch /qe/identity, status code 503",
+//         "metrics": {
+//           "refreshes": 12,
+//           "failures": 1,
+//           "consecutiveFailures": 1,
+//           "lastRefresh": "2022-03-01T10:00:00Z",
+//           "lastFailure": "2022-03-01T10:01:00Z",
+//           "lastError": "Could not fetch /qe/identity, status code 503",
+//           "cachedEntries": 5,
+//           "scheduledEntries": 5
+//         }
+//       }
+//     }
+//   }
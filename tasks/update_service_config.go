@@ -5,6 +5,8 @@
 package tasks
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"encoding/pem"
 	"flag"
 	"fmt"
@@ -12,6 +14,7 @@ import (
 	"intel/isecl/lib/common/v5/setup"
 	"intel/isecl/sqvs/v5/config"
 	"intel/isecl/sqvs/v5/constants"
+	"intel/isecl/sqvs/v5/resource/verifier"
 	"io"
 	"io/ioutil"
 	"net/url"
@@ -52,6 +55,17 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 	}
 	fmt.Fprintf(u.ConsoleWriter, "Using HTTPS port: %d\n", u.Config.Port)
 
+	grpcPort, err := c.GetenvInt("SQVS_GRPC_PORT", "sgx verification service gRPC port")
+	if err == nil {
+		if grpcPort != 0 && (grpcPort > 65535 || grpcPort <= 1024 || grpcPort == u.Config.Port) {
+			return errors.New("tasks/server:Run() Invalid or reserved gRPC port")
+		}
+		u.Config.GRPCPort = grpcPort
+	}
+	if u.Config.GRPCPort != 0 {
+		fmt.Fprintf(u.ConsoleWriter, "Using gRPC port: %d\n", u.Config.GRPCPort)
+	}
+
 	readTimeout, err := c.GetenvString("SQVS_SERVER_READ_TIMEOUT", "SGX Verification Service Read Timeout")
 	if err != nil {
 		u.Config.ReadTimeout = constants.DefaultReadTimeout
@@ -142,6 +156,144 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 		}
 	}
 
+	clientCertAuth, err := c.GetenvString("SQVS_CLIENT_CERT_AUTH", "Boolean value to enable client certificate "+
+		"based auth for quote verifier API")
+	if err == nil && clientCertAuth != "" {
+		u.Config.ClientCertAuth.Enabled, err = strconv.ParseBool(clientCertAuth)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_CLIENT_CERT_AUTH is not defined properly, must be true/false. Client certificate auth will be disabled\n")
+			u.Config.ClientCertAuth.Enabled = false
+		}
+	}
+
+	clientCertRequired, err := c.GetenvString("SQVS_CLIENT_CERT_REQUIRED", "Boolean value to require client "+
+		"certificate on every TLS connection")
+	if err == nil && clientCertRequired != "" {
+		u.Config.ClientCertAuth.Required, err = strconv.ParseBool(clientCertRequired)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_CLIENT_CERT_REQUIRED is not defined properly, must be true/false. Client certificate will be optional\n")
+			u.Config.ClientCertAuth.Required = false
+		}
+	}
+
+	clientCADir, err := c.GetenvString("SQVS_CLIENT_CA_DIR", "Directory of trusted client CA certificates")
+	if err == nil && strings.TrimSpace(clientCADir) != "" {
+		u.Config.ClientCertAuth.CADir = clientCADir
+	} else if u.Config.ClientCertAuth.CADir == "" {
+		u.Config.ClientCertAuth.CADir = constants.TrustedClientCAsDir
+	}
+
+	roleMappings, err := c.GetenvString("SQVS_CLIENT_CERT_ROLE_MAPPINGS", "JSON array of the roles, and the role "+
+		"context binding a tenant, granted to client certificates by subject or SAN")
+	if err == nil && strings.TrimSpace(roleMappings) != "" {
+		u.Config.ClientCertAuth.RoleMappings, err = parseClientCertRoleMappings(roleMappings)
+		if err != nil {
+			return errors.Wrap(err, "tasks/server:Run() SQVS_CLIENT_CERT_ROLE_MAPPINGS is not defined properly")
+		}
+	}
+
+	globalRate, err := c.GetenvString("SQVS_GLOBAL_RATE_LIMIT", "Maximum quote verification requests per second")
+	if err == nil && globalRate != "" {
+		u.Config.RateLimit.GlobalRate, err = strconv.ParseFloat(globalRate, 64)
+		if err != nil || u.Config.RateLimit.GlobalRate < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SQVS_GLOBAL_RATE_LIMIT, global rate limit will be disabled\n")
+			u.Config.RateLimit.GlobalRate = 0
+		}
+	}
+
+	globalBurst, err := c.GetenvInt("SQVS_GLOBAL_RATE_BURST", "Burst size for the global rate limit")
+	if err == nil {
+		u.Config.RateLimit.GlobalBurst = globalBurst
+	}
+
+	clientRate, err := c.GetenvString("SQVS_CLIENT_RATE_LIMIT", "Maximum quote verification requests per second per client")
+	if err == nil && clientRate != "" {
+		u.Config.RateLimit.ClientRate, err = strconv.ParseFloat(clientRate, 64)
+		if err != nil || u.Config.RateLimit.ClientRate < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SQVS_CLIENT_RATE_LIMIT, per client rate limit will be disabled\n")
+			u.Config.RateLimit.ClientRate = 0
+		}
+	}
+
+	clientBurst, err := c.GetenvInt("SQVS_CLIENT_RATE_BURST", "Burst size for the per client rate limit")
+	if err == nil {
+		u.Config.RateLimit.ClientBurst = clientBurst
+	}
+
+	tenants, err := c.GetenvString("SQVS_TENANTS", "JSON object of the tenants identified through the QuoteVerifier "+
+		"role context and their restrictions")
+	if err == nil && strings.TrimSpace(tenants) != "" {
+		u.Config.Tenants, err = parseTenants(tenants)
+		if err != nil {
+			return errors.Wrap(err, "tasks/server:Run() SQVS_TENANTS is not defined properly")
+		}
+	}
+
+	maxConcurrent, err := c.GetenvInt("SQVS_MAX_CONCURRENT_VERIFICATIONS", "Maximum number of quote verifications in flight")
+	if err == nil {
+		u.Config.RateLimit.MaxConcurrent = maxConcurrent
+	}
+	if u.Config.RateLimit.MaxConcurrent == 0 {
+		u.Config.RateLimit.MaxConcurrent = constants.DefaultMaxConcurrentVerify
+	}
+
+	maxQueued, err := c.GetenvInt("SQVS_MAX_QUEUED_VERIFICATIONS", "Maximum number of quote verifications waiting for a free slot")
+	if err == nil {
+		u.Config.RateLimit.MaxQueued = maxQueued
+	}
+	if u.Config.RateLimit.MaxQueued == 0 {
+		u.Config.RateLimit.MaxQueued = constants.DefaultMaxQueuedVerify
+	}
+
+	queueTimeout, err := c.GetenvString("SQVS_VERIFICATION_QUEUE_TIMEOUT", "Maximum time a quote verification waits for a free slot")
+	if err != nil {
+		if u.Config.RateLimit.QueueTimeout == 0 {
+			u.Config.RateLimit.QueueTimeout = constants.DefaultVerifyQueueTimeout
+		}
+	} else {
+		u.Config.RateLimit.QueueTimeout, err = time.ParseDuration(queueTimeout)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_VERIFICATION_QUEUE_TIMEOUT setting it to the default value\n")
+			u.Config.RateLimit.QueueTimeout = constants.DefaultVerifyQueueTimeout
+		}
+	}
+
+	auditLog, err := c.GetenvString("SQVS_AUDIT_LOG_ENABLED", "Boolean value to enable audit log of verification decisions")
+	if err == nil && auditLog != "" {
+		u.Config.Audit.Enabled, err = strconv.ParseBool(auditLog)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_AUDIT_LOG_ENABLED is not defined properly, must be true/false. Audit log will be disabled\n")
+			u.Config.Audit.Enabled = false
+		}
+	}
+
+	auditLogDir, err := c.GetenvString("SQVS_AUDIT_LOG_DIR", "Directory of the audit log files")
+	if err == nil && strings.TrimSpace(auditLogDir) != "" {
+		u.Config.Audit.Dir = auditLogDir
+	} else if u.Config.Audit.Dir == "" {
+		u.Config.Audit.Dir = constants.AuditLogDir
+	}
+
+	auditLogMaxSize, err := c.GetenvInt("SQVS_AUDIT_LOG_MAX_SIZE", "Audit log file size in MB after which it is rotated")
+	if err == nil && auditLogMaxSize >= 0 {
+		u.Config.Audit.MaxSizeMB = auditLogMaxSize
+	} else if u.Config.Audit.MaxSizeMB == 0 {
+		u.Config.Audit.MaxSizeMB = constants.DefaultAuditLogMaxSizeMB
+	}
+
+	auditLogMaxAge, err := c.GetenvString("SQVS_AUDIT_LOG_MAX_AGE", "Audit log file age after which it is rotated")
+	if err != nil {
+		if u.Config.Audit.MaxAge == 0 {
+			u.Config.Audit.MaxAge = constants.DefaultAuditLogMaxAge
+		}
+	} else {
+		u.Config.Audit.MaxAge, err = time.ParseDuration(auditLogMaxAge)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_AUDIT_LOG_MAX_AGE setting it to the default value\n")
+			u.Config.Audit.MaxAge = constants.DefaultAuditLogMaxAge
+		}
+	}
+
 	scsBaseUrl, err := c.GetenvString("SCS_BASE_URL", "SGX Caching Service URL")
 	if err == nil && scsBaseUrl != "" {
 		if _, err = url.ParseRequestURI(scsBaseUrl); err != nil {
@@ -153,6 +305,291 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 		return errors.Wrap(errors.New("SCS_BASE_URL is not defined in environment"), "SaveConfiguration() ENV variable not found")
 	}
 
+	scsTimeout, err := c.GetenvString("SCS_TIMEOUT", "Timeout of a single SGX Caching Service request")
+	if err == nil && scsTimeout != "" {
+		u.Config.SCSClient.Timeout, err = time.ParseDuration(scsTimeout)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SCS_TIMEOUT setting it to the default value\n")
+			u.Config.SCSClient.Timeout = constants.DefaultSCSTimeout
+		}
+	}
+
+	scsMaxRetries, err := c.GetenvInt("SCS_MAX_RETRIES", "Number of retries of failed SGX Caching Service requests")
+	if err == nil {
+		u.Config.SCSClient.MaxRetries = scsMaxRetries
+	}
+
+	scsRetryBackoff, err := c.GetenvString("SCS_RETRY_BACKOFF", "Initial backoff between SGX Caching Service request retries")
+	if err == nil && scsRetryBackoff != "" {
+		u.Config.SCSClient.RetryBackoff, err = time.ParseDuration(scsRetryBackoff)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SCS_RETRY_BACKOFF setting it to the default value\n")
+			u.Config.SCSClient.RetryBackoff = constants.DefaultSCSRetryBackoff
+		}
+	}
+
+	scsMaxRetryBackoff, err := c.GetenvString("SCS_MAX_RETRY_BACKOFF", "Maximum backoff between SGX Caching Service request retries")
+	if err == nil && scsMaxRetryBackoff != "" {
+		u.Config.SCSClient.MaxRetryBackoff, err = time.ParseDuration(scsMaxRetryBackoff)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SCS_MAX_RETRY_BACKOFF setting it to the default value\n")
+			u.Config.SCSClient.MaxRetryBackoff = constants.DefaultSCSMaxRetryBackoff
+		}
+	}
+
+	scsBreakerThreshold, err := c.GetenvInt("SCS_BREAKER_THRESHOLD", "Consecutive failed SGX Caching Service requests after which requests fail fast")
+	if err == nil && scsBreakerThreshold >= 0 {
+		u.Config.SCSClient.BreakerThreshold = scsBreakerThreshold
+	}
+
+	scsBreakerCooldown, err := c.GetenvString("SCS_BREAKER_COOLDOWN", "Time after which a failed SGX Caching Service is tried again")
+	if err == nil && scsBreakerCooldown != "" {
+		u.Config.SCSClient.BreakerCooldown, err = time.ParseDuration(scsBreakerCooldown)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SCS_BREAKER_COOLDOWN setting it to the default value\n")
+			u.Config.SCSClient.BreakerCooldown = constants.DefaultSCSBreakerCooldown
+		}
+	}
+
+	collateralExpiryGrace, err := c.GetenvString("SQVS_COLLATERAL_EXPIRY_GRACE", "Time past nextUpdate during which expired collateral is accepted")
+	if err == nil && collateralExpiryGrace != "" {
+		u.Config.CollateralExpiryGrace, err = time.ParseDuration(collateralExpiryGrace)
+		if err != nil || u.Config.CollateralExpiryGrace < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_COLLATERAL_EXPIRY_GRACE setting it to the default value\n")
+			u.Config.CollateralExpiryGrace = constants.DefaultCollateralExpiryGrace
+		}
+	}
+
+	minTcbEvaluationDataNumber, err := c.GetenvInt("SQVS_MIN_TCB_EVALUATION_DATA_NUMBER", "Minimum tcbEvaluationDataNumber of TCB info and QE identity")
+	if err == nil {
+		if minTcbEvaluationDataNumber < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid value provided for SQVS_MIN_TCB_EVALUATION_DATA_NUMBER, keeping the minimum at %d\n",
+				u.Config.MinTcbEvaluationDataNumber)
+		} else {
+			u.Config.MinTcbEvaluationDataNumber = uint(minTcbEvaluationDataNumber)
+		}
+	}
+
+	verificationTimeout, err := c.GetenvString("SQVS_VERIFICATION_TIMEOUT", "Maximum time the verification of a quote may take")
+	if err != nil {
+		if u.Config.VerificationTimeout == 0 {
+			u.Config.VerificationTimeout = constants.DefaultVerificationTimeout
+		}
+	} else {
+		u.Config.VerificationTimeout, err = time.ParseDuration(verificationTimeout)
+		if err != nil || u.Config.VerificationTimeout < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_VERIFICATION_TIMEOUT setting it to the default value\n")
+			u.Config.VerificationTimeout = constants.DefaultVerificationTimeout
+		}
+	}
+	// the verification result has to be written before the server write timeout closes the connection
+	if u.Config.WriteTimeout > 0 && u.Config.VerificationTimeout >= u.Config.WriteTimeout {
+		return errors.Errorf("tasks/server:Run() SQVS_VERIFICATION_TIMEOUT %s must be below SQVS_SERVER_WRITE_TIMEOUT %s",
+			u.Config.VerificationTimeout, u.Config.WriteTimeout)
+	}
+
+	shutdownTimeout, err := c.GetenvString("SQVS_SHUTDOWN_TIMEOUT", "Maximum time to drain in-flight verifications on shutdown")
+	if err != nil {
+		if u.Config.ShutdownTimeout == 0 {
+			u.Config.ShutdownTimeout = constants.DefaultShutdownTimeout
+		}
+	} else {
+		u.Config.ShutdownTimeout, err = time.ParseDuration(shutdownTimeout)
+		if err != nil || u.Config.ShutdownTimeout <= 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_SHUTDOWN_TIMEOUT setting it to the default value\n")
+			u.Config.ShutdownTimeout = constants.DefaultShutdownTimeout
+		}
+	}
+
+	shutdownDelay, err := c.GetenvString("SQVS_SHUTDOWN_DELAY", "Time between failing the readiness checks and stopping the servers on shutdown")
+	if err != nil {
+		if u.Config.ShutdownDelay == 0 {
+			u.Config.ShutdownDelay = constants.DefaultShutdownDelay
+		}
+	} else {
+		u.Config.ShutdownDelay, err = time.ParseDuration(shutdownDelay)
+		if err != nil || u.Config.ShutdownDelay < 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_SHUTDOWN_DELAY setting it to the default value\n")
+			u.Config.ShutdownDelay = constants.DefaultShutdownDelay
+		}
+	}
+
+	collateralRefresh, err := c.GetenvString("SQVS_COLLATERAL_REFRESH_ENABLED", "Boolean value to enable background refresh of collateral")
+	if err == nil && collateralRefresh != "" {
+		u.Config.CollateralRefresh.Enabled, err = strconv.ParseBool(collateralRefresh)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_COLLATERAL_REFRESH_ENABLED is not defined properly, must be true/false. Collateral refresh will be disabled\n")
+			u.Config.CollateralRefresh.Enabled = false
+		}
+	}
+
+	prefetchFmspcs, err := c.GetenvString("SQVS_COLLATERAL_PREFETCH_FMSPCS", "Comma separated FMSPCs whose collateral is prefetched")
+	if err == nil && strings.TrimSpace(prefetchFmspcs) != "" {
+		u.Config.CollateralRefresh.FMSPCs = nil
+		for _, fmspc := range strings.Split(prefetchFmspcs, ",") {
+			fmspc = strings.ToLower(strings.TrimSpace(fmspc))
+			if _, err := hex.DecodeString(fmspc); err != nil || len(fmspc) != constants.FmspcLen {
+				fmt.Fprintf(u.ConsoleWriter, "Invalid FMSPC %s provided in SQVS_COLLATERAL_PREFETCH_FMSPCS, skipping it\n", fmspc)
+				continue
+			}
+			u.Config.CollateralRefresh.FMSPCs = append(u.Config.CollateralRefresh.FMSPCs, fmspc)
+		}
+	}
+
+	collateralStore, err := c.GetenvString("SQVS_COLLATERAL_STORE_ENABLED", "Boolean value to enable persistence of collateral")
+	if err == nil && collateralStore != "" {
+		u.Config.CollateralStore.Enabled, err = strconv.ParseBool(collateralStore)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_COLLATERAL_STORE_ENABLED is not defined properly, must be true/false. Collateral will not be persisted\n")
+			u.Config.CollateralStore.Enabled = false
+		}
+	}
+
+	collateralDir, err := c.GetenvString("SQVS_COLLATERAL_DIR", "Directory of the persisted collateral")
+	if err == nil && strings.TrimSpace(collateralDir) != "" {
+		u.Config.CollateralStore.Dir = collateralDir
+	} else if u.Config.CollateralStore.Dir == "" {
+		u.Config.CollateralStore.Dir = constants.CollateralDir
+	}
+
+	refreshInterval, err := c.GetenvString("SQVS_COLLATERAL_REFRESH_INTERVAL", "Period at which collateral due for a refresh is looked for")
+	if err != nil {
+		if u.Config.CollateralRefresh.Interval == 0 {
+			u.Config.CollateralRefresh.Interval = constants.DefaultCollateralRefreshPeriod
+		}
+	} else {
+		u.Config.CollateralRefresh.Interval, err = time.ParseDuration(refreshInterval)
+		if err != nil || u.Config.CollateralRefresh.Interval <= 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_COLLATERAL_REFRESH_INTERVAL setting it to the default value\n")
+			u.Config.CollateralRefresh.Interval = constants.DefaultCollateralRefreshPeriod
+		}
+	}
+
+	refreshAhead, err := c.GetenvString("SQVS_COLLATERAL_REFRESH_AHEAD", "Time before nextUpdate at which collateral is refreshed")
+	if err != nil {
+		if u.Config.CollateralRefresh.RefreshAhead == 0 {
+			u.Config.CollateralRefresh.RefreshAhead = constants.DefaultCollateralRefreshAhead
+		}
+	} else {
+		u.Config.CollateralRefresh.RefreshAhead, err = time.ParseDuration(refreshAhead)
+		if err != nil || u.Config.CollateralRefresh.RefreshAhead <= 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_COLLATERAL_REFRESH_AHEAD setting it to the default value\n")
+			u.Config.CollateralRefresh.RefreshAhead = constants.DefaultCollateralRefreshAhead
+		}
+	}
+
+	collateralMaxAge, err := c.GetenvString("SQVS_COLLATERAL_MAX_AGE", "Time after which collateral is refreshed regardless of its nextUpdate")
+	if err != nil {
+		if u.Config.CollateralRefresh.MaxAge == 0 {
+			u.Config.CollateralRefresh.MaxAge = constants.DefaultCollateralMaxAge
+		}
+	} else {
+		u.Config.CollateralRefresh.MaxAge, err = time.ParseDuration(collateralMaxAge)
+		if err != nil || u.Config.CollateralRefresh.MaxAge <= 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_COLLATERAL_MAX_AGE setting it to the default value\n")
+			u.Config.CollateralRefresh.MaxAge = constants.DefaultCollateralMaxAge
+		}
+	}
+
+	replayEnabled, err := c.GetenvString("SQVS_REPLAY_DETECTION_ENABLED", "Boolean value to enable detection of replayed quotes")
+	if err == nil && replayEnabled != "" {
+		u.Config.Replay.Enabled, err = strconv.ParseBool(replayEnabled)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_REPLAY_DETECTION_ENABLED is not defined properly, must be true/false. Replay detection will be disabled\n")
+			u.Config.Replay.Enabled = false
+		}
+	}
+
+	replayKey, err := c.GetenvString("SQVS_REPLAY_KEY", "Identity of quotes for replay detection, quote or reportData")
+	if err == nil && replayKey != "" {
+		u.Config.Replay.KeyBy = replayKey
+		if replayKey != constants.ReplayKeyQuote && replayKey != constants.ReplayKeyReportData {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_REPLAY_KEY is not defined properly, must be %s/%s. Quotes will be identified by %s\n",
+				constants.ReplayKeyQuote, constants.ReplayKeyReportData, constants.ReplayKeyQuote)
+			u.Config.Replay.KeyBy = constants.ReplayKeyQuote
+		}
+	} else if u.Config.Replay.KeyBy == "" {
+		u.Config.Replay.KeyBy = constants.ReplayKeyQuote
+	}
+
+	replayTTL, err := c.GetenvString("SQVS_REPLAY_TTL", "Time verified quotes are remembered for replay detection")
+	if err != nil {
+		if u.Config.Replay.TTL == 0 {
+			u.Config.Replay.TTL = constants.DefaultReplayTTL
+		}
+	} else {
+		u.Config.Replay.TTL, err = time.ParseDuration(replayTTL)
+		if err != nil || u.Config.Replay.TTL <= 0 {
+			fmt.Fprintf(u.ConsoleWriter, "Invalid duration provided for SQVS_REPLAY_TTL setting it to the default value\n")
+			u.Config.Replay.TTL = constants.DefaultReplayTTL
+		}
+	}
+
+	replayMaxEntries, err := c.GetenvInt("SQVS_REPLAY_MAX_ENTRIES", "Maximum number of verified quotes remembered for replay detection")
+	if err == nil && replayMaxEntries > 0 {
+		u.Config.Replay.MaxEntries = replayMaxEntries
+	} else if u.Config.Replay.MaxEntries <= 0 {
+		u.Config.Replay.MaxEntries = constants.DefaultReplayMaxEntries
+	}
+
+	replayBackend, err := c.GetenvString("SQVS_REPLAY_BACKEND", "Backend of the replay detection, memory or file")
+	if err == nil && replayBackend != "" {
+		u.Config.Replay.Backend = replayBackend
+		if replayBackend != constants.ReplayBackendMemory && replayBackend != constants.ReplayBackendFile {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_REPLAY_BACKEND is not defined properly, must be %s/%s. Verified quotes will be remembered in %s\n",
+				constants.ReplayBackendMemory, constants.ReplayBackendFile, constants.ReplayBackendMemory)
+			u.Config.Replay.Backend = constants.ReplayBackendMemory
+		}
+	} else if u.Config.Replay.Backend == "" {
+		u.Config.Replay.Backend = constants.ReplayBackendMemory
+	}
+
+	replayFile, err := c.GetenvString("SQVS_REPLAY_FILE", "File of the file backend of the replay detection")
+	if err == nil && strings.TrimSpace(replayFile) != "" {
+		u.Config.Replay.File = replayFile
+	} else if u.Config.Replay.File == "" {
+		u.Config.Replay.File = constants.ReplayCacheFile
+	}
+
+	replayStrict, err := c.GetenvString("SQVS_REPLAY_STRICT", "Boolean value to reject replayed quotes")
+	if err == nil && replayStrict != "" {
+		u.Config.Replay.Strict, err = strconv.ParseBool(replayStrict)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_REPLAY_STRICT is not defined properly, must be true/false. Replayed quotes will be reported and not rejected\n")
+			u.Config.Replay.Strict = false
+		}
+	}
+
+	includePPID, err := c.GetenvString("SQVS_INCLUDE_PPID", "Boolean value to report the PPID of platforms in "+
+		"verification responses and audit records")
+	if err == nil && includePPID != "" {
+		u.Config.IncludePPID, err = strconv.ParseBool(includePPID)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_INCLUDE_PPID is not defined properly, must be true/false. The PPID will be omitted\n")
+			u.Config.IncludePPID = false
+		}
+	}
+
+	rejectSmtEnabled, err := c.GetenvString("SQVS_PLATFORM_REJECT_SMT_ENABLED", "Boolean value to reject quotes "+
+		"from Platform CA certified platforms with SMT enabled")
+	if err == nil && rejectSmtEnabled != "" {
+		u.Config.PlatformPolicy.RejectSmtEnabled, err = strconv.ParseBool(rejectSmtEnabled)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_PLATFORM_REJECT_SMT_ENABLED is not defined properly, must be true/false. Platforms with SMT enabled will be accepted\n")
+			u.Config.PlatformPolicy.RejectSmtEnabled = false
+		}
+	}
+
+	rejectDynamicPlatform, err := c.GetenvString("SQVS_PLATFORM_REJECT_DYNAMIC_PLATFORM", "Boolean value to reject "+
+		"quotes from Platform CA certified dynamic platforms")
+	if err == nil && rejectDynamicPlatform != "" {
+		u.Config.PlatformPolicy.RejectDynamicPlatform, err = strconv.ParseBool(rejectDynamicPlatform)
+		if err != nil {
+			fmt.Fprintf(u.ConsoleWriter, "SQVS_PLATFORM_REJECT_DYNAMIC_PLATFORM is not defined properly, must be true/false. Dynamic platforms will be accepted\n")
+			u.Config.PlatformPolicy.RejectDynamicPlatform = false
+		}
+	}
+
 	aasApiUrl, err := c.GetenvString("AAS_API_URL", "AAS API URL")
 	if err == nil && aasApiUrl != "" {
 		if _, err = url.ParseRequestURI(aasApiUrl); err != nil {
@@ -228,6 +665,65 @@ func (u Update_Service_Config) Run(c setup.Context) error {
 	return nil
 }
 
+// parseClientCertRoleMappings decodes the client certificate role mappings, e.g.
+// [{"subject": "sqvs-client", "roles": ["QuoteVerifier"], "context": "type=SQVS,tenant=team-a"}], and checks
+// that each mapping matches certificates and grants roles
+func parseClientCertRoleMappings(value string) ([]config.ClientCertRoleMapping, error) {
+	var mappings []config.ClientCertRoleMapping
+	if err := json.Unmarshal([]byte(value), &mappings); err != nil {
+		return nil, errors.Wrap(err, "Could not decode client certificate role mappings")
+	}
+	for i, mapping := range mappings {
+		if strings.TrimSpace(mapping.Subject) == "" && strings.TrimSpace(mapping.SAN) == "" {
+			return nil, errors.Errorf("Client certificate role mapping %d has neither subject nor SAN", i)
+		}
+		if len(mapping.Roles) == 0 {
+			return nil, errors.Errorf("Client certificate role mapping %d grants no roles", i)
+		}
+		for _, role := range mapping.Roles {
+			if strings.TrimSpace(role) == "" {
+				return nil, errors.Errorf("Client certificate role mapping %d grants an empty role", i)
+			}
+		}
+	}
+	return mappings, nil
+}
+
+// parseTenants decodes the tenants keyed by their id, e.g. {"team-a": {"endpoints": ["v2"], "dailyQuota": 1000}},
+// and checks their restrictions
+func parseTenants(value string) (map[string]config.TenantConfig, error) {
+	var tenants map[string]config.TenantConfig
+	if err := json.Unmarshal([]byte(value), &tenants); err != nil {
+		return nil, errors.Wrap(err, "Could not decode tenants")
+	}
+	for id, tenant := range tenants {
+		if strings.TrimSpace(id) == "" || strings.ContainsAny(id, ",=") {
+			return nil, errors.Errorf("Invalid tenant id %q", id)
+		}
+		for _, endpoint := range tenant.Endpoints {
+			if endpoint != constants.EndpointV1 && endpoint != constants.EndpointV2 && endpoint != constants.EndpointBatch {
+				return nil, errors.Errorf("Invalid endpoint %s of tenant %s, must be %s, %s or %s", endpoint, id,
+					constants.EndpointV1, constants.EndpointV2, constants.EndpointBatch)
+			}
+		}
+		for _, policy := range tenant.Policies {
+			if policy == "" || !verifier.IsValidReportDataBinding(policy) {
+				return nil, errors.Errorf("Invalid policy %s of tenant %s, must be a report data binding", policy, id)
+			}
+		}
+		for _, profile := range tenant.TrustProfiles {
+			if profile != constants.CollateralSourceSCS && profile != constants.CollateralSourceRequest {
+				return nil, errors.Errorf("Invalid trust profile %s of tenant %s, must be %s or %s", profile, id,
+					constants.CollateralSourceSCS, constants.CollateralSourceRequest)
+			}
+		}
+		if tenant.RateLimit < 0 || tenant.RateBurst < 0 || tenant.DailyQuota < 0 {
+			return nil, errors.Errorf("Invalid rate limit, burst or daily quota of tenant %s", id)
+		}
+	}
+	return tenants, nil
+}
+
 func (u Update_Service_Config) Validate(c setup.Context) error {
 	return nil
 }
@@ -18,6 +18,7 @@ import (
 	"intel/isecl/sqvs/v5/constants"
 	"math/big"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -201,6 +202,490 @@ func TestServerSetupInvalidLogLevelArg(t *testing.T) {
 	assert.Equal(t, logrus.InfoLevel, c.LogLevel)
 }
 
+func TestServerSetupCollateralExpiryGrace(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_COLLATERAL_EXPIRY_GRACE", "12h")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, c.CollateralExpiryGrace)
+
+	// negative grace period falls back to the default
+	os.Setenv("SQVS_COLLATERAL_EXPIRY_GRACE", "-1h")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(constants.DefaultCollateralExpiryGrace), c.CollateralExpiryGrace)
+}
+
+func TestServerSetupMinTcbEvaluationDataNumber(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_MIN_TCB_EVALUATION_DATA_NUMBER", "12")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(12), c.MinTcbEvaluationDataNumber)
+
+	// a negative minimum keeps the configured minimum
+	os.Setenv("SQVS_MIN_TCB_EVALUATION_DATA_NUMBER", "-1")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(12), c.MinTcbEvaluationDataNumber)
+}
+
+func TestServerSetupReplay(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.False(t, c.Replay.Enabled)
+	assert.Equal(t, constants.ReplayKeyQuote, c.Replay.KeyBy)
+	assert.Equal(t, constants.DefaultReplayTTL, c.Replay.TTL)
+	assert.Equal(t, constants.DefaultReplayMaxEntries, c.Replay.MaxEntries)
+	assert.Equal(t, constants.ReplayBackendMemory, c.Replay.Backend)
+	assert.Equal(t, constants.ReplayCacheFile, c.Replay.File)
+	assert.False(t, c.Replay.Strict)
+
+	os.Setenv("SQVS_REPLAY_DETECTION_ENABLED", "true")
+	os.Setenv("SQVS_REPLAY_KEY", "reportData")
+	os.Setenv("SQVS_REPLAY_TTL", "1h")
+	os.Setenv("SQVS_REPLAY_MAX_ENTRIES", "10")
+	os.Setenv("SQVS_REPLAY_BACKEND", "file")
+	os.Setenv("SQVS_REPLAY_FILE", "/tmp/replay-cache")
+	os.Setenv("SQVS_REPLAY_STRICT", "true")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.Replay.Enabled)
+	assert.Equal(t, constants.ReplayKeyReportData, c.Replay.KeyBy)
+	assert.Equal(t, time.Hour, c.Replay.TTL)
+	assert.Equal(t, 10, c.Replay.MaxEntries)
+	assert.Equal(t, constants.ReplayBackendFile, c.Replay.Backend)
+	assert.Equal(t, "/tmp/replay-cache", c.Replay.File)
+	assert.True(t, c.Replay.Strict)
+
+	os.Setenv("SQVS_REPLAY_KEY", "nonce")
+	os.Setenv("SQVS_REPLAY_TTL", "1x")
+	os.Setenv("SQVS_REPLAY_BACKEND", "redis")
+	os.Setenv("SQVS_REPLAY_STRICT", "yes")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.ReplayKeyQuote, c.Replay.KeyBy)
+	assert.Equal(t, constants.DefaultReplayTTL, c.Replay.TTL)
+	assert.Equal(t, constants.ReplayBackendMemory, c.Replay.Backend)
+	assert.False(t, c.Replay.Strict)
+}
+
+func TestServerSetupTenants(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_TENANTS", `{"team-a": {"endpoints": ["v2", "batch"], "rateLimit": 2.5, "rateBurst": 5, `+
+		`"dailyQuota": 1000, "policies": ["sha256"], "trustProfiles": ["SCS"]}}`)
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, config.TenantConfig{
+		Endpoints:     []string{constants.EndpointV2, constants.EndpointBatch},
+		RateLimit:     2.5,
+		RateBurst:     5,
+		DailyQuota:    1000,
+		Policies:      []string{constants.ReportDataBindingSHA256},
+		TrustProfiles: []string{constants.CollateralSourceSCS},
+	}, c.Tenants["team-a"])
+
+	for _, tenants := range []string{
+		`["team-a"]`,
+		`{"": {}}`,
+		`{"team-a": {"endpoints": ["tdx"]}}`,
+		`{"team-a": {"policies": ["sha1"]}}`,
+		`{"team-a": {"trustProfiles": ["any"]}}`,
+		`{"team-a": {"dailyQuota": -1}}`,
+	} {
+		os.Setenv("SQVS_TENANTS", tenants)
+		assert.Error(t, s.Run(ctx), tenants)
+	}
+}
+
+func TestServerSetupClientCertRoleMappings(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_CLIENT_CERT_ROLE_MAPPINGS", `[{"subject": "sqvs-client", "roles": ["QuoteVerifier"], `+
+		`"context": "type=SQVS,tenant=team-a"}, {"san": "verifier.example.com", "roles": ["QuoteVerifier"]}]`)
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []config.ClientCertRoleMapping{
+		{Subject: "sqvs-client", Roles: []string{constants.QuoteVerifierGroupName}, Context: "type=SQVS,tenant=team-a"},
+		{SAN: "verifier.example.com", Roles: []string{constants.QuoteVerifierGroupName}},
+	}, c.ClientCertAuth.RoleMappings)
+
+	for _, mappings := range []string{
+		`{"subject": "sqvs-client"}`,
+		`[{"roles": ["QuoteVerifier"]}]`,
+		`[{"subject": "sqvs-client"}]`,
+		`[{"subject": "sqvs-client", "roles": [""]}]`,
+	} {
+		os.Setenv("SQVS_CLIENT_CERT_ROLE_MAPPINGS", mappings)
+		assert.Error(t, s.Run(ctx), mappings)
+	}
+}
+
+func TestServerSetupVerificationTimeout(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultVerificationTimeout, c.VerificationTimeout)
+
+	os.Setenv("SQVS_VERIFICATION_TIMEOUT", "5s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Second, c.VerificationTimeout)
+
+	// verification must end before the server write timeout
+	os.Setenv("SQVS_VERIFICATION_TIMEOUT", "15s")
+	err = s.Run(ctx)
+	assert.Error(t, err)
+
+	os.Setenv("SQVS_SERVER_WRITE_TIMEOUT", "30s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Second, c.VerificationTimeout)
+	os.Unsetenv("SQVS_SERVER_WRITE_TIMEOUT")
+
+	// zero disables the deadline
+	os.Setenv("SQVS_VERIFICATION_TIMEOUT", "0s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), c.VerificationTimeout)
+
+	os.Setenv("SQVS_VERIFICATION_TIMEOUT", "invalid")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultVerificationTimeout, c.VerificationTimeout)
+}
+
+func TestServerSetupShutdownTimeout(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultShutdownTimeout, c.ShutdownTimeout)
+
+	os.Setenv("SQVS_SHUTDOWN_TIMEOUT", "2m")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, c.ShutdownTimeout)
+
+	os.Setenv("SQVS_SHUTDOWN_TIMEOUT", "0s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultShutdownTimeout, c.ShutdownTimeout)
+}
+
+func TestServerSetupShutdownDelay(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultShutdownDelay, c.ShutdownDelay)
+
+	os.Setenv("SQVS_SHUTDOWN_DELAY", "15s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Second, c.ShutdownDelay)
+
+	// zero disables the delay
+	os.Setenv("SQVS_SHUTDOWN_DELAY", "0s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), c.ShutdownDelay)
+
+	os.Setenv("SQVS_SHUTDOWN_DELAY", "-1s")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.DefaultShutdownDelay, c.ShutdownDelay)
+}
+
+func TestServerSetupCollateralRefresh(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_COLLATERAL_REFRESH_ENABLED", "true")
+	os.Setenv("SQVS_COLLATERAL_PREFETCH_FMSPCS", "00906ED50000, invalid,20606a000000")
+	os.Setenv("SQVS_COLLATERAL_REFRESH_AHEAD", "-1h")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.CollateralRefresh.Enabled)
+	assert.Equal(t, []string{"00906ed50000", "20606a000000"}, c.CollateralRefresh.FMSPCs)
+	assert.Equal(t, constants.DefaultCollateralRefreshPeriod, c.CollateralRefresh.Interval)
+	assert.Equal(t, constants.DefaultCollateralRefreshAhead, c.CollateralRefresh.RefreshAhead)
+	assert.Equal(t, constants.DefaultCollateralMaxAge, c.CollateralRefresh.MaxAge)
+	assert.False(t, c.CollateralStore.Enabled)
+	assert.Equal(t, constants.CollateralDir, c.CollateralStore.Dir)
+
+	os.Setenv("SQVS_COLLATERAL_STORE_ENABLED", "true")
+	os.Setenv("SQVS_COLLATERAL_DIR", "/var/lib/sqvs/collateral")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.CollateralStore.Enabled)
+	assert.Equal(t, "/var/lib/sqvs/collateral", c.CollateralStore.Dir)
+}
+
+func TestServerSetupPlatformPolicy(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_PLATFORM_REJECT_SMT_ENABLED", "true")
+	os.Setenv("SQVS_PLATFORM_REJECT_DYNAMIC_PLATFORM", "invalid")
+	os.Setenv("SQVS_INCLUDE_PPID", "true")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.True(t, c.PlatformPolicy.RejectSmtEnabled)
+	// invalid values keep the platform accepted
+	assert.False(t, c.PlatformPolicy.RejectDynamicPlatform)
+	assert.True(t, c.IncludePPID)
+}
+
+func TestServerSetupGRPCPort(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	defer os.Clearenv()
+
+	c := *config.Load("testconfig.yml")
+	defer os.Remove("testconfig.yml")
+
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	_ = os.Setenv("SQVS_GRPC_PORT", "12001")
+	err = s.Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 12001, c.GRPCPort)
+
+	// the gRPC API can not share the port of the REST API
+	_ = os.Setenv("SQVS_GRPC_PORT", strconv.Itoa(c.Port))
+	err = s.Run(ctx)
+	assert.Error(t, err)
+}
+
 func TestServerSetupRootCertFailure(t *testing.T) {
 	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
 	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
@@ -324,3 +809,53 @@ func TestServerSetupNegativeCases(t *testing.T) {
 	assert.NotEqual(t, err, nil)
 
 }
+
+func TestServerSetupRateLimitEnv(t *testing.T) {
+	os.Setenv("AAS_API_URL", "http://localhost:8444/aas/v1")
+	os.Setenv("SCS_BASE_URL", "http://localhost:12000/scs/v1")
+	os.Setenv("SQVS_CLIENT_RATE_LIMIT", "2.5")
+	os.Setenv("SQVS_CLIENT_RATE_BURST", "5")
+	os.Setenv("SQVS_GLOBAL_RATE_LIMIT", "invalid")
+	os.Setenv("SQVS_MAX_CONCURRENT_VERIFICATIONS", "8")
+	os.Setenv("SQVS_VERIFICATION_QUEUE_TIMEOUT", "2s")
+	defer os.Clearenv()
+
+	c := config.Configuration{}
+	s := Update_Service_Config{
+		Flags:                    nil,
+		Config:                   &c,
+		ConsoleWriter:            os.Stdout,
+		TrustedSGXRootCAFilePath: rootCACertFile,
+	}
+	err := testGetRootCACert()
+	if err != nil {
+		t.Error("Cert generation failed")
+	}
+	defer func() {
+		_ = os.Remove(rootCACertFile)
+	}()
+	_ = os.Setenv("SGX_TRUSTED_ROOT_CA_PATH", rootCACertFile)
+
+	ctx := setup.Context{}
+	err = s.Run(ctx)
+	if err != nil {
+		assert.Contains(t, err.Error(), config.ErrNoConfigFile.Error())
+	}
+	assert.Equal(t, 2.5, c.RateLimit.ClientRate)
+	assert.Equal(t, 5, c.RateLimit.ClientBurst)
+	assert.Equal(t, float64(0), c.RateLimit.GlobalRate)
+	assert.Equal(t, 8, c.RateLimit.MaxConcurrent)
+	assert.Equal(t, constants.DefaultMaxQueuedVerify, c.RateLimit.MaxQueued)
+	assert.Equal(t, 2*time.Second, c.RateLimit.QueueTimeout)
+
+	// configured limits are kept when setup is run again
+	os.Unsetenv("SQVS_MAX_CONCURRENT_VERIFICATIONS")
+	c.RateLimit.MaxConcurrent = -1
+	c.RateLimit.MaxQueued = -1
+	err = s.Run(ctx)
+	if err != nil {
+		assert.Contains(t, err.Error(), config.ErrNoConfigFile.Error())
+	}
+	assert.Equal(t, -1, c.RateLimit.MaxConcurrent)
+	assert.Equal(t, -1, c.RateLimit.MaxQueued)
+}